@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/speakeasy-api/rest-template-go/internal/core/logging"
+	"github.com/speakeasy-api/rest-template-go/internal/events/schema"
+	"go.uber.org/zap"
+)
+
+const defaultReleasedPath = "proto/released/events.binpb"
+
+func main() {
+	ctx := context.Background()
+
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	fs := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	path := fs.String("released", defaultReleasedPath, "path to the previously released descriptor set")
+	_ = fs.Parse(os.Args[2:])
+
+	switch os.Args[1] {
+	case "check":
+		// Compare the schemas compiled into this binary against the last released schemas
+		previous, err := schema.Load(*path)
+		if err != nil {
+			logging.From(ctx).Fatal("failed to load released schemas", zap.Error(err))
+		}
+
+		violations := schema.Check(previous, schema.Current())
+		for _, v := range violations {
+			fmt.Fprintln(os.Stderr, v.String())
+		}
+
+		if len(violations) > 0 {
+			logging.From(ctx).Fatal("breaking schema changes detected", zap.Int("violations", len(violations)))
+		}
+
+		logging.From(ctx).Info("schemas are compatible with the released schemas")
+	case "release":
+		// Record the current schemas as released so future changes are checked against them
+		if err := schema.Save(*path, schema.Current()); err != nil {
+			logging.From(ctx).Fatal("failed to save released schemas", zap.Error(err))
+		}
+
+		logging.From(ctx).Info("released schemas saved", zap.String("path", *path))
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: schema <check|release> [-released path]")
+}
@@ -7,12 +7,24 @@ import (
 	"github.com/speakeasy-api/rest-template-go/internal/config"
 	"github.com/speakeasy-api/rest-template-go/internal/core/app"
 	"github.com/speakeasy-api/rest-template-go/internal/core/drivers/psql"
+	"github.com/speakeasy-api/rest-template-go/internal/core/listeners/grpc"
 	"github.com/speakeasy-api/rest-template-go/internal/core/listeners/http"
 	"github.com/speakeasy-api/rest-template-go/internal/core/logging"
+	"github.com/speakeasy-api/rest-template-go/internal/core/metrics"
+	"github.com/speakeasy-api/rest-template-go/internal/core/ratelimit"
+	ratelimitstore "github.com/speakeasy-api/rest-template-go/internal/core/ratelimit/store"
+	"github.com/speakeasy-api/rest-template-go/internal/core/tracing"
 	"github.com/speakeasy-api/rest-template-go/internal/events"
+	"github.com/speakeasy-api/rest-template-go/internal/events/consumer"
+	eventsstore "github.com/speakeasy-api/rest-template-go/internal/events/store"
+	"github.com/speakeasy-api/rest-template-go/internal/events/stream"
+	docstransport "github.com/speakeasy-api/rest-template-go/internal/transport/docs"
+	graphqltransport "github.com/speakeasy-api/rest-template-go/internal/transport/graphql"
+	grpctransport "github.com/speakeasy-api/rest-template-go/internal/transport/grpc"
 	httptransport "github.com/speakeasy-api/rest-template-go/internal/transport/http"
 	"github.com/speakeasy-api/rest-template-go/internal/users"
 	"github.com/speakeasy-api/rest-template-go/internal/users/store"
+	"github.com/speakeasy-api/rest-template-go/openapi"
 	"go.uber.org/zap"
 )
 
@@ -27,6 +39,11 @@ func appStart(ctx context.Context, a *app.App) ([]app.Listener, error) {
 		return nil, err
 	}
 
+	// Export traces as configured, enabled first so it is shut down last and flushes the spans of everything else
+	if err := tracing.EnableTracing(ctx, a.Name, cfg.Tracing, a); err != nil {
+		return nil, err
+	}
+
 	// Connect to the postgres DB
 	db, err := initDatabase(ctx, cfg, a)
 	if err != nil {
@@ -46,21 +63,97 @@ func appStart(ctx context.Context, a *app.App) ([]app.Listener, error) {
 
 	// Instantiate and connect all our classes
 	us := store.New(db.GetDB())
-	e := events.New()
+	es := eventsstore.New(db.GetDB())
+	e, err := events.NewFromConfig(cfg.Events, es, db.GetDB())
+	if err != nil {
+		return nil, err
+	}
+
+	// Replay any events spooled while sinks were unavailable, including those from previous runs
+	e.Start(ctx)
+	a.OnShutdown(func() {
+		logging.From(ctx).Info("shutting down events")
+		if err := e.Close(ctx); err != nil {
+			logging.From(ctx).Error("failed to close events", zap.Error(err))
+		}
+	})
 	u := users.New(us, e)
 
-	httpServer := httptransport.New(u, db.GetDB())
+	// Expose the depth of the dead letter store for alerting, the DB pool and the events produced
+	if err := metrics.Register(append(events.Collectors(), events.NewDeadLetterCollector(es), db.Collector())...); err != nil {
+		return nil, err
+	}
+
+	// Fan out user events published by any instance to clients streaming them
+	hub := stream.New(cfg.Stream, db.NewListener(ctx))
+
+	// Audit user events published by any instance, events that can't be handled are stored as dead letters so they can
+	// be redriven with the admin API
+	c := consumer.New(cfg.Consumer, consumer.NewNotifySource(db.NewListener(ctx)), es, es)
+	c.Handle(events.TopicUsers, consumer.UserEventHandler(users.AuditUserEvent))
+
+	httpServer := httptransport.New(u, db.GetDB(), hub)
+	adminServer := httptransport.NewAdmin(cfg.AdminAPI, e)
+	graphqlServer, err := graphqltransport.New(cfg.GraphQL, u)
+	if err != nil {
+		return nil, err
+	}
+
+	docsServer, err := docstransport.New(cfg.Docs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Share rate limits between instances if configured, otherwise each instance limits clients on its own
+	var rateLimits ratelimit.Backend = ratelimit.NewMemoryBackend(cfg.HTTP.RateLimit.MaxBuckets)
+	if cfg.HTTP.RateLimit.Backend == ratelimit.BackendPostgres {
+		rs := ratelimitstore.New(db.GetDB(), cfg.HTTP.RateLimit.MaxBuckets)
+		rs.Start(ctx)
+		a.OnShutdown(func() {
+			logging.From(ctx).Info("stopping rate limit pruning")
+			rs.Close()
+		})
+		rateLimits = rs
+	}
+
+	// Serve metrics on their own port if configured so they aren't exposed with the API
+	services := []http.Service{httpServer, graphqlServer, docsServer}
+	if cfg.Metrics.Port == "" {
+		services = append(services, metrics.Service{})
+	}
 
 	// Create a HTTP server
-	h, err := http.New(httpServer, cfg.HTTP)
+	h, err := http.New(cfg.HTTP, rateLimits, openapi.Spec, services...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a HTTP server for operational requests on its own port so it isn't exposed with the API
+	admin, err := http.New(cfg.Admin, nil, nil, adminServer)
 	if err != nil {
 		return nil, err
 	}
 
-	// Start listening for HTTP requests
-	return []app.Listener{
+	// Create a gRPC server exposing the same operations on its own port
+	g := grpc.New(cfg.GRPC, grpctransport.New(u))
+	a.OnShutdown(func() {
+		logging.From(ctx).Info("shutting down grpc server")
+		g.Stop()
+	})
+
+	listeners := []app.Listener{
 		h,
-	}, nil
+		admin,
+		g,
+		hub,
+		c,
+	}
+	if cfg.Metrics.Port != "" {
+		listeners = append(listeners, metrics.NewServer(cfg.Metrics))
+	}
+
+	// Start listening for HTTP and gRPC requests
+	return listeners, nil
 }
 
 func initDatabase(ctx context.Context, cfg *config.Config, a *app.App) (*psql.Driver, error) {
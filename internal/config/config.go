@@ -10,6 +10,12 @@ import (
 	"github.com/speakeasy-api/rest-template-go/internal/core/config"
 	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
 	"github.com/speakeasy-api/rest-template-go/internal/core/logging"
+	"github.com/speakeasy-api/rest-template-go/internal/events"
+	"github.com/speakeasy-api/rest-template-go/internal/events/consumer"
+	"github.com/speakeasy-api/rest-template-go/internal/events/stream"
+	"github.com/speakeasy-api/rest-template-go/internal/transport/docs"
+	"github.com/speakeasy-api/rest-template-go/internal/transport/graphql"
+	"github.com/speakeasy-api/rest-template-go/internal/transport/http"
 	"go.uber.org/zap"
 	"gopkg.in/yaml.v2"
 )
@@ -35,6 +41,13 @@ var (
 // Config represents the configuration of our application.
 type Config struct {
 	config.AppConfig `yaml:",inline"`
+	Events           events.Config   `yaml:"events"`
+	Consumer         consumer.Config `yaml:"consumer"`
+	Stream           stream.Config   `yaml:"stream"`
+	GraphQL          graphql.Config  `yaml:"graphql"`
+	Docs             docs.Config     `yaml:"docs"`
+	// AdminAPI is only configured by environment variables, the listener serving it is configured under admin.
+	AdminAPI http.AdminConfig `yaml:"-"`
 }
 
 // Load loads the configuration from the config/config.yaml file.
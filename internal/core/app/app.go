@@ -9,7 +9,6 @@ import (
 	"syscall"
 
 	"github.com/speakeasy-api/rest-template-go/internal/core/logging"
-	"github.com/speakeasy-api/rest-template-go/internal/core/tracing"
 	"go.uber.org/zap"
 )
 
@@ -45,10 +44,6 @@ func Start(onStart OnStart) {
 
 	logging.From(ctx).Info("app starting...")
 
-	if err := tracing.EnableTracing(ctx, a.Name, a); err != nil {
-		logging.From(ctx).Fatal("failed to enable tracing", zap.Error(err))
-	}
-
 	listeners, err := onStart(ctx, a)
 	if err != nil {
 		logging.From(ctx).Fatal("failed to start app", zap.Error(err))
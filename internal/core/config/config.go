@@ -5,7 +5,10 @@ import (
 
 	"github.com/speakeasy-api/rest-template-go/internal/core/drivers/psql"
 	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	"github.com/speakeasy-api/rest-template-go/internal/core/listeners/grpc"
 	"github.com/speakeasy-api/rest-template-go/internal/core/listeners/http"
+	"github.com/speakeasy-api/rest-template-go/internal/core/metrics"
+	"github.com/speakeasy-api/rest-template-go/internal/core/tracing"
 	"gopkg.in/yaml.v2"
 )
 
@@ -18,8 +21,12 @@ const (
 
 // AppConfig represents the configuration of our application.
 type AppConfig struct {
-	HTTP http.Config `yaml:"http"`
-	PSQL psql.Config `yaml:"psql"`
+	HTTP    http.Config    `yaml:"http"`
+	Admin   http.Config    `yaml:"admin"`
+	GRPC    grpc.Config    `yaml:"grpc"`
+	PSQL    psql.Config    `yaml:"psql"`
+	Metrics metrics.Config `yaml:"metrics"`
+	Tracing tracing.Config `yaml:"tracing"`
 }
 
 // Load loads the configuration from a yaml file on disk.
@@ -2,10 +2,15 @@ package psql
 
 import (
 	"context"
+	"time"
 
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq" // imports the postgres driver
+	"github.com/lib/pq" // also registers the postgres driver
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	"github.com/speakeasy-api/rest-template-go/internal/core/logging"
+	"go.uber.org/zap"
 )
 
 const (
@@ -15,6 +20,11 @@ const (
 	ErrClose = errors.Error("failed to close postgres db connection")
 )
 
+const (
+	listenerMinReconnectInterval = time.Second
+	listenerMaxReconnectInterval = time.Minute
+)
+
 // Config represents the configuration for our postgres database.
 type Config struct {
 	DSN string `env:"POSTGRES_DSN" validate:"required"`
@@ -58,3 +68,18 @@ func (d *Driver) Close(ctx context.Context) error {
 func (d *Driver) GetDB() *sqlx.DB {
 	return d.db
 }
+
+// Collector returns a prometheus collector exposing the statistics of the connection pool.
+func (d *Driver) Collector() prometheus.Collector {
+	return collectors.NewDBStatsCollector(d.db.DB, "postgres")
+}
+
+// NewListener returns a listener for postgres notifications on its own dedicated connection,
+// the listener will reconnect automatically if the connection is lost.
+func (d *Driver) NewListener(ctx context.Context) *pq.Listener {
+	return pq.NewListener(d.cfg.DSN, listenerMinReconnectInterval, listenerMaxReconnectInterval, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			logging.From(ctx).Error("postgres listener connection event", zap.Error(err), zap.Int("event", int(event)))
+		}
+	})
+}
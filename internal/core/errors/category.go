@@ -0,0 +1,66 @@
+package errors
+
+// Category is an enum of the broad kinds of errors, transports use it to determine how an error is
+// reported such as the status code of a HTTP response.
+type Category string
+
+const (
+	// CategoryUnknown is the category of unexpected errors.
+	CategoryUnknown Category = "unknown"
+	// CategoryInvalidRequest is the category of errors caused by requests that can't be parsed.
+	CategoryInvalidRequest Category = "invalid_request"
+	// CategoryValidation is the category of errors caused by requests that don't pass validation.
+	CategoryValidation Category = "validation"
+	// CategoryNotFound is the category of errors caused by a resource not being found.
+	CategoryNotFound Category = "not_found"
+	// CategoryConflict is the category of errors caused by a request conflicting with the current state of a resource.
+	CategoryConflict Category = "conflict"
+	// CategoryUnauthenticated is the category of errors caused by requests without valid credentials.
+	CategoryUnauthenticated Category = "unauthenticated"
+	// CategoryForbidden is the category of errors caused by requests the caller isn't allowed to make.
+	CategoryForbidden Category = "forbidden"
+	// CategoryUnavailable is the category of errors caused by a dependency being temporarily unavailable.
+	CategoryUnavailable Category = "unavailable"
+	// CategoryRateLimited is the category of errors caused by the caller exceeding a rate limit.
+	CategoryRateLimited Category = "rate_limited"
+)
+
+// categories maps the generic errors to their category so errors that wrap them are categorized.
+var categories = map[Error]Category{
+	ErrUnknown:         CategoryUnknown,
+	ErrInvalidRequest:  CategoryInvalidRequest,
+	ErrValidation:      CategoryValidation,
+	ErrNotFound:        CategoryNotFound,
+	ErrConflict:        CategoryConflict,
+	ErrUnauthenticated: CategoryUnauthenticated,
+	ErrForbidden:       CategoryForbidden,
+	ErrUnavailable:     CategoryUnavailable,
+	ErrRateLimited:     CategoryRateLimited,
+}
+
+// CategoryOf returns the category of the first error in the chain that has one, either a StructuredError
+// or one of the generic errors, errors with neither are CategoryUnknown.
+func CategoryOf(err error) Category {
+	for err != nil {
+		switch e := err.(type) { //nolint:errorlint
+		case *StructuredError:
+			return e.category
+		case Error:
+			if c, ok := categories[e]; ok {
+				return c
+			}
+		case wrappedError:
+			if c, ok := categories[Error(e.msg)]; ok {
+				return c
+			}
+		}
+
+		u, ok := err.(interface{ Unwrap() error }) //nolint:errorlint
+		if !ok {
+			break
+		}
+		err = u.Unwrap()
+	}
+
+	return CategoryUnknown
+}
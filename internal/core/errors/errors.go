@@ -3,7 +3,6 @@ package errors
 import (
 	"errors"
 	"fmt"
-	"reflect"
 	"strings"
 )
 
@@ -16,6 +15,16 @@ const (
 	ErrValidation = Error("err_validation: failed validation")
 	// ErrNotFound is returned when the requested resource is not found.
 	ErrNotFound = Error("err_not_found: not found")
+	// ErrConflict is returned when the request conflicts with the current state of the resource.
+	ErrConflict = Error("err_conflict: conflicts with current state")
+	// ErrUnauthenticated is returned when the request doesn't have valid credentials.
+	ErrUnauthenticated = Error("err_unauthenticated: unauthenticated")
+	// ErrForbidden is returned when the caller isn't allowed to make the request.
+	ErrForbidden = Error("err_forbidden: forbidden")
+	// ErrUnavailable is returned when a dependency is temporarily unavailable.
+	ErrUnavailable = Error("err_unavailable: temporarily unavailable")
+	// ErrRateLimited is returned when the caller has made too many requests.
+	ErrRateLimited = Error("err_rate_limited: too many requests")
 )
 
 // ErrSeperator is used to determine the boundaries of the errors in the hierarchy.
@@ -29,21 +38,44 @@ func (s Error) Error() string {
 	return string(s)
 }
 
+// Code returns the machine readable code of the error, the part of its message before the first ": ".
+func (s Error) Code() string {
+	code, _, _ := strings.Cut(string(s), ": ")
+	return code
+}
+
+// Message returns the human readable part of the error's message following its code.
+func (s Error) Message() string {
+	code, msg, found := strings.Cut(string(s), ": ")
+	if !found {
+		return code
+	}
+	return msg
+}
+
+// Field returns a FieldError describing this error occurring on the provided field.
+func (s Error) Field(field string) FieldError {
+	return FieldError{
+		Field:   field,
+		Code:    s.Code(),
+		Message: s.Message(),
+	}
+}
+
 // Is implements https://golang.org/pkg/errors/#Is allowing a Error
 // to check it is the same even when wrapped. This implementation only
-// checks the top most wrapped error.
+// checks this error, errors.Is checks the rest of the chain via Unwrap.
 func (s Error) Is(target error) bool {
 	return s.Error() == target.Error() || strings.HasPrefix(target.Error(), s.Error()+ErrSeperator)
 }
 
 // As implements As(interface{}) bool which is used by errors.As
 // (https://golang.org/pkg/errors/#As) allowing a Error to be set as the
-// target if it matches the specified target type. This implementation
-// only checks the top most wrapped error.
+// target if the target is an Error. This implementation only checks this
+// error, errors.As checks the rest of the chain via Unwrap.
 func (s Error) As(target interface{}) bool {
-	v := reflect.ValueOf(target).Elem()
-	if v.Type().Name() == "Error" && v.CanSet() {
-		v.SetString(string(s))
+	if t, ok := target.(*Error); ok {
+		*t = s
 		return true
 	}
 	return false
@@ -101,3 +133,23 @@ func Is(err error, target error) bool {
 func As(err error, target any) bool {
 	return errors.As(err, target)
 }
+
+// PublicMessage returns the message of the outermost error defined by this package in the chain, which is safe to
+// return to clients. The errors it wraps are left out as they can describe internals such as the queries that
+// failed, and chains without an error defined by this package are described by ErrUnknown.
+func PublicMessage(err error) string {
+	for err != nil {
+		switch e := err.(type) { //nolint:errorlint
+		case Error:
+			return string(e)
+		case wrappedError:
+			return e.msg
+		case *StructuredError:
+			return e.code + ": " + e.message
+		}
+
+		err = errors.Unwrap(err)
+	}
+
+	return string(ErrUnknown)
+}
@@ -0,0 +1,53 @@
+package errors_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublicMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			name: "const error",
+			err:  errors.ErrNotFound,
+			want: "err_not_found: not found",
+		},
+		{
+			name: "wrapped cause is left out",
+			err:  errInvalidEmail.Wrap(errors.ErrValidation.Wrap(errors.New(`pq: new row for relation "users" violates check constraint`))),
+			want: "invalid_email: email is invalid",
+		},
+		{
+			name: "structured error",
+			err:  errEmailAlreadyUsed.Structured(errors.CategoryConflict).Wrap(errors.New("unique_violation")),
+			want: "email_already_used: email is already in use",
+		},
+		{
+			name: "error with fields",
+			err:  errors.WithFieldErrors(errInvalidEmail.Wrap(errors.ErrValidation), errInvalidEmail.Field("email")),
+			want: "invalid_email: email is invalid",
+		},
+		{
+			name: "error wrapped by another package",
+			err:  fmt.Errorf("select * from users where email = 'test@test.com': %w", errors.ErrNotFound),
+			want: "err_not_found: not found",
+		},
+		{
+			name: "error not defined by this package",
+			err:  errors.New("dial tcp 10.0.0.1:5432: connection refused"),
+			want: "err_unknown: unknown error occurred",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, errors.PublicMessage(tt.err))
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package errors
+
+// FieldError describes why a single field of a request failed validation.
+type FieldError struct {
+	// Field is the path of the field within the request such as filters[0].value.
+	Field string `json:"field"`
+	// Code is a machine readable code for the failure.
+	Code string `json:"code"`
+	// Message is a human readable description of the failure.
+	Message string `json:"message"`
+}
+
+// fieldsError is an internal error type attaching the fields that caused an error to it.
+type fieldsError struct {
+	cause  error
+	fields []FieldError
+}
+
+// WithFieldErrors attaches details of the fields that caused the error to it, the error
+// is otherwise unchanged so can still be compared against const Errors.
+func WithFieldErrors(err error, fields ...FieldError) error {
+	return fieldsError{cause: err, fields: fields}
+}
+
+func (f fieldsError) Error() string {
+	return f.cause.Error()
+}
+
+// Implements https://golang.org/pkg/errors/#Unwrap allow the cause
+// error to be retrieved.
+func (f fieldsError) Unwrap() error {
+	return f.cause
+}
+
+// FieldErrors returns the details of all the fields attached to errors in the chain.
+func FieldErrors(err error) []FieldError {
+	var fields []FieldError
+
+	for err != nil {
+		if f, ok := err.(fieldsError); ok { //nolint:errorlint
+			fields = append(fields, f.fields...)
+		}
+
+		u, ok := err.(interface{ Unwrap() error }) //nolint:errorlint
+		if !ok {
+			break
+		}
+		err = u.Unwrap()
+	}
+
+	return fields
+}
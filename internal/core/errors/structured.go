@@ -0,0 +1,163 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+)
+
+const maxStackDepth = 32
+
+// StructuredError is an error carrying a stable machine readable code, the category used to report it,
+// arbitrary details about the failure and optionally the stack where it occurred. It is immutable, each
+// method returning a modified copy, so can be declared once and reused.
+type StructuredError struct {
+	code     string
+	message  string
+	category Category
+	details  map[string]any
+	stack    []uintptr
+	cause    error
+}
+
+// NewStructured will instantiate a new StructuredError.
+func NewStructured(category Category, code, message string) *StructuredError {
+	return &StructuredError{
+		code:     code,
+		message:  message,
+		category: category,
+	}
+}
+
+// Structured returns a StructuredError with the code and message of this error in the category.
+func (s Error) Structured(category Category) *StructuredError {
+	return NewStructured(category, s.Code(), s.Message())
+}
+
+// Code returns the machine readable code of the error.
+func (e *StructuredError) Code() string {
+	return e.code
+}
+
+// Message returns the human readable message of the error.
+func (e *StructuredError) Message() string {
+	return e.message
+}
+
+// Category returns the category of the error.
+func (e *StructuredError) Category() Category {
+	return e.category
+}
+
+// Details returns the details of the failure attached to the error.
+func (e *StructuredError) Details() map[string]any {
+	details := make(map[string]any, len(e.details))
+	for k, v := range e.details {
+		details[k] = v
+	}
+	return details
+}
+
+// Stack returns the stack captured by WithStack, or nil if it wasn't captured.
+func (e *StructuredError) Stack() []runtime.Frame {
+	if len(e.stack) == 0 {
+		return nil
+	}
+
+	var stack []runtime.Frame
+
+	frames := runtime.CallersFrames(e.stack)
+	for {
+		f, more := frames.Next()
+		stack = append(stack, f)
+		if !more {
+			break
+		}
+	}
+
+	return stack
+}
+
+// WithDetail returns a copy of the error with the detail added.
+func (e *StructuredError) WithDetail(key string, value any) *StructuredError {
+	c := e.clone()
+	c.details = e.Details()
+	c.details[key] = value
+	return c
+}
+
+// WithStack returns a copy of the error with the stack of the caller captured.
+func (e *StructuredError) WithStack() *StructuredError {
+	c := e.clone()
+	c.stack = make([]uintptr, maxStackDepth)
+	c.stack = c.stack[:runtime.Callers(2, c.stack)]
+	return c
+}
+
+// Wrap returns a copy of the error wrapping the cause.
+func (e *StructuredError) Wrap(err error) *StructuredError {
+	c := e.clone()
+	c.cause = err
+	return c
+}
+
+func (e *StructuredError) Error() string {
+	msg := e.code + ": " + e.message
+	if e.cause != nil {
+		return msg + ErrSeperator + e.cause.Error()
+	}
+	return msg
+}
+
+// Is implements https://golang.org/pkg/errors/#Is matching errors with the same code, either other
+// StructuredErrors or const Errors, and the generic error of its category so code checking for the
+// generic errors keeps working. The rest of the chain is checked by errors.Is via Unwrap.
+func (e *StructuredError) Is(target error) bool {
+	switch t := target.(type) { //nolint:errorlint
+	case *StructuredError:
+		return e.code == t.code
+	case Error:
+		c, ok := categories[t]
+		return e.code == t.Code() || (ok && c == e.category)
+	}
+	return false
+}
+
+// Implements https://golang.org/pkg/errors/#Unwrap allow the cause
+// error to be retrieved.
+func (e *StructuredError) Unwrap() error {
+	return e.cause
+}
+
+// Format implements fmt.Formatter so the details and stack are included when formatted with %+v.
+func (e *StructuredError) Format(s fmt.State, verb rune) {
+	if verb != 'v' || !s.Flag('+') {
+		_, _ = io.WriteString(s, e.Error())
+		return
+	}
+
+	_, _ = io.WriteString(s, e.code+": "+e.message)
+	keys := make([]string, 0, len(e.details))
+	for k := range e.details {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		_, _ = fmt.Fprintf(s, " %s=%v", k, e.details[k])
+	}
+
+	for _, f := range e.Stack() {
+		_, _ = fmt.Fprintf(s, "\n\t%s\n\t\t%s:%d", f.Function, f.File, f.Line)
+	}
+
+	if e.cause != nil {
+		_, _ = fmt.Fprintf(s, "%s%+v", ErrSeperator, e.cause)
+	}
+}
+
+func (e *StructuredError) clone() *StructuredError {
+	c := *e
+	return &c
+}
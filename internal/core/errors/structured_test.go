@@ -0,0 +1,156 @@
+package errors_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	errEmailAlreadyUsed = errors.Error("email_already_used: email is already in use")
+	errInvalidEmail     = errors.Error("invalid_email: email is invalid")
+)
+
+func TestCategoryOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want errors.Category
+	}{
+		{
+			name: "generic error",
+			err:  errors.ErrNotFound,
+			want: errors.CategoryNotFound,
+		},
+		{
+			name: "wrapped generic error",
+			err:  errInvalidEmail.Wrap(errors.ErrValidation.Wrap(errors.New("check_violation"))),
+			want: errors.CategoryValidation,
+		},
+		{
+			name: "structured error",
+			err:  errEmailAlreadyUsed.Structured(errors.CategoryConflict).Wrap(errors.New("unique_violation")),
+			want: errors.CategoryConflict,
+		},
+		{
+			name: "first category in the chain wins",
+			err:  errors.WithFieldErrors(errors.ErrUnknown.Wrap(errors.NewStructured(errors.CategoryUnavailable, "db_down", "database is down"))),
+			want: errors.CategoryUnknown,
+		},
+		{
+			name: "structured error with fields",
+			err:  errors.WithFieldErrors(errEmailAlreadyUsed.Structured(errors.CategoryConflict), errEmailAlreadyUsed.Field("email")),
+			want: errors.CategoryConflict,
+		},
+		{
+			name: "uncategorized error",
+			err:  errors.New("connection refused"),
+			want: errors.CategoryUnknown,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, errors.CategoryOf(tt.err))
+		})
+	}
+}
+
+func TestStructuredError_Is(t *testing.T) {
+	cause := errors.New("unique_violation")
+	err := errors.ErrUnknown.Wrap(errEmailAlreadyUsed.Structured(errors.CategoryConflict).Wrap(cause))
+
+	tests := []struct {
+		name   string
+		target error
+		want   bool
+	}{
+		{
+			name:   "same code",
+			target: errEmailAlreadyUsed,
+			want:   true,
+		},
+		{
+			name:   "structured error with same code",
+			target: errors.NewStructured(errors.CategoryValidation, "email_already_used", "a different message"),
+			want:   true,
+		},
+		{
+			name:   "generic error of category",
+			target: errors.ErrConflict,
+			want:   true,
+		},
+		{
+			name:   "top most error",
+			target: errors.ErrUnknown,
+			want:   true,
+		},
+		{
+			name:   "cause",
+			target: cause,
+			want:   true,
+		},
+		{
+			name:   "different code",
+			target: errInvalidEmail,
+			want:   false,
+		},
+		{
+			name:   "generic error of other category",
+			target: errors.ErrValidation,
+			want:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, errors.Is(err, tt.target))
+		})
+	}
+}
+
+func TestStructuredError_As(t *testing.T) {
+	err := errors.WithFieldErrors(errors.ErrUnknown.Wrap(errEmailAlreadyUsed.Structured(errors.CategoryConflict).WithDetail("constraint", "email_unique")))
+
+	var structured *errors.StructuredError
+	require.True(t, errors.As(err, &structured))
+	assert.Equal(t, "email_already_used", structured.Code())
+	assert.Equal(t, "email is already in use", structured.Message())
+	assert.Equal(t, errors.CategoryConflict, structured.Category())
+	assert.Equal(t, map[string]any{"constraint": "email_unique"}, structured.Details())
+
+	var constErr errors.Error
+	require.True(t, errors.As(err, &constErr))
+	assert.Equal(t, errors.ErrUnknown, constErr)
+}
+
+func TestStructuredError_Immutable(t *testing.T) {
+	base := errors.NewStructured(errors.CategoryValidation, "invalid_email", "email is invalid")
+
+	withDetail := base.WithDetail("value", "test@@test.com")
+	withStack := base.WithStack()
+	wrapped := base.Wrap(errors.New("check_violation"))
+
+	assert.Empty(t, base.Details())
+	assert.Nil(t, base.Stack())
+	assert.Equal(t, "invalid_email: email is invalid", base.Error())
+
+	assert.Equal(t, map[string]any{"value": "test@@test.com"}, withDetail.Details())
+	assert.NotEmpty(t, withStack.Stack())
+	assert.Equal(t, "invalid_email: email is invalid -- check_violation", wrapped.Error())
+}
+
+func TestStructuredError_Format(t *testing.T) {
+	err := errors.NewStructured(errors.CategoryValidation, "invalid_email", "email is invalid").
+		WithDetail("value", "test@@test.com").
+		WithStack().
+		Wrap(errors.New("check_violation"))
+
+	assert.Equal(t, "invalid_email: email is invalid -- check_violation", fmt.Sprintf("%v", err))
+
+	verbose := fmt.Sprintf("%+v", err)
+	assert.Contains(t, verbose, "invalid_email: email is invalid value=test@@test.com")
+	assert.Contains(t, verbose, "errors_test.TestStructuredError_Format")
+	assert.Contains(t, verbose, " -- check_violation")
+}
@@ -0,0 +1,99 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	"github.com/speakeasy-api/rest-template-go/internal/core/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+const (
+	// ErrListen is the error returned when the server can't listen on its port.
+	ErrListen = errors.Error("failed to listen on port")
+	// ErrServer is the error returned when the server stops due to an error.
+	ErrServer = errors.Error("serve stopped with error")
+)
+
+// Config represents the configuration of the grpc listener.
+type Config struct {
+	Port string `yaml:"port"`
+}
+
+// Service represents a grpc service that can be registered with the listener.
+type Service interface {
+	Register(s *grpc.Server)
+}
+
+// Server represents a grpc server that listens on a port.
+type Server struct {
+	server *grpc.Server
+	health *health.Server
+	port   string
+}
+
+// New instantiates a new instance of Server serving all the provided services
+// alongside the grpc health and reflection services.
+func New(cfg Config, services ...Service) *Server {
+	// Recovery is last so panics are reported with the fields of the request's logger and on its span
+	s := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(tracingUnaryInterceptor(), logTracingUnaryInterceptor, requestLoggingUnaryInterceptor, recoveryUnaryInterceptor),
+		grpc.ChainStreamInterceptor(tracingStreamInterceptor(), logTracingStreamInterceptor, requestLoggingStreamInterceptor, recoveryStreamInterceptor),
+	)
+
+	h := health.NewServer()
+	healthpb.RegisterHealthServer(s, h)
+	reflection.Register(s)
+
+	for _, svc := range services {
+		svc.Register(s)
+	}
+
+	// Services start as not serving until the server is listening
+	for name := range s.GetServiceInfo() {
+		h.SetServingStatus(name, healthpb.HealthCheckResponse_NOT_SERVING)
+	}
+	h.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	return &Server{
+		server: s,
+		health: h,
+		port:   cfg.Port,
+	}
+}
+
+// Listen starts the server and listens on the configured port.
+func (s *Server) Listen(ctx context.Context) error {
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%s", s.port))
+	if err != nil {
+		return ErrListen.Wrap(err)
+	}
+
+	return s.Serve(ctx, lis)
+}
+
+// Serve starts the server accepting connections from the provided listener.
+func (s *Server) Serve(ctx context.Context, lis net.Listener) error {
+	logging.From(ctx).Info(fmt.Sprintf("grpc server starting on: %s", lis.Addr()))
+
+	s.health.Resume()
+
+	if err := s.server.Serve(lis); err != nil {
+		return ErrServer.Wrap(err)
+	}
+
+	logging.From(ctx).Info("grpc server stopped")
+
+	return nil
+}
+
+// Stop marks all services as not serving and gracefully stops the server once in flight requests have completed.
+func (s *Server) Stop() {
+	s.health.Shutdown()
+	s.server.GracefulStop()
+}
@@ -0,0 +1,35 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/speakeasy-api/rest-template-go/internal/core/logging"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+func requestLoggingUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx = logging.WithFields(ctx, zap.String("method", info.FullMethod))
+
+	logging.From(ctx).Info("request")
+
+	return handler(ctx, req)
+}
+
+func requestLoggingStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx := logging.WithFields(ss.Context(), zap.String("method", info.FullMethod))
+
+	logging.From(ctx).Info("stream")
+
+	return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+}
+
+// serverStream overrides the context of a stream so interceptors can add to it.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,62 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	"github.com/speakeasy-api/rest-template-go/internal/core/logging"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrPanic is the error recorded when a handler panics.
+const ErrPanic = errors.Error("panic recovered")
+
+// recoveryUnaryInterceptor recovers from panics in handlers so they fail the request with an internal error rather
+// than crashing the server.
+func recoveryUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (res interface{}, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = recovered(ctx, v, debug.Stack())
+		}
+	}()
+
+	return handler(ctx, req)
+}
+
+// recoveryStreamInterceptor recovers from panics in stream handlers so they fail the stream with an internal error
+// rather than crashing the server.
+func recoveryStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = recovered(ss.Context(), v, debug.Stack())
+		}
+	}()
+
+	return handler(srv, ss)
+}
+
+// recovered will report the panic of the request, logging the stack with the fields of the request's logger and
+// recording the error on its span, returning the status the request fails with.
+func recovered(ctx context.Context, v interface{}, stack []byte) error {
+	cause, ok := v.(error)
+	if !ok {
+		cause = errors.New(fmt.Sprint(v))
+	}
+	err := ErrPanic.Wrap(cause)
+
+	logging.From(ctx).Error("panic while handling request", zap.Error(err), zap.ByteString("stack", stack))
+
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(err, trace.WithAttributes(semconv.ExceptionStacktraceKey.String(string(stack))))
+	span.SetStatus(codes.Error, ErrPanic.Error())
+
+	return status.Error(grpccodes.Internal, errors.ErrUnknown.Error())
+}
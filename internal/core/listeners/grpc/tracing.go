@@ -0,0 +1,36 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/speakeasy-api/rest-template-go/internal/core/logging"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+func tracingUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return otelgrpc.UnaryServerInterceptor()
+}
+
+func tracingStreamInterceptor() grpc.StreamServerInterceptor {
+	return otelgrpc.StreamServerInterceptor()
+}
+
+func logTracingUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(withTraceFields(ctx), req)
+}
+
+func logTracingStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &serverStream{ServerStream: ss, ctx: withTraceFields(ss.Context())})
+}
+
+func withTraceFields(ctx context.Context) context.Context {
+	span := trace.SpanFromContext(ctx)
+
+	traceID := span.SpanContext().TraceID()
+	spanID := span.SpanContext().SpanID()
+
+	return logging.WithFields(ctx, zap.String("trace_id", traceID.String()), zap.String("span_id", spanID.String()))
+}
@@ -0,0 +1,87 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	"github.com/speakeasy-api/rest-template-go/internal/core/problem"
+)
+
+// ErrBodyTooLarge is returned when reading a request body larger than the maximum size of the listener.
+const ErrBodyTooLarge = errors.Error("body_too_large: request body is too large")
+
+const defaultMaxBodySize = 1 << 20
+
+// BodyLimiter limits the size of request bodies so oversized bodies are never read into memory.
+type BodyLimiter struct {
+	maxBodySize int64
+}
+
+// NewBodyLimiter will instantiate a new instance of BodyLimiter allowing bodies of up to maxBodySize bytes,
+// defaulting to 1MiB.
+func NewBodyLimiter(maxBodySize int64) *BodyLimiter {
+	if maxBodySize <= 0 {
+		maxBodySize = defaultMaxBodySize
+	}
+
+	return &BodyLimiter{
+		maxBodySize: maxBodySize,
+	}
+}
+
+// Middleware will reject requests whose Content-Length is larger than the maximum size with a 413, and make reading
+// the body of the rest fail with ErrBodyTooLarge once the maximum size is exceeded.
+func (l *BodyLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > l.maxBodySize {
+			WriteBodyTooLarge(r.Context(), w)
+			return
+		}
+
+		if r.Body != nil && r.Body != http.NoBody {
+			r.Body = &limitedBody{ReadCloser: r.Body, remaining: l.maxBodySize}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// WriteBodyTooLarge will respond with the 413 problem for a request body larger than the maximum size.
+func WriteBodyTooLarge(ctx context.Context, w http.ResponseWriter) {
+	p := problem.FromError(ctx, ErrBodyTooLarge.Wrap(errors.ErrInvalidRequest))
+	p.Status = http.StatusRequestEntityTooLarge
+
+	problem.Write(ctx, w, p)
+}
+
+// limitedBody fails reads with ErrBodyTooLarge once more than remaining bytes have been read, unlike
+// io.LimitReader which would silently truncate the body.
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+	err       error
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+
+	// Reads one byte more than remaining to detect bodies that are too large
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+
+	n, err := b.ReadCloser.Read(p)
+	if int64(n) > b.remaining {
+		n = int(b.remaining)
+		b.remaining = 0
+		b.err = ErrBodyTooLarge.Wrap(errors.ErrInvalidRequest)
+		return n, b.err
+	}
+	b.remaining -= int64(n)
+
+	return n, err
+}
@@ -0,0 +1,105 @@
+package http_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	httplistener "github.com/speakeasy-api/rest-template-go/internal/core/listeners/http"
+	"github.com/speakeasy-api/rest-template-go/internal/core/problem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBodyLimiter_Middleware(t *testing.T) {
+	tests := []struct {
+		name            string
+		body            string
+		contentEncoding string
+		unknownLength   bool
+		wantCode        int
+		wantBody        string
+	}{
+		{
+			name:     "within limit",
+			body:     strings.Repeat("a", 16),
+			wantCode: http.StatusOK,
+			wantBody: strings.Repeat("a", 16),
+		},
+		{
+			name:     "content length too large",
+			body:     strings.Repeat("a", 17),
+			wantCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:          "streamed body within limit",
+			body:          strings.Repeat("a", 16),
+			unknownLength: true,
+			wantCode:      http.StatusOK,
+			wantBody:      strings.Repeat("a", 16),
+		},
+		{
+			name:          "streamed body too large",
+			body:          strings.Repeat("a", 17),
+			unknownLength: true,
+			wantCode:      http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:            "compressed body too large",
+			body:            string(compress(t, "gzip", strings.Repeat(`{"first_name":"test"}`, 10))),
+			contentEncoding: "gzip",
+			unknownLength:   true,
+			wantCode:        http.StatusRequestEntityTooLarge,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := httplistener.NewCompressor(httplistener.CompressionConfig{Enabled: true})
+			require.NoError(t, err)
+
+			h := httplistener.NewBodyLimiter(16).Middleware(c.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, err := io.ReadAll(r.Body)
+				if errors.Is(err, httplistener.ErrBodyTooLarge) {
+					httplistener.WriteBodyTooLarge(r.Context(), w)
+					return
+				}
+				require.NoError(t, err)
+
+				_, _ = w.Write(body)
+			})))
+
+			var body io.Reader = bytes.NewBufferString(tt.body)
+			if tt.unknownLength {
+				// Hides the length of the body so it is only known once read
+				body = io.MultiReader(body)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/", body)
+			if tt.contentEncoding != "" {
+				req.Header.Set("Content-Encoding", tt.contentEncoding)
+			}
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+
+			if tt.wantCode != http.StatusOK {
+				assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+
+				var res problem.Problem
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+				assert.Equal(t, http.StatusRequestEntityTooLarge, res.Status)
+				assert.Equal(t, "body_too_large: request body is too large", res.Detail)
+				return
+			}
+
+			assert.Equal(t, tt.wantBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// CacheController sets the Cache-Control header of responses according to the route being handled.
+type CacheController struct {
+	policies map[string]string
+}
+
+// NewCacheController will instantiate a new instance of CacheController with the policies mapping route names, the
+// operationId of routes in the OpenAPI document, to their Cache-Control header.
+func NewCacheController(policies map[string]string) *CacheController {
+	return &CacheController{
+		policies: policies,
+	}
+}
+
+// Middleware will set the Cache-Control header configured for the route being handled, handlers responding with an
+// error are expected to remove it as the error isn't the representation the policy describes.
+func (c *CacheController) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if route := mux.CurrentRoute(r); route != nil {
+			if policy, ok := c.policies[route.GetName()]; ok {
+				w.Header().Set("Cache-Control", policy)
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,50 @@
+package http_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	httplistener "github.com/speakeasy-api/rest-template-go/internal/core/listeners/http"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheController_Middleware(t *testing.T) {
+	tests := []struct {
+		name             string
+		url              string
+		wantCacheControl string
+	}{
+		{
+			name:             "route with policy",
+			url:              "/user/1",
+			wantCacheControl: "private, no-cache",
+		},
+		{
+			name: "route without policy",
+			url:  "/users",
+		},
+		{
+			name: "unmatched route",
+			url:  "/unknown",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := mux.NewRouter()
+			r.Use(httplistener.NewCacheController(map[string]string{"getUserv1": "private, no-cache"}).Middleware)
+
+			ok := func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}
+			r.HandleFunc("/user/{id}", ok).Methods(http.MethodGet).Name("getUserv1")
+			r.HandleFunc("/users", ok).Methods(http.MethodGet).Name("getUsersv1")
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.url, nil))
+
+			assert.Equal(t, tt.wantCacheControl, w.Header().Get("Cache-Control"))
+		})
+	}
+}
@@ -0,0 +1,499 @@
+package http
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	"github.com/speakeasy-api/rest-template-go/internal/core/logging"
+	"github.com/speakeasy-api/rest-template-go/internal/core/problem"
+	"go.uber.org/zap"
+)
+
+const (
+	// ErrUnknownEncoding is returned when compression is configured with an encoding that isn't supported.
+	ErrUnknownEncoding = errors.Error("unknown encoding")
+	// ErrUnsupportedEncoding is returned when a request body is compressed with an encoding that isn't supported.
+	ErrUnsupportedEncoding = errors.Error("unsupported_encoding: content encoding of request is not supported")
+	// ErrInvalidEncoding is returned when a request body can't be decompressed.
+	ErrInvalidEncoding = errors.Error("invalid_encoding: request body could not be decompressed")
+	// ErrDecompressedTooLarge is returned when a request body is larger than allowed once decompressed.
+	ErrDecompressedTooLarge = errors.Error("decompressed_too_large: decompressed request body is too large")
+)
+
+const (
+	// EncodingGzip is the gzip content encoding.
+	EncodingGzip = "gzip"
+	// EncodingBrotli is the brotli content encoding.
+	EncodingBrotli = "br"
+	// EncodingZstd is the zstd content encoding.
+	EncodingZstd = "zstd"
+
+	encodingIdentity = "identity"
+
+	defaultMinSize             = 1024
+	defaultMaxDecompressedSize = 10 << 20
+)
+
+// CompressionConfig represents the configuration of compressing responses and decompressing requests.
+type CompressionConfig struct {
+	// Enabled determines whether responses are compressed and compressed requests are accepted.
+	Enabled bool `yaml:"enabled"`
+	// Encodings are the encodings responses can be compressed with in order of preference, defaults to br, zstd and gzip.
+	Encodings []string `yaml:"encodings"`
+	// MinSize is the size in bytes a response body must reach to be compressed, defaults to 1KiB.
+	MinSize int `yaml:"minSize"`
+	// MaxDecompressedSize is the size in bytes a compressed request body is allowed to expand to, defaults to 10MiB.
+	MaxDecompressedSize int64 `yaml:"maxDecompressedSize"`
+}
+
+// encoder is implemented by the compressed writers of every encoding so they can be pooled.
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+var encoders = map[string]func() encoder{
+	EncodingGzip: func() encoder {
+		return gzip.NewWriter(nil)
+	},
+	EncodingBrotli: func() encoder {
+		return brotli.NewWriterLevel(nil, brotli.DefaultCompression)
+	},
+	EncodingZstd: func() encoder {
+		// Only fails with invalid options
+		e, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+		return e
+	},
+}
+
+var decoders = map[string]func(r io.Reader) (io.ReadCloser, error){
+	EncodingGzip: func(r io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(r)
+	},
+	EncodingBrotli: func(r io.Reader) (io.ReadCloser, error) {
+		return io.NopCloser(brotli.NewReader(r)), nil
+	},
+	EncodingZstd: func(r io.Reader) (io.ReadCloser, error) {
+		d, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return d.IOReadCloser(), nil
+	},
+}
+
+// incompressible are the media types of content that is already compressed so isn't worth compressing again.
+var incompressible = map[string]bool{
+	"application/gzip":             true,
+	"application/x-gzip":           true,
+	"application/zip":              true,
+	"application/zstd":             true,
+	"application/x-brotli":         true,
+	"application/x-7z-compressed":  true,
+	"application/x-rar-compressed": true,
+	"application/pdf":              true,
+	"font/woff":                    true,
+	"font/woff2":                   true,
+}
+
+// Compressor compresses responses with the encoding the client prefers and decompresses compressed requests.
+type Compressor struct {
+	encodings           []string
+	pools               map[string]*sync.Pool
+	minSize             int
+	maxDecompressedSize int64
+}
+
+// NewCompressor will instantiate a new instance of Compressor.
+func NewCompressor(cfg CompressionConfig) (*Compressor, error) {
+	encodings := cfg.Encodings
+	if len(encodings) == 0 {
+		encodings = []string{EncodingBrotli, EncodingZstd, EncodingGzip}
+	}
+
+	pools := make(map[string]*sync.Pool, len(encodings))
+	for _, e := range encodings {
+		newEncoder, ok := encoders[e]
+		if !ok {
+			return nil, ErrUnknownEncoding.Wrap(errors.New(e))
+		}
+		pools[e] = &sync.Pool{New: func() any { return newEncoder() }}
+	}
+
+	minSize := cfg.MinSize
+	if minSize == 0 {
+		minSize = defaultMinSize
+	}
+
+	maxDecompressedSize := cfg.MaxDecompressedSize
+	if maxDecompressedSize == 0 {
+		maxDecompressedSize = defaultMaxDecompressedSize
+	}
+
+	return &Compressor{
+		encodings:           encodings,
+		pools:               pools,
+		minSize:             minSize,
+		maxDecompressedSize: maxDecompressedSize,
+	}, nil
+}
+
+// Middleware will decompress request bodies sent with a Content-Encoding and compress responses with the
+// encoding negotiated from the Accept-Encoding header of the request.
+func (c *Compressor) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		if err := c.decompressRequest(r); err != nil {
+			logging.From(ctx).Warn("failed to decompress request body", zap.Error(err))
+
+			if errors.Is(err, ErrBodyTooLarge) {
+				WriteBodyTooLarge(ctx, w)
+				return
+			}
+
+			p := problem.FromError(ctx, err)
+			switch {
+			case errors.Is(err, ErrUnsupportedEncoding):
+				p.Status = http.StatusUnsupportedMediaType
+			case errors.Is(err, ErrDecompressedTooLarge):
+				p.Status = http.StatusRequestEntityTooLarge
+			}
+
+			problem.Write(ctx, w, p)
+			return
+		}
+
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		encoding := c.negotiate(r.Header.Get("Accept-Encoding"))
+
+		// Ranges are of the uncompressed body so can't be served compressed
+		if encoding == "" || r.Method == http.MethodHead || r.Header.Get("Range") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressWriter{
+			ResponseWriter: w,
+			encoding:       encoding,
+			pool:           c.pools[encoding],
+			minSize:        c.minSize,
+		}
+		returned := false
+		defer func() {
+			// Responses of handlers that panicked are left unfinished for the recovery middleware to replace or abort
+			if !returned {
+				cw.discard()
+				return
+			}
+
+			if err := cw.close(); err != nil {
+				logging.From(ctx).Error("failed to compress response", zap.Error(err))
+			}
+		}()
+
+		next.ServeHTTP(cw, r)
+		returned = true
+	})
+}
+
+// decompressRequest replaces a compressed request body with its decompressed content so handlers and the
+// validator can read it as normal. The body is decompressed up front so oversized bodies are rejected before
+// any of it is handled.
+func (c *Compressor) decompressRequest(r *http.Request) error {
+	encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding")))
+	if encoding == "" {
+		return nil
+	}
+	if encoding == encodingIdentity {
+		r.Header.Del("Content-Encoding")
+		return nil
+	}
+
+	newDecoder, ok := decoders[encoding]
+	if !ok {
+		return ErrUnsupportedEncoding.Wrap(errors.ErrInvalidRequest)
+	}
+
+	dec, err := newDecoder(r.Body)
+	if err != nil {
+		return decompressError(err)
+	}
+	defer dec.Close()
+
+	// Read one byte more than allowed to detect bodies that are too large without reading all of them
+	data, err := io.ReadAll(io.LimitReader(dec, c.maxDecompressedSize+1))
+	if err != nil {
+		return decompressError(err)
+	}
+	if int64(len(data)) > c.maxDecompressedSize {
+		return ErrDecompressedTooLarge.Wrap(errors.ErrInvalidRequest)
+	}
+
+	_ = r.Body.Close()
+
+	r.Body = io.NopCloser(bytes.NewReader(data))
+	r.ContentLength = int64(len(data))
+	r.Header.Del("Content-Encoding")
+	r.Header.Set("Content-Length", strconv.Itoa(len(data)))
+
+	return nil
+}
+
+// decompressError describes a failure to read a compressed body, keeping ErrBodyTooLarge so bodies that are too
+// large before they are decompressed are rejected with a 413 rather than as an invalid encoding.
+func decompressError(err error) error {
+	if errors.Is(err, ErrBodyTooLarge) {
+		return err
+	}
+
+	return ErrInvalidEncoding.Wrap(errors.ErrInvalidRequest.Wrap(err))
+}
+
+// negotiate returns the supported encoding with the highest quality in the Accept-Encoding header, preferring
+// encodings in the configured order when qualities are equal, or an empty string if none are acceptable.
+func (c *Compressor) negotiate(acceptEncoding string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+
+	qualities := map[string]float64{}
+	wildcard := -1.0
+
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		fields := strings.Split(part, ";")
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+
+		q := 1.0
+		for _, param := range fields[1:] {
+			k, v, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if !ok || strings.TrimSpace(k) != "q" {
+				continue
+			}
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				q = 0
+				continue
+			}
+			q = parsed
+		}
+
+		if name == "*" {
+			wildcard = q
+			continue
+		}
+		qualities[name] = q
+	}
+
+	candidates := make([]string, 0, len(c.encodings))
+	for _, e := range c.encodings {
+		q, ok := qualities[e]
+		if !ok {
+			q = wildcard
+		}
+		if q > 0 {
+			qualities[e] = q
+			candidates = append(candidates, e)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return ""
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return qualities[candidates[i]] > qualities[candidates[j]]
+	})
+
+	return candidates[0]
+}
+
+// compressWriter buffers the start of a response until it is large enough to be worth compressing, then
+// streams the rest of it through the encoder. Flushing a response starts compressing it regardless of its
+// size so streamed responses are sent as they are written.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	pool     *sync.Pool
+	minSize  int
+
+	status  int
+	buf     bytes.Buffer
+	decided bool
+	enc     encoder
+}
+
+func (w *compressWriter) WriteHeader(status int) {
+	if w.decided {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if w.status != 0 {
+		return
+	}
+
+	w.status = status
+
+	// Responses without a body are sent as is
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified {
+		w.decided = true
+		w.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	if w.decided {
+		if w.enc != nil {
+			return w.enc.Write(data)
+		}
+		return w.ResponseWriter.Write(data)
+	}
+
+	n, _ := w.buf.Write(data)
+	if w.buf.Len() >= w.minSize {
+		if err := w.start(true); err != nil {
+			return 0, err
+		}
+	}
+
+	return n, nil
+}
+
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		if err := w.start(true); err != nil {
+			return
+		}
+	}
+
+	if w.enc != nil {
+		if err := w.enc.Flush(); err != nil {
+			return
+		}
+	}
+
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// start sends the headers and any buffered content, compressing the rest of the response if compress is true
+// and the content is worth compressing.
+func (w *compressWriter) start(compress bool) error {
+	w.decided = true
+
+	h := w.Header()
+
+	if compress && h.Get("Content-Encoding") == "" && w.compressible() {
+		w.enc = w.pool.Get().(encoder) //nolint:forcetypeassert
+		w.enc.Reset(w.ResponseWriter)
+
+		h.Set("Content-Encoding", w.encoding)
+		h.Del("Content-Length")
+		h.Del("Accept-Ranges")
+
+		// The compressed body is a different sequence of bytes so the ETag can only be a weak match for it
+		if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			h.Set("ETag", "W/"+etag)
+		}
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+
+	if w.buf.Len() == 0 {
+		return nil
+	}
+
+	var err error
+	if w.enc != nil {
+		_, err = w.enc.Write(w.buf.Bytes())
+	} else {
+		_, err = w.ResponseWriter.Write(w.buf.Bytes())
+	}
+	w.buf.Reset()
+
+	return err
+}
+
+// compressible returns whether the content type of the response isn't already compressed, detecting it from
+// the buffered content if the handler didn't set it.
+func (w *compressWriter) compressible() bool {
+	contentType := w.Header().Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(w.buf.Bytes())
+		w.Header().Set("Content-Type", contentType)
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	if incompressible[mediaType] {
+		return false
+	}
+
+	switch {
+	case mediaType == "image/svg+xml":
+		return true
+	case strings.HasPrefix(mediaType, "image/"), strings.HasPrefix(mediaType, "video/"), strings.HasPrefix(mediaType, "audio/"):
+		return false
+	}
+
+	return true
+}
+
+// discard returns the encoder to the pool without finishing the response.
+func (w *compressWriter) discard() {
+	if w.enc == nil {
+		return
+	}
+
+	w.enc.Reset(nil)
+	w.pool.Put(w.enc)
+	w.enc = nil
+}
+
+// close sends responses that never reached the minimum size uncompressed and finishes compressed responses.
+func (w *compressWriter) close() error {
+	if !w.decided {
+		// Nothing was written so the handler relied on the status defaulting
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		if err := w.start(false); err != nil {
+			return err
+		}
+	}
+
+	if w.enc == nil {
+		return nil
+	}
+
+	err := w.enc.Close()
+
+	w.enc.Reset(nil)
+	w.pool.Put(w.enc)
+	w.enc = nil
+
+	return err
+}
@@ -0,0 +1,412 @@
+package http_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	httplistener "github.com/speakeasy-api/rest-template-go/internal/core/listeners/http"
+	"github.com/speakeasy-api/rest-template-go/internal/core/problem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressor_Middleware_Response(t *testing.T) {
+	large := strings.Repeat(`{"first_name":"test","last_name":"user"}`, 100)
+
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		contentType    string
+		body           string
+		handlerHeaders map[string]string
+		wantEncoding   string
+		wantETag       string
+	}{
+		{
+			name:        "no accept encoding",
+			contentType: "application/json",
+			body:        large,
+		},
+		{
+			name:           "gzip",
+			acceptEncoding: "gzip",
+			contentType:    "application/json",
+			body:           large,
+			wantEncoding:   "gzip",
+		},
+		{
+			name:           "brotli",
+			acceptEncoding: "br",
+			contentType:    "application/json",
+			body:           large,
+			wantEncoding:   "br",
+		},
+		{
+			name:           "zstd",
+			acceptEncoding: "zstd",
+			contentType:    "application/json",
+			body:           large,
+			wantEncoding:   "zstd",
+		},
+		{
+			name:           "server preference on equal quality",
+			acceptEncoding: "gzip, deflate, zstd, br",
+			contentType:    "application/json",
+			body:           large,
+			wantEncoding:   "br",
+		},
+		{
+			name:           "highest quality",
+			acceptEncoding: "br;q=0.5, gzip;q=0.8, zstd;q=0.1",
+			contentType:    "application/json",
+			body:           large,
+			wantEncoding:   "gzip",
+		},
+		{
+			name:           "wildcard",
+			acceptEncoding: "*",
+			contentType:    "application/json",
+			body:           large,
+			wantEncoding:   "br",
+		},
+		{
+			name:           "wildcard excluding encoding",
+			acceptEncoding: "*, br;q=0",
+			contentType:    "application/json",
+			body:           large,
+			wantEncoding:   "zstd",
+		},
+		{
+			name:           "only unsupported encodings",
+			acceptEncoding: "deflate, gzip;q=0",
+			contentType:    "application/json",
+			body:           large,
+		},
+		{
+			name:           "small body",
+			acceptEncoding: "gzip",
+			contentType:    "application/json",
+			body:           `{"first_name":"test"}`,
+		},
+		{
+			name:           "already compressed content type",
+			acceptEncoding: "gzip",
+			contentType:    "image/png",
+			body:           large,
+		},
+		{
+			name:           "detected content type",
+			acceptEncoding: "gzip",
+			body:           large,
+			wantEncoding:   "gzip",
+		},
+		{
+			name:           "strong etag weakened",
+			acceptEncoding: "gzip",
+			contentType:    "application/json",
+			body:           large,
+			handlerHeaders: map[string]string{"ETag": `"abc"`},
+			wantEncoding:   "gzip",
+			wantETag:       `W/"abc"`,
+		},
+		{
+			name:           "uncompressed etag unchanged",
+			acceptEncoding: "gzip",
+			contentType:    "application/json",
+			body:           `{"first_name":"test"}`,
+			handlerHeaders: map[string]string{"ETag": `"abc"`},
+			wantETag:       `"abc"`,
+		},
+		{
+			name:           "already encoded by handler",
+			acceptEncoding: "gzip",
+			contentType:    "application/json",
+			body:           large,
+			handlerHeaders: map[string]string{"Content-Encoding": "custom"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := httplistener.NewCompressor(httplistener.CompressionConfig{Enabled: true})
+			require.NoError(t, err)
+
+			h := c.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.contentType != "" {
+					w.Header().Set("Content-Type", tt.contentType)
+				}
+				for k, v := range tt.handlerHeaders {
+					w.Header().Set(k, v)
+				}
+				w.Header().Set("Content-Length", "1")
+				w.WriteHeader(http.StatusOK)
+				// Written in chunks to check content is buffered until it reaches the minimum size
+				for i := 0; i < len(tt.body); i += 100 {
+					_, err := w.Write([]byte(tt.body[i:min(i+100, len(tt.body))]))
+					require.NoError(t, err)
+				}
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/users", nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+
+			w := httptest.NewRecorder()
+
+			h.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+			if tt.wantETag != "" {
+				assert.Equal(t, tt.wantETag, w.Header().Get("ETag"))
+			}
+
+			if tt.wantEncoding == "" {
+				assert.NotEqual(t, "gzip", w.Header().Get("Content-Encoding"))
+				assert.Equal(t, "1", w.Header().Get("Content-Length"))
+				assert.Equal(t, tt.body, w.Body.String())
+				return
+			}
+
+			assert.Equal(t, tt.wantEncoding, w.Header().Get("Content-Encoding"))
+			assert.Empty(t, w.Header().Get("Content-Length"))
+			assert.Less(t, w.Body.Len(), len(tt.body))
+			assert.Equal(t, tt.body, decompress(t, tt.wantEncoding, w.Body))
+		})
+	}
+}
+
+func TestCompressor_Middleware_Streaming(t *testing.T) {
+	c, err := httplistener.NewCompressor(httplistener.CompressionConfig{Enabled: true})
+	require.NoError(t, err)
+
+	events := []string{"data: one\n\n", "data: two\n\n"}
+	flushed := make(chan struct{})
+	resume := make(chan struct{})
+
+	h := c.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+
+		_, err := w.Write([]byte(events[0]))
+		assert.NoError(t, err)
+		w.(http.Flusher).Flush() //nolint:forcetypeassert
+
+		close(flushed)
+		<-resume
+
+		_, err = w.Write([]byte(events[1]))
+		assert.NoError(t, err)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/users/stream", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	w := httptest.NewRecorder()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.ServeHTTP(w, req)
+	}()
+
+	<-flushed
+
+	// The first event is sent compressed despite being smaller than the minimum size
+	assert.True(t, w.Flushed)
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+
+	r, err := gzip.NewReader(bytes.NewReader(w.Body.Bytes()))
+	require.NoError(t, err)
+
+	first := make([]byte, len(events[0]))
+	_, err = io.ReadFull(r, first)
+	require.NoError(t, err)
+	assert.Equal(t, events[0], string(first))
+
+	close(resume)
+	<-done
+
+	assert.Equal(t, strings.Join(events, ""), decompress(t, "gzip", w.Body))
+}
+
+func TestCompressor_Middleware_Request(t *testing.T) {
+	body := strings.Repeat(`{"first_name":"test"}`, 10)
+
+	tests := []struct {
+		name            string
+		contentEncoding string
+		body            []byte
+		maxSize         int64
+		wantCode        int
+		wantBody        string
+	}{
+		{
+			name:     "uncompressed",
+			body:     []byte(body),
+			wantCode: http.StatusOK,
+			wantBody: body,
+		},
+		{
+			name:            "gzip",
+			contentEncoding: "gzip",
+			body:            compress(t, "gzip", body),
+			wantCode:        http.StatusOK,
+			wantBody:        body,
+		},
+		{
+			name:            "brotli",
+			contentEncoding: "br",
+			body:            compress(t, "br", body),
+			wantCode:        http.StatusOK,
+			wantBody:        body,
+		},
+		{
+			name:            "zstd",
+			contentEncoding: "zstd",
+			body:            compress(t, "zstd", body),
+			wantCode:        http.StatusOK,
+			wantBody:        body,
+		},
+		{
+			name:            "identity",
+			contentEncoding: "identity",
+			body:            []byte(body),
+			wantCode:        http.StatusOK,
+			wantBody:        body,
+		},
+		{
+			name:            "exactly max size",
+			contentEncoding: "gzip",
+			body:            compress(t, "gzip", body),
+			maxSize:         int64(len(body)),
+			wantCode:        http.StatusOK,
+			wantBody:        body,
+		},
+		{
+			name:            "too large once decompressed",
+			contentEncoding: "gzip",
+			body:            compress(t, "gzip", body),
+			maxSize:         int64(len(body)) - 1,
+			wantCode:        http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:            "unsupported encoding",
+			contentEncoding: "compress",
+			body:            []byte(body),
+			wantCode:        http.StatusUnsupportedMediaType,
+		},
+		{
+			name:            "corrupt body",
+			contentEncoding: "gzip",
+			body:            []byte(body),
+			wantCode:        http.StatusBadRequest,
+		},
+		{
+			name:            "truncated body",
+			contentEncoding: "zstd",
+			body:            compress(t, "zstd", body)[:10],
+			wantCode:        http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := httplistener.NewCompressor(httplistener.CompressionConfig{
+				Enabled:             true,
+				MaxDecompressedSize: tt.maxSize,
+			})
+			require.NoError(t, err)
+
+			h := c.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Empty(t, r.Header.Get("Content-Encoding"))
+				assert.Equal(t, int64(len(tt.wantBody)), r.ContentLength)
+
+				data, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantBody, string(data))
+
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/users/search", bytes.NewReader(tt.body))
+			if tt.contentEncoding != "" {
+				req.Header.Set("Content-Encoding", tt.contentEncoding)
+			}
+
+			w := httptest.NewRecorder()
+
+			h.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			if tt.wantCode != http.StatusOK {
+				assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestNewCompressor_UnknownEncoding(t *testing.T) {
+	_, err := httplistener.NewCompressor(httplistener.CompressionConfig{Encodings: []string{"gzip", "deflate"}})
+	assert.ErrorIs(t, err, httplistener.ErrUnknownEncoding)
+}
+
+func compress(t *testing.T, encoding, body string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	var w io.WriteCloser
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "br":
+		w = brotli.NewWriter(&buf)
+	case "zstd":
+		var err error
+		w, err = zstd.NewWriter(&buf)
+		require.NoError(t, err)
+	}
+
+	_, err := w.Write([]byte(body))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	return buf.Bytes()
+}
+
+func decompress(t *testing.T, encoding string, body io.Reader) string {
+	t.Helper()
+
+	var r io.Reader
+	switch encoding {
+	case "gzip":
+		var err error
+		r, err = gzip.NewReader(body)
+		require.NoError(t, err)
+	case "br":
+		r = brotli.NewReader(body)
+	case "zstd":
+		d, err := zstd.NewReader(body)
+		require.NoError(t, err)
+		defer d.Close()
+		r = d
+	}
+
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+
+	return string(data)
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,265 @@
+package http
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	"github.com/speakeasy-api/rest-template-go/internal/core/problem"
+)
+
+const (
+	// ErrInvalidCORS is returned when the CORS configuration is invalid.
+	ErrInvalidCORS = errors.Error("invalid cors config")
+	// ErrOriginNotAllowed is returned when a preflight request is made from an origin that isn't allowed.
+	ErrOriginNotAllowed = errors.Error("origin_not_allowed: origin is not allowed to make cross-origin requests")
+	// ErrMethodNotAllowed is returned when a preflight request is for a method that isn't allowed.
+	ErrMethodNotAllowed = errors.Error("method_not_allowed: method is not allowed in cross-origin requests")
+	// ErrHeaderNotAllowed is returned when a preflight request is for a header that isn't allowed.
+	ErrHeaderNotAllowed = errors.Error("header_not_allowed: header is not allowed in cross-origin requests")
+)
+
+var (
+	defaultAllowedMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodDelete}
+	defaultAllowedHeaders = []string{"Accept", "Content-Type"}
+)
+
+// CORSConfig represents the configuration of allowing browsers to make cross-origin requests.
+type CORSConfig struct {
+	// AllowedOrigins are the origins allowed to make requests such as https://app.example.com, a wildcard subdomain
+	// such as https://*.example.com or * for any origin. CORS is disabled if empty.
+	AllowedOrigins []string `yaml:"allowedOrigins"`
+	// AllowedMethods are the methods allowed in requests, defaults to GET, HEAD, POST, PUT and DELETE.
+	AllowedMethods []string `yaml:"allowedMethods"`
+	// AllowedHeaders are the headers allowed in requests or * for any header, defaults to Accept and Content-Type.
+	AllowedHeaders []string `yaml:"allowedHeaders"`
+	// ExposedHeaders are the headers of responses browsers expose to the client.
+	ExposedHeaders []string `yaml:"exposedHeaders"`
+	// AllowCredentials determines whether requests can include cookies and authorization headers.
+	AllowCredentials bool `yaml:"allowCredentials"`
+	// MaxAge is how many seconds browsers can cache the result of a preflight request for, not sent if zero.
+	MaxAge int `yaml:"maxAge"`
+}
+
+// originPattern matches an allowed origin, either exactly or as a wildcard subdomain.
+type originPattern struct {
+	prefix string
+	suffix string
+	exact  bool
+}
+
+func (p originPattern) matches(origin string) bool {
+	if p.exact {
+		return origin == p.prefix
+	}
+
+	if len(origin) <= len(p.prefix)+len(p.suffix) || !strings.HasPrefix(origin, p.prefix) || !strings.HasSuffix(origin, p.suffix) {
+		return false
+	}
+
+	// The wildcard only matches subdomains, not ports, paths or credentials
+	subdomain := origin[len(p.prefix) : len(origin)-len(p.suffix)]
+	return !strings.ContainsAny(subdomain, "/:@")
+}
+
+// CORS handles cross-origin requests from browsers, answering preflight requests for routes of the router and
+// adding the CORS headers to the responses of requests from allowed origins.
+type CORS struct {
+	anyOrigin        bool
+	origins          []originPattern
+	methods          map[string]bool
+	allowedMethods   string
+	anyHeader        bool
+	headers          map[string]bool
+	exposedHeaders   string
+	allowCredentials bool
+	maxAge           string
+}
+
+// NewCORS will instantiate a new instance of CORS.
+func NewCORS(cfg CORSConfig) (*CORS, error) {
+	c := &CORS{
+		methods:          map[string]bool{},
+		headers:          map[string]bool{},
+		exposedHeaders:   strings.Join(cfg.ExposedHeaders, ", "),
+		allowCredentials: cfg.AllowCredentials,
+	}
+
+	for _, o := range cfg.AllowedOrigins {
+		if o == "*" {
+			c.anyOrigin = true
+			continue
+		}
+
+		p, err := parseOriginPattern(o)
+		if err != nil {
+			return nil, err
+		}
+		c.origins = append(c.origins, p)
+	}
+
+	// Browsers reject credentialed responses allowing any origin, echoing the origin instead would allow every site
+	// to make requests with the user's credentials
+	if c.anyOrigin && c.allowCredentials {
+		return nil, ErrInvalidCORS.Wrap(errors.New("allowCredentials can't be used when any origin is allowed"))
+	}
+
+	methods := cfg.AllowedMethods
+	if len(methods) == 0 {
+		methods = defaultAllowedMethods
+	}
+	for _, m := range methods {
+		c.methods[strings.ToUpper(m)] = true
+	}
+	c.allowedMethods = strings.Join(methods, ", ")
+
+	headers := cfg.AllowedHeaders
+	if len(headers) == 0 {
+		headers = defaultAllowedHeaders
+	}
+	for _, h := range headers {
+		if h == "*" {
+			c.anyHeader = true
+			continue
+		}
+		c.headers[http.CanonicalHeaderKey(h)] = true
+	}
+
+	if cfg.MaxAge > 0 {
+		c.maxAge = strconv.Itoa(cfg.MaxAge)
+	}
+
+	return c, nil
+}
+
+func parseOriginPattern(origin string) (originPattern, error) {
+	origin = strings.ToLower(origin)
+
+	u, err := url.Parse(strings.Replace(origin, "*", "wildcard", 1))
+	if err != nil || u.Scheme == "" || u.Host == "" || (u.Path != "" && u.Path != "/") {
+		return originPattern{}, ErrInvalidCORS.Wrap(errors.New("invalid allowed origin " + origin))
+	}
+	origin = strings.TrimSuffix(origin, "/")
+
+	prefix, suffix, wildcard := strings.Cut(origin, "*")
+	if !wildcard {
+		return originPattern{prefix: origin, exact: true}, nil
+	}
+
+	if !strings.HasSuffix(prefix, "://") || !strings.HasPrefix(suffix, ".") || strings.Contains(suffix, "*") {
+		return originPattern{}, ErrInvalidCORS.Wrap(errors.New("wildcard must be the first label of the host in allowed origin " + origin))
+	}
+
+	return originPattern{prefix: prefix, suffix: suffix}, nil
+}
+
+// Handler wraps the router so preflight requests are answered before they are routed, as the routes only match
+// the methods they handle and not OPTIONS. Preflight requests for paths or methods the router has no route for
+// are passed on to it to be rejected as usual.
+func (c *CORS) Handler(router *mux.Router) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			router.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Origin")
+
+		requestMethod := r.Header.Get("Access-Control-Request-Method")
+		if r.Method == http.MethodOptions && requestMethod != "" {
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+
+			// Check the route exists for the method the actual request will use
+			match := r.Clone(r.Context())
+			match.Method = requestMethod
+
+			var m mux.RouteMatch
+			if !router.Match(match, &m) {
+				router.ServeHTTP(w, r)
+				return
+			}
+
+			c.preflight(w, r, origin, requestMethod)
+			return
+		}
+
+		if c.allowOrigin(origin) {
+			c.setAllowOrigin(w, origin)
+			if c.exposedHeaders != "" {
+				w.Header().Set("Access-Control-Expose-Headers", c.exposedHeaders)
+			}
+		}
+
+		router.ServeHTTP(w, r)
+	})
+}
+
+func (c *CORS) preflight(w http.ResponseWriter, r *http.Request, origin, method string) {
+	ctx := r.Context()
+
+	if !c.allowOrigin(origin) {
+		problem.Write(ctx, w, problem.FromError(ctx, ErrOriginNotAllowed.Wrap(errors.ErrForbidden)))
+		return
+	}
+
+	if !c.methods[strings.ToUpper(method)] {
+		problem.Write(ctx, w, problem.FromError(ctx, ErrMethodNotAllowed.Wrap(errors.ErrForbidden)))
+		return
+	}
+
+	var requestHeaders []string
+	for _, h := range strings.Split(r.Header.Get("Access-Control-Request-Headers"), ",") {
+		h = http.CanonicalHeaderKey(strings.TrimSpace(h))
+		if h == "" {
+			continue
+		}
+		if !c.anyHeader && !c.headers[h] {
+			problem.Write(ctx, w, problem.FromError(ctx, ErrHeaderNotAllowed.Wrap(errors.ErrForbidden)))
+			return
+		}
+		requestHeaders = append(requestHeaders, h)
+	}
+
+	c.setAllowOrigin(w, origin)
+	w.Header().Set("Access-Control-Allow-Methods", c.allowedMethods)
+	if len(requestHeaders) > 0 {
+		w.Header().Set("Access-Control-Allow-Headers", strings.Join(requestHeaders, ", "))
+	}
+	if c.maxAge != "" {
+		w.Header().Set("Access-Control-Max-Age", c.maxAge)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *CORS) allowOrigin(origin string) bool {
+	if c.anyOrigin {
+		return true
+	}
+
+	origin = strings.ToLower(origin)
+	for _, p := range c.origins {
+		if p.matches(origin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *CORS) setAllowOrigin(w http.ResponseWriter, origin string) {
+	if c.anyOrigin {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	if c.allowCredentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+}
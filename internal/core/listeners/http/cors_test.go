@@ -0,0 +1,357 @@
+package http_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	httplistener "github.com/speakeasy-api/rest-template-go/internal/core/listeners/http"
+	"github.com/speakeasy-api/rest-template-go/internal/core/problem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCORSRouter(t *testing.T, cfg httplistener.CORSConfig) http.Handler {
+	t.Helper()
+
+	c, err := httplistener.NewCORS(cfg)
+	require.NoError(t, err)
+
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	r := mux.NewRouter()
+	r.HandleFunc("/v1/user", ok).Methods(http.MethodPost)
+	r.HandleFunc("/v1/user/{id}", ok).Methods(http.MethodGet, http.MethodPut, http.MethodDelete)
+	r.HandleFunc("/v1/users/stream", ok).Methods(http.MethodGet)
+
+	return c.Handler(r)
+}
+
+func TestCORS_Preflight(t *testing.T) {
+	cfg := httplistener.CORSConfig{
+		AllowedOrigins:   []string{"https://app.example.com", "https://*.staging.example.com", "http://localhost:3000"},
+		AllowedMethods:   []string{http.MethodGet, http.MethodPost, http.MethodPut},
+		AllowedHeaders:   []string{"Content-Type", "If-None-Match"},
+		AllowCredentials: true,
+		MaxAge:           600,
+	}
+
+	tests := []struct {
+		name           string
+		path           string
+		origin         string
+		method         string
+		headers        string
+		wantCode       int
+		wantAllowed    bool
+		wantAllowHeads string
+	}{
+		{
+			name:        "exact origin",
+			path:        "/v1/user",
+			origin:      "https://app.example.com",
+			method:      http.MethodPost,
+			wantCode:    http.StatusNoContent,
+			wantAllowed: true,
+		},
+		{
+			name:        "exact origin with port",
+			path:        "/v1/user/some-id",
+			origin:      "http://localhost:3000",
+			method:      http.MethodGet,
+			wantCode:    http.StatusNoContent,
+			wantAllowed: true,
+		},
+		{
+			name:        "origin is case insensitive",
+			path:        "/v1/user",
+			origin:      "https://App.Example.com",
+			method:      http.MethodPost,
+			wantCode:    http.StatusNoContent,
+			wantAllowed: true,
+		},
+		{
+			name:        "wildcard subdomain",
+			path:        "/v1/user/some-id",
+			origin:      "https://pr-123.staging.example.com",
+			method:      http.MethodPut,
+			wantCode:    http.StatusNoContent,
+			wantAllowed: true,
+		},
+		{
+			name:        "nested wildcard subdomain",
+			path:        "/v1/user/some-id",
+			origin:      "https://a.b.staging.example.com",
+			method:      http.MethodPut,
+			wantCode:    http.StatusNoContent,
+			wantAllowed: true,
+		},
+		{
+			name:           "allowed headers",
+			path:           "/v1/user/some-id",
+			origin:         "https://app.example.com",
+			method:         http.MethodPut,
+			headers:        "content-type, if-none-match",
+			wantCode:       http.StatusNoContent,
+			wantAllowed:    true,
+			wantAllowHeads: "Content-Type, If-None-Match",
+		},
+		{
+			name:     "wildcard doesn't match parent domain",
+			path:     "/v1/user",
+			origin:   "https://staging.example.com",
+			method:   http.MethodPost,
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "wildcard doesn't match suffix of another domain",
+			path:     "/v1/user",
+			origin:   "https://evilstaging.example.com",
+			method:   http.MethodPost,
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "wildcard doesn't match port",
+			path:     "/v1/user",
+			origin:   "https://evil.com:443.staging.example.com",
+			method:   http.MethodPost,
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "different scheme",
+			path:     "/v1/user",
+			origin:   "http://app.example.com",
+			method:   http.MethodPost,
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "different port",
+			path:     "/v1/user",
+			origin:   "http://localhost:8080",
+			method:   http.MethodPost,
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "unknown origin",
+			path:     "/v1/user",
+			origin:   "https://evil.com",
+			method:   http.MethodPost,
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "method not allowed",
+			path:     "/v1/user/some-id",
+			origin:   "https://app.example.com",
+			method:   http.MethodDelete,
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "header not allowed",
+			path:     "/v1/user/some-id",
+			origin:   "https://app.example.com",
+			method:   http.MethodPut,
+			headers:  "Content-Type, X-Custom",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "method without route",
+			path:     "/v1/user",
+			origin:   "https://app.example.com",
+			method:   http.MethodGet,
+			wantCode: http.StatusMethodNotAllowed,
+		},
+		{
+			name:     "unknown path",
+			path:     "/v1/unknown",
+			origin:   "https://app.example.com",
+			method:   http.MethodGet,
+			wantCode: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newCORSRouter(t, cfg)
+
+			req := httptest.NewRequest(http.MethodOptions, tt.path, nil)
+			req.Header.Set("Origin", tt.origin)
+			req.Header.Set("Access-Control-Request-Method", tt.method)
+			if tt.headers != "" {
+				req.Header.Set("Access-Control-Request-Headers", tt.headers)
+			}
+
+			w := httptest.NewRecorder()
+
+			h.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Contains(t, w.Header().Values("Vary"), "Origin")
+
+			if !tt.wantAllowed {
+				assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+				assert.Empty(t, w.Header().Get("Access-Control-Allow-Methods"))
+				if tt.wantCode == http.StatusForbidden {
+					assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+				}
+				return
+			}
+
+			assert.Equal(t, tt.origin, w.Header().Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+			assert.Equal(t, "GET, POST, PUT", w.Header().Get("Access-Control-Allow-Methods"))
+			assert.Equal(t, tt.wantAllowHeads, w.Header().Get("Access-Control-Allow-Headers"))
+			assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+		})
+	}
+}
+
+func TestCORS_Request(t *testing.T) {
+	tests := []struct {
+		name            string
+		cfg             httplistener.CORSConfig
+		method          string
+		path            string
+		origin          string
+		wantCode        int
+		wantAllowOrigin string
+		wantCredentials string
+		wantExposed     string
+	}{
+		{
+			name: "allowed origin",
+			cfg: httplistener.CORSConfig{
+				AllowedOrigins:   []string{"https://app.example.com"},
+				ExposedHeaders:   []string{"ETag", "Last-Modified"},
+				AllowCredentials: true,
+			},
+			method:          http.MethodGet,
+			path:            "/v1/user/some-id",
+			origin:          "https://app.example.com",
+			wantCode:        http.StatusOK,
+			wantAllowOrigin: "https://app.example.com",
+			wantCredentials: "true",
+			wantExposed:     "ETag, Last-Modified",
+		},
+		{
+			name: "allowed wildcard subdomain",
+			cfg: httplistener.CORSConfig{
+				AllowedOrigins: []string{"https://*.example.com"},
+			},
+			method:          http.MethodPost,
+			path:            "/v1/user",
+			origin:          "https://app.example.com",
+			wantCode:        http.StatusOK,
+			wantAllowOrigin: "https://app.example.com",
+		},
+		{
+			name: "any origin",
+			cfg: httplistener.CORSConfig{
+				AllowedOrigins: []string{"*"},
+			},
+			method:          http.MethodGet,
+			path:            "/v1/users/stream",
+			origin:          "https://anywhere.com",
+			wantCode:        http.StatusOK,
+			wantAllowOrigin: "*",
+		},
+		{
+			name: "denied origin is still handled",
+			cfg: httplistener.CORSConfig{
+				AllowedOrigins:   []string{"https://app.example.com"},
+				ExposedHeaders:   []string{"ETag"},
+				AllowCredentials: true,
+			},
+			method:   http.MethodGet,
+			path:     "/v1/user/some-id",
+			origin:   "https://evil.com",
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "same origin request",
+			cfg: httplistener.CORSConfig{
+				AllowedOrigins: []string{"https://app.example.com"},
+			},
+			method:   http.MethodDelete,
+			path:     "/v1/user/some-id",
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "options without preflight",
+			cfg: httplistener.CORSConfig{
+				AllowedOrigins: []string{"https://app.example.com"},
+			},
+			method:          http.MethodOptions,
+			path:            "/v1/user/some-id",
+			origin:          "https://app.example.com",
+			wantCode:        http.StatusMethodNotAllowed,
+			wantAllowOrigin: "https://app.example.com",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newCORSRouter(t, tt.cfg)
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+
+			w := httptest.NewRecorder()
+
+			h.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Equal(t, tt.wantAllowOrigin, w.Header().Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, tt.wantCredentials, w.Header().Get("Access-Control-Allow-Credentials"))
+			assert.Equal(t, tt.wantExposed, w.Header().Get("Access-Control-Expose-Headers"))
+			assert.Empty(t, w.Header().Get("Access-Control-Allow-Methods"))
+		})
+	}
+}
+
+func TestNewCORS_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  httplistener.CORSConfig
+	}{
+		{
+			name: "credentials with any origin",
+			cfg: httplistener.CORSConfig{
+				AllowedOrigins:   []string{"*"},
+				AllowCredentials: true,
+			},
+		},
+		{
+			name: "origin without scheme",
+			cfg: httplistener.CORSConfig{
+				AllowedOrigins: []string{"app.example.com"},
+			},
+		},
+		{
+			name: "origin with path",
+			cfg: httplistener.CORSConfig{
+				AllowedOrigins: []string{"https://app.example.com/app"},
+			},
+		},
+		{
+			name: "wildcard in middle of host",
+			cfg: httplistener.CORSConfig{
+				AllowedOrigins: []string{"https://app.*.example.com"},
+			},
+		},
+		{
+			name: "wildcard matching any domain",
+			cfg: httplistener.CORSConfig{
+				AllowedOrigins: []string{"https://*"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := httplistener.NewCORS(tt.cfg)
+			assert.ErrorIs(t, err, httplistener.ErrInvalidCORS)
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package http
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"sync"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+var registerBodyDecoders sync.Once
+
+// registerBinaryBodyDecoders allows bodies encoded as MessagePack or CBOR to be validated, only JSON is supported by default.
+func registerBinaryBodyDecoders() {
+	registerBodyDecoders.Do(func() {
+		cborDecMode, _ := cbor.DecOptions{DefaultMapType: reflect.TypeOf(map[string]interface{}(nil))}.DecMode()
+
+		for _, mediaType := range []string{"application/msgpack", "application/x-msgpack", "application/vnd.msgpack"} {
+			openapi3filter.RegisterBodyDecoder(mediaType, binaryBodyDecoder(msgpack.Unmarshal))
+		}
+		openapi3filter.RegisterBodyDecoder("application/cbor", binaryBodyDecoder(cborDecMode.Unmarshal))
+	})
+}
+
+func binaryBodyDecoder(unmarshal func(data []byte, v interface{}) error) openapi3filter.BodyDecoder {
+	return func(body io.Reader, _ http.Header, _ *openapi3.SchemaRef, _ openapi3filter.EncodingFn) (interface{}, error) {
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return nil, err
+		}
+
+		var v interface{}
+		if err := unmarshal(data, &v); err != nil {
+			return nil, err
+		}
+
+		// Round trip through JSON so values have the same types as JSON bodies, such as times becoming strings
+		data, err = json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+
+		var decoded interface{}
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			return nil, err
+		}
+
+		return decoded, nil
+	}
+}
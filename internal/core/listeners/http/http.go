@@ -5,11 +5,15 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
 	"github.com/speakeasy-api/rest-template-go/internal/core/logging"
+	"github.com/speakeasy-api/rest-template-go/internal/core/metrics"
+	"github.com/speakeasy-api/rest-template-go/internal/core/ratelimit"
+	"github.com/speakeasy-api/rest-template-go/internal/core/requestid"
 )
 
 const (
@@ -25,7 +29,20 @@ const (
 
 // Config represents the configuration of the http listener.
 type Config struct {
-	Port string `yaml:"port"`
+	// Name identifies the listener in the metrics of its requests, defaults to its port.
+	Name        string            `yaml:"name"`
+	Port        string            `yaml:"port"`
+	Validation  ValidationConfig  `yaml:"validation"`
+	Compression CompressionConfig `yaml:"compression"`
+	CORS        CORSConfig        `yaml:"cors"`
+	RateLimit   ratelimit.Config  `yaml:"rateLimit"`
+	Recovery    RecoveryConfig    `yaml:"recovery"`
+	AccessLog   AccessLogConfig   `yaml:"accessLog"`
+	// MaxBodySize is the maximum size in bytes of request bodies, larger bodies are rejected with a 413. Defaults
+	// to 1MiB.
+	MaxBodySize int64 `yaml:"maxBodySize"`
+	// CacheControl maps the operationId of routes in the OpenAPI document to the Cache-Control header of their responses.
+	CacheControl map[string]string `yaml:"cacheControl"`
 }
 
 // Service represents a http service that provides routes for the listener.
@@ -39,16 +56,87 @@ type Server struct {
 	port   string
 }
 
-// New instantiates a new instance of Server.
-func New(s Service, cfg Config) (*Server, error) {
+// New instantiates a new instance of Server serving the routes of all the provided services, rate limits are
+// kept in the backend and requests validated against the OpenAPI document in spec if enabled.
+func New(cfg Config, rateLimits ratelimit.Backend, spec []byte, services ...Service) (*Server, error) {
 	r := mux.NewRouter()
-	r.Use(tracingMiddleware)
-	r.Use(logTracingMiddleware)
-	r.Use(requestLoggingMiddleware)
+	// Added first so the route is known to the middleware wrapping the router and included in the logs of handlers
+	r.Use(recordRoute)
 
-	if err := s.AddRoutes(r); err != nil {
-		return nil, ErrAddRoutes.Wrap(err)
+	rc, err := NewRecoverer(cfg.Recovery)
+	if err != nil {
+		return nil, err
+	}
+	r.Use(rc.Middleware)
+
+	// Added before the rest of the middleware so limited requests are rejected as cheaply as possible
+	if cfg.RateLimit.Enabled {
+		l, err := ratelimit.New(cfg.RateLimit, rateLimits)
+		if err != nil {
+			return nil, err
+		}
+		r.Use(l.Middleware)
+	}
+
+	// Added before reading the body is possible so oversized bodies are never read into memory
+	r.Use(NewBodyLimiter(cfg.MaxBodySize).Middleware)
+
+	// Added before validation so requests are validated decompressed and responses before they are compressed
+	if cfg.Compression.Enabled {
+		c, err := NewCompressor(cfg.Compression)
+		if err != nil {
+			return nil, err
+		}
+		r.Use(c.Middleware)
+	}
+
+	if cfg.Validation.Enabled {
+		v, err := NewValidator(cfg.Validation, spec)
+		if err != nil {
+			return nil, err
+		}
+		r.Use(v.Middleware)
+	}
+
+	// Added after validation so requests rejected before reaching the handler aren't given the policy of the route
+	r.Use(NewCacheController(cfg.CacheControl).Middleware)
+
+	for _, s := range services {
+		if err := s.AddRoutes(r); err != nil {
+			return nil, ErrAddRoutes.Wrap(err)
+		}
+	}
+
+	var handler http.Handler = r
+	if len(cfg.CORS.AllowedOrigins) > 0 {
+		c, err := NewCORS(cfg.CORS)
+		if err != nil {
+			return nil, err
+		}
+		handler = c.Handler(r)
+	}
+
+	// Wraps the router and CORS so requests rejected before reaching a route, such as 404s, 405s and preflight
+	// requests, are still traced, given a request ID, measured and logged. Added before the recovery middleware of
+	// the router so requests that panicked are logged with the 500 they were answered with.
+	a, err := NewAccessLogger(cfg.AccessLog, os.Stdout)
+	if err != nil {
+		return nil, err
+	}
+	handler = a.Middleware(handler)
+
+	if err := metrics.Register(requestsTotal, requestDuration, requestsInFlight); err != nil {
+		return nil, err
 	}
+	name := cfg.Name
+	if name == "" {
+		name = cfg.Port
+	}
+	handler = metricsMiddleware(name)(handler)
+
+	handler = logTracingMiddleware(handler)
+	handler = requestid.Middleware(handler)
+	handler = tracingMiddleware(handler)
 
 	return &Server{
 		server: &http.Server{
@@ -57,7 +145,7 @@ func New(s Service, cfg Config) (*Server, error) {
 				baseContext := context.Background()
 				return logging.With(baseContext, logging.From(baseContext))
 			},
-			Handler:           r,
+			Handler:           handler,
 			ReadHeaderTimeout: readHeaderTimeout,
 		},
 		port: cfg.Port,
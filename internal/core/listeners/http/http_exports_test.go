@@ -0,0 +1,39 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/speakeasy-api/rest-template-go/internal/core/metrics"
+)
+
+// ExportSetTimeNow makes successive calls for the current time return each of the times, repeating the last.
+func ExportSetTimeNow(times ...time.Time) {
+	i := 0
+	timeNow = func() time.Time {
+		t := times[i]
+		if i < len(times)-1 {
+			i++
+		}
+		return t
+	}
+}
+
+// ExportMetricsMiddleware registers the request metrics and returns the middleware recording them for the listener.
+func ExportMetricsMiddleware(listener string) (func(http.Handler) http.Handler, error) {
+	if err := metrics.Register(requestsTotal, requestDuration, requestsInFlight); err != nil {
+		return nil, err
+	}
+	return metricsMiddleware(listener), nil
+}
+
+// ExportRequestsTotal returns the number of requests counted with the labels.
+func ExportRequestsTotal(listener, method, route, code string) float64 {
+	return testutil.ToFloat64(requestsTotal.WithLabelValues(listener, method, route, code))
+}
+
+// ExportRecordRoute records the route matched by the router for the middleware wrapping it.
+func ExportRecordRoute(next http.Handler) http.Handler {
+	return recordRoute(next)
+}
@@ -1,18 +1,217 @@
 package http
 
 import (
+	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
 
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
 	"github.com/speakeasy-api/rest-template-go/internal/core/logging"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
-func requestLoggingMiddleware(next http.Handler) http.Handler {
+// ErrUnknownLogFormat is returned when the access log is configured with a format that isn't supported.
+const ErrUnknownLogFormat = errors.Error("unknown access log format")
+
+const (
+	// LogFormatJSON logs requests as structured entries of the application log.
+	LogFormatJSON = "json"
+	// LogFormatCombined logs requests as lines in the Apache combined log format.
+	LogFormatCombined = "combined"
+
+	combinedTimeFormat = "02/Jan/2006:15:04:05 -0700"
+)
+
+var timeNow = time.Now
+
+// AccessLogConfig represents the configuration of logging the outcome of requests.
+type AccessLogConfig struct {
+	// Format is either json or combined, defaults to json.
+	Format string `yaml:"format"`
+	// SuccessSampling logs one in every SuccessSampling 2xx responses, defaults to logging every response. Other
+	// responses are always logged.
+	SuccessSampling int `yaml:"successSampling"`
+	// Exclude are the path templates of routes that aren't logged such as /health, defaults to /health.
+	Exclude []string `yaml:"exclude"`
+}
+
+// AccessLogger logs the outcome of each request once it has been handled.
+type AccessLogger struct {
+	format   string
+	sampling uint64
+	exclude  map[string]bool
+	out      io.Writer
+
+	successes uint64
+}
+
+// NewAccessLogger will instantiate a new instance of AccessLogger, lines in the combined format are written to out.
+func NewAccessLogger(cfg AccessLogConfig, out io.Writer) (*AccessLogger, error) {
+	a := &AccessLogger{
+		format:   cfg.Format,
+		sampling: 1,
+		exclude:  map[string]bool{},
+		out:      out,
+	}
+
+	switch a.format {
+	case "":
+		a.format = LogFormatJSON
+	case LogFormatJSON, LogFormatCombined:
+	default:
+		return nil, ErrUnknownLogFormat.Wrap(errors.New(cfg.Format))
+	}
+
+	if cfg.SuccessSampling > 1 {
+		a.sampling = uint64(cfg.SuccessSampling)
+	}
+
+	exclude := cfg.Exclude
+	if exclude == nil {
+		exclude = []string{"/health"}
+	}
+	for _, e := range exclude {
+		a.exclude[e] = true
+	}
+
+	return a, nil
+}
+
+// Middleware will add the method of the request to its logger then log its route, status, duration, bytes written,
+// user agent and remote address once the rest of the chain has handled it.
+func (a *AccessLogger) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ctx := logging.WithFields(r.Context(), zap.String("uri", r.RequestURI))
+		start := timeNow()
+
+		r, matched := trackRoute(r)
+
+		ctx := logging.WithFields(r.Context(), zap.String("method", r.Method))
+
+		aw := &accessLogWriter{ResponseWriter: w}
+
+		next.ServeHTTP(aw, r.WithContext(ctx))
+
+		route := matched.name()
+		if a.exclude[route] {
+			return
+		}
+
+		status := aw.status
+		if status == 0 {
+			status = http.StatusOK
+		}
 
-		logging.From(ctx).Info("request", zap.String("method", r.Method))
+		// Only successes are sampled so every failure can be investigated
+		if status >= http.StatusOK && status < http.StatusMultipleChoices && atomic.AddUint64(&a.successes, 1)%a.sampling != 0 {
+			return
+		}
 
-		next.ServeHTTP(w, r.WithContext(ctx))
+		duration := timeNow().Sub(start)
+
+		if a.format == LogFormatCombined {
+			a.writeCombined(r, status, aw.bytes, start)
+			return
+		}
+
+		level := zapcore.InfoLevel
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = zapcore.ErrorLevel
+		case status >= http.StatusBadRequest:
+			level = zapcore.WarnLevel
+		}
+
+		if ce := logging.From(ctx).Check(level, "request handled"); ce != nil {
+			ce.Write(
+				zap.String("route", route),
+				zap.Int("status", status),
+				zap.Duration("duration", duration),
+				zap.Int64("bytes", aw.bytes),
+				zap.String("user_agent", r.UserAgent()),
+				zap.String("remote_addr", remoteHost(r)),
+			)
+		}
 	})
 }
+
+// writeCombined will write the Apache combined log format line of the request.
+func (a *AccessLogger) writeCombined(r *http.Request, status int, bytes int64, start time.Time) {
+	size := "-"
+	if bytes > 0 {
+		size = strconv.FormatInt(bytes, 10)
+	}
+
+	user := "-"
+	if u, _, ok := r.BasicAuth(); ok && u != "" {
+		user = u
+	}
+
+	line := fmt.Sprintf("%s - %s [%s] %q %d %s %q %q\n",
+		remoteHost(r),
+		user,
+		start.Format(combinedTimeFormat),
+		r.Method+" "+r.RequestURI+" "+r.Proto,
+		status,
+		size,
+		orDash(r.Referer()),
+		orDash(r.UserAgent()),
+	)
+
+	if _, err := io.WriteString(a.out, line); err != nil {
+		logging.From(r.Context()).Error("failed to write access log", zap.Error(err))
+	}
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// accessLogWriter records the status and number of bytes of the response.
+type accessLogWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *accessLogWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *accessLogWriter) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	n, err := w.ResponseWriter.Write(data)
+	w.bytes += int64(n)
+
+	return n, err
+}
+
+func (w *accessLogWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		f.Flush()
+	}
+}
@@ -0,0 +1,263 @@
+package http_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	httplistener "github.com/speakeasy-api/rest-template-go/internal/core/listeners/http"
+	"github.com/speakeasy-api/rest-template-go/internal/core/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func newAccessLogRouter(t *testing.T, cfg httplistener.AccessLogConfig, out *bytes.Buffer) (*mux.Router, *observer.ObservedLogs) {
+	t.Helper()
+
+	a, err := httplistener.NewAccessLogger(cfg, out)
+	require.NoError(t, err)
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(core)
+
+	r := mux.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(logging.With(r.Context(), logger)))
+		})
+	})
+	r.Use(a.Middleware)
+	r.Use(httplistener.ExportRecordRoute)
+	r.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}).Methods(http.MethodGet)
+	r.HandleFunc("/v1/user/{id}", func(w http.ResponseWriter, r *http.Request) {
+		switch mux.Vars(r)["id"] {
+		case "missing":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"status":404}`))
+		case "broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			logging.From(r.Context()).Info("getting user")
+			_, _ = w.Write([]byte(`{"data":{}}`))
+		}
+	}).Methods(http.MethodGet)
+
+	return r, logs
+}
+
+func TestAccessLogger_Middleware_JSON(t *testing.T) {
+	start := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		path      string
+		wantLevel zapcore.Level
+		wantCode  int
+		wantBytes int64
+	}{
+		{
+			name:      "success",
+			path:      "/v1/user/some-id",
+			wantLevel: zapcore.InfoLevel,
+			wantCode:  http.StatusOK,
+			wantBytes: 11,
+		},
+		{
+			name:      "client error",
+			path:      "/v1/user/missing",
+			wantLevel: zapcore.WarnLevel,
+			wantCode:  http.StatusNotFound,
+			wantBytes: 14,
+		},
+		{
+			name:      "server error",
+			path:      "/v1/user/broken",
+			wantLevel: zapcore.ErrorLevel,
+			wantCode:  http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httplistener.ExportSetTimeNow(start, start.Add(25*time.Millisecond))
+
+			r, logs := newAccessLogRouter(t, httplistener.AccessLogConfig{}, nil)
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.RemoteAddr = "203.0.113.10:51234"
+			req.Header.Set("User-Agent", "test-agent/1.0")
+
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			entries := logs.FilterMessage("request handled").All()
+			require.Len(t, entries, 1)
+			assert.Equal(t, tt.wantLevel, entries[0].Level)
+			assert.Equal(t, map[string]interface{}{
+				"method":      http.MethodGet,
+				"route":       "/v1/user/{id}",
+				"status":      int64(tt.wantCode),
+				"duration":    25 * time.Millisecond,
+				"bytes":       tt.wantBytes,
+				"user_agent":  "test-agent/1.0",
+				"remote_addr": "203.0.113.10",
+			}, entries[0].ContextMap())
+		})
+	}
+}
+
+func TestAccessLogger_Middleware_HandlerLogs(t *testing.T) {
+	r, logs := newAccessLogRouter(t, httplistener.AccessLogConfig{}, nil)
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/user/some-id", nil))
+
+	entries := logs.FilterMessage("getting user").All()
+	require.Len(t, entries, 1)
+	assert.Equal(t, map[string]interface{}{"method": http.MethodGet, "route": "/v1/user/{id}"}, entries[0].ContextMap())
+}
+
+func TestAccessLogger_Middleware_WrappingRouter(t *testing.T) {
+	a, err := httplistener.NewAccessLogger(httplistener.AccessLogConfig{}, nil)
+	require.NoError(t, err)
+
+	core, logs := observer.New(zapcore.DebugLevel)
+
+	r := mux.NewRouter()
+	r.Use(httplistener.ExportRecordRoute)
+	r.HandleFunc("/v1/user/{id}", func(w http.ResponseWriter, r *http.Request) {
+		logging.From(r.Context()).Info("getting user")
+	}).Methods(http.MethodGet)
+
+	h := a.Middleware(r)
+
+	tests := []struct {
+		name      string
+		method    string
+		path      string
+		wantRoute string
+		wantCode  int64
+	}{
+		{
+			name:      "matched",
+			method:    http.MethodGet,
+			path:      "/v1/user/some-id",
+			wantRoute: "/v1/user/{id}",
+			wantCode:  http.StatusOK,
+		},
+		{
+			name:      "not found",
+			method:    http.MethodGet,
+			path:      "/v1/unknown",
+			wantRoute: "unmatched",
+			wantCode:  http.StatusNotFound,
+		},
+		{
+			name:      "method not allowed",
+			method:    http.MethodDelete,
+			path:      "/v1/user/some-id",
+			wantRoute: "unmatched",
+			wantCode:  http.StatusMethodNotAllowed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			h.ServeHTTP(httptest.NewRecorder(), req.WithContext(logging.With(req.Context(), zap.New(core))))
+
+			entries := logs.TakeAll()
+			require.NotEmpty(t, entries)
+
+			handled := entries[len(entries)-1]
+			assert.Equal(t, "request handled", handled.Message)
+			assert.Equal(t, tt.wantRoute, handled.ContextMap()["route"])
+			assert.Equal(t, tt.wantCode, handled.ContextMap()["status"])
+
+			// Logs of handlers include the route matched by the router
+			for _, e := range entries[:len(entries)-1] {
+				assert.Equal(t, map[string]interface{}{"method": tt.method, "route": tt.wantRoute}, e.ContextMap())
+			}
+		})
+	}
+}
+
+func TestAccessLogger_Middleware_Sampling(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      httplistener.AccessLogConfig
+		paths    []string
+		wantLogs int
+	}{
+		{
+			name:     "health excluded by default",
+			paths:    []string{"/health", "/health", "/v1/user/some-id"},
+			wantLogs: 1,
+		},
+		{
+			name:     "exclusions replace default",
+			cfg:      httplistener.AccessLogConfig{Exclude: []string{"/v1/user/{id}"}},
+			paths:    []string{"/health", "/v1/user/some-id", "/v1/user/missing"},
+			wantLogs: 1,
+		},
+		{
+			name:     "successes sampled",
+			cfg:      httplistener.AccessLogConfig{SuccessSampling: 3},
+			paths:    []string{"/v1/user/1", "/v1/user/2", "/v1/user/3", "/v1/user/4", "/v1/user/5", "/v1/user/6", "/v1/user/7"},
+			wantLogs: 2,
+		},
+		{
+			name:     "failures always logged",
+			cfg:      httplistener.AccessLogConfig{SuccessSampling: 100},
+			paths:    []string{"/v1/user/missing", "/v1/user/broken", "/v1/user/missing"},
+			wantLogs: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, logs := newAccessLogRouter(t, tt.cfg, nil)
+
+			for _, path := range tt.paths {
+				r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+			}
+
+			assert.Equal(t, tt.wantLogs, logs.FilterMessage("request handled").Len())
+		})
+	}
+}
+
+func TestAccessLogger_Middleware_Combined(t *testing.T) {
+	httplistener.ExportSetTimeNow(time.Date(2020, time.January, 2, 15, 4, 5, 0, time.UTC))
+
+	var out bytes.Buffer
+
+	r, logs := newAccessLogRouter(t, httplistener.AccessLogConfig{Format: httplistener.LogFormatCombined}, &out)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/user/missing?fields=email", nil)
+	req.RemoteAddr = "[2001:db8::1]:51234"
+	req.Header.Set("User-Agent", `test "agent"`)
+	req.Header.Set("Referer", "https://app.example.com/users")
+	req.SetBasicAuth("admin", "secret")
+
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/user/broken", nil)
+	req.RemoteAddr = "203.0.113.10:51234"
+
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t,
+		`2001:db8::1 - admin [02/Jan/2020:15:04:05 +0000] "GET /v1/user/missing?fields=email HTTP/1.1" 404 14 "https://app.example.com/users" "test \"agent\""`+"\n"+
+			`203.0.113.10 - - [02/Jan/2020:15:04:05 +0000] "GET /v1/user/broken HTTP/1.1" 500 - "-" "-"`+"\n",
+		out.String())
+	assert.Zero(t, logs.FilterMessage("request handled").Len())
+}
+
+func TestNewAccessLogger_Invalid(t *testing.T) {
+	_, err := httplistener.NewAccessLogger(httplistener.AccessLogConfig{Format: "common"}, nil)
+	assert.ErrorIs(t, err, httplistener.ErrUnknownLogFormat)
+}
@@ -0,0 +1,86 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Labelled by route template rather than path so the number of series is bounded by the routes of the service, and by
+// listener so the requests of each server are told apart.
+var (
+	requestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Number of HTTP requests handled by listener, method, route and status code.",
+	}, []string{"listener", "method", "route", "code"})
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Duration of handling HTTP requests in seconds by listener, method and route.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"listener", "method", "route"})
+	requestsInFlight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "Number of HTTP requests currently being handled by listener.",
+	}, []string{"listener"})
+)
+
+// metricsMiddleware will record the rate, errors and duration of requests to each route of the listener, requests no
+// route handled are recorded as the unmatched route.
+func metricsMiddleware(listener string) func(http.Handler) http.Handler {
+	inFlight := requestsInFlight.WithLabelValues(listener)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := timeNow()
+
+			inFlight.Inc()
+			defer inFlight.Dec()
+
+			r, matched := trackRoute(r)
+
+			sw := &statusWriter{ResponseWriter: w}
+
+			next.ServeHTTP(sw, r)
+
+			status := sw.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			route := matched.name()
+
+			requestsTotal.WithLabelValues(listener, r.Method, route, strconv.Itoa(status)).Inc()
+			requestDuration.WithLabelValues(listener, r.Method, route).Observe(timeNow().Sub(start).Seconds())
+		})
+	}
+}
+
+// statusWriter records the status of the response.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		f.Flush()
+	}
+}
@@ -0,0 +1,95 @@
+package http_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	httplistener "github.com/speakeasy-api/rest-template-go/internal/core/listeners/http"
+	"github.com/speakeasy-api/rest-template-go/internal/core/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsMiddleware(t *testing.T) {
+	mw, err := httplistener.ExportMetricsMiddleware("api")
+	require.NoError(t, err)
+
+	r := mux.NewRouter()
+	r.Use(mw)
+	r.HandleFunc("/v1/user/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if mux.Vars(r)["id"] == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{}}`))
+	}).Methods(http.MethodGet)
+
+	tests := []struct {
+		name string
+		path string
+		code string
+	}{
+		{
+			name: "success",
+			path: "/v1/user/some-id",
+			code: "200",
+		},
+		{
+			name: "not found",
+			path: "/v1/user/missing",
+			code: "404",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+			httplistener.ExportSetTimeNow(start, start.Add(250*time.Millisecond))
+
+			before := httplistener.ExportRequestsTotal("api", http.MethodGet, "/v1/user/{id}", tt.code)
+
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			// Counted by the route template so each user doesn't create a new series
+			assert.Equal(t, before+1, httplistener.ExportRequestsTotal("api", http.MethodGet, "/v1/user/{id}", tt.code))
+		})
+	}
+
+	// Requests to other listeners sharing the route are counted in their own series
+	adminMW, err := httplistener.ExportMetricsMiddleware("admin")
+	require.NoError(t, err)
+
+	admin := mux.NewRouter()
+	admin.Use(adminMW)
+	admin.HandleFunc("/v1/user/{id}", func(w http.ResponseWriter, r *http.Request) {}).Methods(http.MethodGet)
+
+	before := httplistener.ExportRequestsTotal("api", http.MethodGet, "/v1/user/{id}", "200")
+	admin.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/user/some-id", nil))
+	assert.Equal(t, before, httplistener.ExportRequestsTotal("api", http.MethodGet, "/v1/user/{id}", "200"))
+	assert.Equal(t, float64(1), httplistener.ExportRequestsTotal("admin", http.MethodGet, "/v1/user/{id}", "200"))
+
+	// Wrapping the router, requests no route handled are counted under a single route rather than by path
+	routed := mux.NewRouter()
+	routed.Use(httplistener.ExportRecordRoute)
+	routed.HandleFunc("/v1/user/{id}", func(w http.ResponseWriter, r *http.Request) {}).Methods(http.MethodGet)
+
+	h := adminMW(routed)
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/user/some-id", nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/unknown", nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/v1/user/some-id", nil))
+
+	assert.Equal(t, float64(2), httplistener.ExportRequestsTotal("admin", http.MethodGet, "/v1/user/{id}", "200"))
+	assert.Equal(t, float64(1), httplistener.ExportRequestsTotal("admin", http.MethodGet, "unmatched", "404"))
+	assert.Equal(t, float64(1), httplistener.ExportRequestsTotal("admin", http.MethodDelete, "unmatched", "405"))
+
+	w := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body := w.Body.String()
+	assert.Contains(t, body, `http_request_duration_seconds_bucket{listener="api",method="GET",route="/v1/user/{id}",le="0.25"}`)
+	assert.Contains(t, body, `http_requests_in_flight{listener="api"} 0`)
+	assert.NotContains(t, body, "some-id")
+}
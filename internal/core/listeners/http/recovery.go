@@ -0,0 +1,191 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	"github.com/speakeasy-api/rest-template-go/internal/core/logging"
+	"github.com/speakeasy-api/rest-template-go/internal/core/metrics"
+	"github.com/speakeasy-api/rest-template-go/internal/core/problem"
+	"github.com/speakeasy-api/rest-template-go/internal/core/requestid"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	// ErrPanic is the error recorded when a handler panics.
+	ErrPanic = errors.Error("panic recovered")
+	// ErrCrashReportDir is returned when the directory crash reports are written to can't be created.
+	ErrCrashReportDir = errors.Error("failed to create crash report directory")
+)
+
+var panicsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "http_panics_total",
+	Help: "Number of panics recovered from while handling requests.",
+}, []string{"method", "route"})
+
+// RecoveryConfig represents the configuration of recovering from panics in handlers.
+type RecoveryConfig struct {
+	// CrashReports determines whether a report of each panic is written to CrashReportDir.
+	CrashReports bool `yaml:"crashReports"`
+	// CrashReportDir is the directory crash reports are written to, it is created if it doesn't exist.
+	CrashReportDir string `yaml:"crashReportDir"`
+}
+
+// Recoverer recovers from panics in handlers so they result in a 500 rather than a dropped connection.
+type Recoverer struct {
+	crashReportDir string
+}
+
+// NewRecoverer will instantiate a new instance of Recoverer.
+func NewRecoverer(cfg RecoveryConfig) (*Recoverer, error) {
+	if err := metrics.Register(panicsTotal); err != nil {
+		return nil, err
+	}
+
+	rc := &Recoverer{}
+
+	if cfg.CrashReports {
+		if err := os.MkdirAll(cfg.CrashReportDir, 0o750); err != nil {
+			return nil, ErrCrashReportDir.Wrap(err)
+		}
+		rc.crashReportDir = cfg.CrashReportDir
+	}
+
+	return rc, nil
+}
+
+// Middleware will recover from panics in the rest of the chain, logging the stack with the fields of the request's
+// logger, recording the error on its span and responding with a 500 problem. Responses that were already started
+// can't be replaced so are aborted.
+func (rc *Recoverer) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &recoveryWriter{ResponseWriter: w}
+
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+
+			// Used by handlers to deliberately abort the response so isn't a crash
+			if v == http.ErrAbortHandler { //nolint:errorlint,goerr113
+				panic(v)
+			}
+
+			rc.recovered(r, v, debug.Stack())
+
+			if rw.wroteHeader {
+				panic(http.ErrAbortHandler)
+			}
+
+			ctx := r.Context()
+			problem.Write(ctx, w, problem.FromError(ctx, errors.ErrUnknown.Wrap(ErrPanic)))
+		}()
+
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// recovered will report the panic of the request.
+func (rc *Recoverer) recovered(r *http.Request, v interface{}, stack []byte) {
+	ctx := r.Context()
+
+	method, route := r.Method, routeTemplate(r)
+
+	panicsTotal.WithLabelValues(method, route).Inc()
+
+	cause, ok := v.(error)
+	if !ok {
+		cause = errors.New(fmt.Sprint(v))
+	}
+	err := ErrPanic.Wrap(cause)
+
+	logging.From(ctx).Error("panic while handling request", zap.Error(err), zap.String("route", route), zap.ByteString("stack", stack))
+
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(err, trace.WithAttributes(semconv.ExceptionStacktraceKey.String(string(stack))))
+	span.SetStatus(codes.Error, ErrPanic.Error())
+
+	if rc.crashReportDir == "" {
+		return
+	}
+
+	path, werr := rc.writeCrashReport(r, v, stack)
+	if werr != nil {
+		logging.From(ctx).Error("failed to write crash report", zap.Error(werr))
+		return
+	}
+
+	logging.From(ctx).Info("crash report written", zap.String("path", path))
+}
+
+// writeCrashReport will write a report of the panic and the request that caused it to the crash report directory,
+// returning the path of the report.
+func (rc *Recoverer) writeCrashReport(r *http.Request, v interface{}, stack []byte) (string, error) {
+	now := time.Now().UTC()
+	id := requestid.From(r.Context())
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "time: %s\n", now.Format(time.RFC3339Nano))
+	fmt.Fprintf(&b, "request_id: %s\n", id)
+	if sc := trace.SpanContextFromContext(r.Context()); sc.HasTraceID() {
+		fmt.Fprintf(&b, "trace_id: %s\n", sc.TraceID())
+	}
+	fmt.Fprintf(&b, "request: %s %s\n", r.Method, r.URL.RequestURI())
+	fmt.Fprintf(&b, "route: %s\n", routeTemplate(r))
+	fmt.Fprintf(&b, "panic: %v\n\n", v)
+	b.Write(stack)
+
+	name := "crash-" + now.Format("20060102T150405.000000000Z")
+	if id != "" {
+		// Request IDs can come from clients so only safe characters are used in the file name
+		name += "-" + strings.Map(func(r rune) rune {
+			if r == '-' || r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+				return r
+			}
+			return '_'
+		}, id)
+	}
+
+	path := filepath.Join(rc.crashReportDir, name+".txt")
+
+	if err := os.WriteFile(path, []byte(b.String()), 0o600); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+// recoveryWriter tracks whether the response has been started so the recovery middleware knows if it can still
+// be replaced.
+type recoveryWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *recoveryWriter) WriteHeader(status int) {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recoveryWriter) Write(data []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recoveryWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.wroteHeader = true
+		f.Flush()
+	}
+}
@@ -0,0 +1,164 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	httplistener "github.com/speakeasy-api/rest-template-go/internal/core/listeners/http"
+	"github.com/speakeasy-api/rest-template-go/internal/core/metrics"
+	"github.com/speakeasy-api/rest-template-go/internal/core/problem"
+	"github.com/speakeasy-api/rest-template-go/internal/core/requestid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newRecoveryRouter(t *testing.T, cfg httplistener.RecoveryConfig) *mux.Router {
+	t.Helper()
+
+	rc, err := httplistener.NewRecoverer(cfg)
+	require.NoError(t, err)
+
+	r := mux.NewRouter()
+	r.Use(requestid.Middleware)
+	r.Use(rc.Middleware)
+	r.HandleFunc("/v1/user/{id}", func(w http.ResponseWriter, r *http.Request) {
+		panic("nil map in " + mux.Vars(r)["id"])
+	}).Methods(http.MethodGet)
+	r.HandleFunc("/v1/users/stream", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		panic("stream closed")
+	}).Methods(http.MethodGet)
+	r.HandleFunc("/abort", func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}).Methods(http.MethodGet)
+	r.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}).Methods(http.MethodGet)
+
+	return r
+}
+
+func TestRecoverer_Middleware(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "crashes")
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	r := newRecoveryRouter(t, httplistener.RecoveryConfig{CrashReports: true, CrashReportDir: dir})
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/user/some-id", nil)
+	req.Header.Set(requestid.Header, "../some-request-id")
+
+	ctx, span := tp.Tracer("test").Start(req.Context(), "request")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req.WithContext(ctx))
+	span.End()
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+
+	var p problem.Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+	assert.Equal(t, http.StatusInternalServerError, p.Status)
+	assert.Equal(t, errors.ErrUnknown.Error(), p.Detail)
+	assert.Equal(t, "../some-request-id", p.Instance)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	require.Len(t, spans[0].Events(), 1)
+	assert.Equal(t, "exception", spans[0].Events()[0].Name)
+
+	reports, err := filepath.Glob(filepath.Join(dir, "crash-*-___some-request-id.txt"))
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+
+	report, err := os.ReadFile(reports[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(report), "request_id: ../some-request-id\n")
+	assert.Contains(t, string(report), "request: GET /v1/user/some-id\n")
+	assert.Contains(t, string(report), "route: /v1/user/{id}\n")
+	assert.Contains(t, string(report), "panic: nil map in some-id\n")
+	assert.Contains(t, string(report), "runtime/debug.Stack")
+
+	w = httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Contains(t, w.Body.String(), `http_panics_total{method="GET",route="/v1/user/{id}"}`)
+}
+
+func TestRecoverer_Middleware_ResponseStarted(t *testing.T) {
+	r := newRecoveryRouter(t, httplistener.RecoveryConfig{})
+
+	w := httptest.NewRecorder()
+
+	// The server aborts the connection as the client already has the start of the response
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/users/stream", nil))
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestRecoverer_Middleware_Abort(t *testing.T) {
+	dir := t.TempDir()
+
+	r := newRecoveryRouter(t, httplistener.RecoveryConfig{CrashReports: true, CrashReportDir: dir})
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/abort", nil))
+	})
+
+	reports, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, reports)
+}
+
+func TestRecoverer_Middleware_NoPanic(t *testing.T) {
+	r := newRecoveryRouter(t, httplistener.RecoveryConfig{})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestRecoverer_Middleware_Compressed(t *testing.T) {
+	rc, err := httplistener.NewRecoverer(httplistener.RecoveryConfig{})
+	require.NoError(t, err)
+
+	c, err := httplistener.NewCompressor(httplistener.CompressionConfig{Enabled: true})
+	require.NoError(t, err)
+
+	r := mux.NewRouter()
+	r.Use(rc.Middleware)
+	r.Use(c.Middleware)
+	r.HandleFunc("/v1/user/{id}", func(w http.ResponseWriter, r *http.Request) {
+		// Buffered by the compressor as it is too small to compress yet
+		_, _ = w.Write([]byte(`{"data":`))
+		panic("nil pointer")
+	}).Methods(http.MethodGet)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/user/some-id", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+
+	var p problem.Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+	assert.Equal(t, http.StatusInternalServerError, p.Status)
+}
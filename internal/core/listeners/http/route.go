@@ -0,0 +1,73 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/speakeasy-api/rest-template-go/internal/core/logging"
+	"go.uber.org/zap"
+)
+
+// unmatchedRoute labels requests no route handled, such as 404s, 405s and preflight requests answered before reaching
+// the router, so they are still measured without each path creating a new series.
+const unmatchedRoute = "unmatched"
+
+type matchedRouteKey struct{}
+
+// matchedRoute holds the path template of the route the router matched, as the router only makes the route available
+// to the request it passes on so middleware wrapping the router can't see it otherwise.
+type matchedRoute struct {
+	template string
+}
+
+func (m *matchedRoute) name() string {
+	if m.template == "" {
+		return unmatchedRoute
+	}
+	return m.template
+}
+
+// trackRoute returns the request with a matchedRoute the router will fill in once it has matched a route, along with
+// the matchedRoute to read the route from once the request has been handled.
+func trackRoute(r *http.Request) (*http.Request, *matchedRoute) {
+	if m, ok := r.Context().Value(matchedRouteKey{}).(*matchedRoute); ok {
+		return r, m
+	}
+
+	m := &matchedRoute{}
+	if mux.CurrentRoute(r) != nil {
+		m.template = routeTemplate(r)
+	}
+
+	return r.WithContext(context.WithValue(r.Context(), matchedRouteKey{}, m)), m
+}
+
+// recordRoute will record the route matched by the router for the middleware wrapping it and add the route to the
+// logger of the request.
+func recordRoute(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := routeTemplate(r)
+
+		if m, ok := r.Context().Value(matchedRouteKey{}).(*matchedRoute); ok {
+			m.template = route
+		}
+
+		next.ServeHTTP(w, r.WithContext(logging.WithFields(r.Context(), zap.String("route", route))))
+	})
+}
+
+// routeTemplate returns the path template of the route handling the request, so metrics of requests to a route
+// share labels regardless of their path parameters.
+func routeTemplate(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return unmatchedRoute
+	}
+
+	if tmpl, err := route.GetPathTemplate(); err == nil {
+		return tmpl
+	}
+
+	return r.URL.Path
+}
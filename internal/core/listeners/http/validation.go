@@ -0,0 +1,309 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	"github.com/speakeasy-api/rest-template-go/internal/core/logging"
+	"github.com/speakeasy-api/rest-template-go/internal/core/problem"
+	"go.uber.org/zap"
+)
+
+const (
+	// ErrLoadSpec is returned when the OpenAPI document can't be loaded.
+	ErrLoadSpec = errors.Error("failed to load openapi spec")
+	// ErrInvalidSpec is returned when the OpenAPI document isn't valid.
+	ErrInvalidSpec = errors.Error("invalid openapi spec")
+	// ErrInvalidParameter describes a parameter of a request that doesn't match the OpenAPI document.
+	ErrInvalidParameter = errors.Error("invalid_parameter: parameter doesn't match the api spec")
+	// ErrInvalidBody describes a field of a request body that doesn't match the OpenAPI document.
+	ErrInvalidBody = errors.Error("invalid_body: body doesn't match the api spec")
+)
+
+// ResponseValidation is an enum of the ways responses that don't match the OpenAPI document are handled.
+type ResponseValidation string
+
+const (
+	// ResponseValidationOff disables validation of responses.
+	ResponseValidationOff ResponseValidation = "off"
+	// ResponseValidationLog logs responses that don't match the document but still sends them.
+	ResponseValidationLog ResponseValidation = "log"
+	// ResponseValidationFail replaces responses that don't match the document with an internal server error.
+	ResponseValidationFail ResponseValidation = "fail"
+)
+
+// ValidationConfig represents the configuration of validating requests and responses against an OpenAPI document.
+type ValidationConfig struct {
+	// Enabled determines whether requests and responses are validated against the OpenAPI document.
+	Enabled bool `yaml:"enabled"`
+	// Responses determines how responses are validated, defaults to off.
+	Responses ResponseValidation `yaml:"responses"`
+}
+
+// Validator validates requests and responses against the operations in an OpenAPI document.
+type Validator struct {
+	router    routers.Router
+	responses ResponseValidation
+}
+
+// NewValidator will load the OpenAPI document from the spec and instantiate a new instance of Validator.
+func NewValidator(cfg ValidationConfig, spec []byte) (*Validator, error) {
+	registerBinaryBodyDecoders()
+
+	doc, err := openapi3.NewLoader().LoadFromData(spec)
+	if err != nil {
+		return nil, ErrLoadSpec.Wrap(err)
+	}
+
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, ErrInvalidSpec.Wrap(err)
+	}
+
+	// Servers are only examples of where the API is hosted so requests are matched on their path alone
+	doc.Servers = nil
+
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, ErrInvalidSpec.Wrap(err)
+	}
+
+	responses := cfg.Responses
+	if responses == "" {
+		responses = ResponseValidationOff
+	}
+
+	return &Validator{
+		router:    router,
+		responses: responses,
+	}, nil
+}
+
+// Middleware will reject requests that don't match their operation in the document before they reach the handler.
+// Requests for routes that aren't in the document are not validated.
+func (v *Validator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		route, pathParams, err := v.router.FindRoute(r)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    r,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    &openapi3filter.Options{MultiError: true},
+		}
+
+		if err := openapi3filter.ValidateRequest(ctx, input); err != nil {
+			// The body is read to validate it so oversized bodies fail here rather than in the handler
+			if errors.Is(err, ErrBodyTooLarge) {
+				WriteBodyTooLarge(ctx, w)
+				return
+			}
+
+			logging.From(ctx).Warn("request doesn't match openapi spec", zap.Error(err))
+
+			p := problem.FromError(ctx, errors.WithFieldErrors(errors.ErrInvalidRequest, requestFieldErrors(err)...))
+			if unsupportedContentType(err) {
+				p.Status = http.StatusUnsupportedMediaType
+			}
+
+			problem.Write(ctx, w, p)
+			return
+		}
+
+		if v.responses == ResponseValidationOff {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rec := &responseRecorder{ResponseWriter: w}
+
+		next.ServeHTTP(rec, r)
+
+		// Streamed responses have already been sent so can't be validated
+		if rec.streaming {
+			return
+		}
+
+		if err := openapi3filter.ValidateResponse(ctx, &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: input,
+			Status:                 rec.statusCode(),
+			Header:                 rec.Header(),
+			Body:                   io.NopCloser(bytes.NewReader(rec.body.Bytes())),
+			Options:                &openapi3filter.Options{MultiError: true, IncludeResponseStatus: true},
+		}); err != nil {
+			logging.From(ctx).Error("response doesn't match openapi spec", zap.Error(err), zap.Int("status", rec.statusCode()))
+
+			if v.responses == ResponseValidationFail {
+				// The error isn't the representation the cache policy and validators set by the handler describe
+				for _, h := range []string{"Cache-Control", "ETag", "Last-Modified"} {
+					rec.Header().Del(h)
+				}
+
+				problem.Write(ctx, w, problem.FromError(ctx, errors.ErrUnknown))
+				return
+			}
+		}
+
+		rec.send()
+	})
+}
+
+// responseRecorder buffers a response so it can be validated before it is sent. Responses that are flushed
+// are assumed to be streamed and are sent straight away.
+type responseRecorder struct {
+	http.ResponseWriter
+	status    int
+	body      bytes.Buffer
+	streaming bool
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.streaming {
+		r.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	if r.streaming {
+		return r.ResponseWriter.Write(data)
+	}
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.body.Write(data)
+}
+
+func (r *responseRecorder) Flush() {
+	if !r.streaming {
+		r.streaming = true
+		r.send()
+	}
+
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (r *responseRecorder) statusCode() int {
+	if r.status == 0 {
+		return http.StatusOK
+	}
+	return r.status
+}
+
+func (r *responseRecorder) send() {
+	r.ResponseWriter.WriteHeader(r.statusCode())
+	_, _ = r.ResponseWriter.Write(r.body.Bytes())
+	r.body.Reset()
+}
+
+// requestFieldErrors describes the parameters and parts of the body that caused a request to fail validation.
+func requestFieldErrors(err error) []errors.FieldError {
+	var fields []errors.FieldError
+
+	// Checked without unwrapping as request errors wrap the schema errors of their body in a MultiError
+	if multi, ok := err.(openapi3.MultiError); ok { //nolint:errorlint
+		for _, e := range multi {
+			fields = append(fields, requestFieldErrors(e)...)
+		}
+		return fields
+	}
+
+	var reqErr *openapi3filter.RequestError
+	if !errors.As(err, &reqErr) {
+		return nil
+	}
+
+	switch {
+	case reqErr.Parameter != nil:
+		field := ErrInvalidParameter.Field(reqErr.Parameter.Name)
+
+		var schemaErr *openapi3.SchemaError
+		if errors.As(reqErr.Err, &schemaErr) {
+			field.Message = schemaErr.Reason
+		} else if reqErr.Reason != "" {
+			field.Message = reqErr.Reason
+		}
+
+		return []errors.FieldError{field}
+	case reqErr.RequestBody != nil:
+		schemaErrs, ok := reqErr.Err.(openapi3.MultiError) //nolint:errorlint
+		if !ok {
+			schemaErrs = openapi3.MultiError{reqErr.Err}
+		}
+
+		for _, e := range schemaErrs {
+			var schemaErr *openapi3.SchemaError
+			if errors.As(e, &schemaErr) {
+				fields = append(fields, errors.FieldError{
+					Field:   fieldPath(schemaErr.JSONPointer()),
+					Code:    ErrInvalidBody.Code(),
+					Message: schemaErr.Reason,
+				})
+			}
+		}
+
+		if len(fields) == 0 {
+			fields = append(fields, ErrInvalidBody.Field(""))
+		}
+	}
+
+	return fields
+}
+
+// unsupportedContentType returns whether the request failed validation because its body is encoded with a media
+// type the operation doesn't support.
+func unsupportedContentType(err error) bool {
+	if multi, ok := err.(openapi3.MultiError); ok { //nolint:errorlint
+		for _, e := range multi {
+			if unsupportedContentType(e) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var reqErr *openapi3filter.RequestError
+	if !errors.As(err, &reqErr) {
+		return false
+	}
+
+	// The reason is the only way kin-openapi distinguishes this failure, it uses the same check to respond with a 415
+	return reqErr.RequestBody != nil && strings.HasPrefix(reqErr.Reason, "header Content-Type has unexpected value")
+}
+
+// fieldPath formats a JSON pointer to a field such as /filters/0/value as filters[0].value.
+func fieldPath(pointer []string) string {
+	var b strings.Builder
+
+	for _, p := range pointer {
+		if _, err := strconv.Atoi(p); err == nil {
+			b.WriteString("[" + p + "]")
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString(".")
+		}
+		b.WriteString(p)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,76 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	httplistener "github.com/speakeasy-api/rest-template-go/internal/core/listeners/http"
+	"github.com/speakeasy-api/rest-template-go/internal/core/problem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSpec = `
+openapi: 3.0.3
+info:
+  title: test
+  version: 1.0.0
+paths:
+  /v1/thing:
+    get:
+      operationId: getThing
+      responses:
+        "200":
+          description: A thing.
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  name:
+                    type: string
+                required:
+                  - name
+`
+
+func TestValidator_Middleware_ResponseFail(t *testing.T) {
+	v, err := httplistener.NewValidator(httplistener.ValidationConfig{
+		Enabled:   true,
+		Responses: httplistener.ResponseValidationFail,
+	}, []byte(testSpec))
+	require.NoError(t, err)
+
+	r := mux.NewRouter()
+	r.Use(v.Middleware)
+	r.HandleFunc("/v1/thing", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "private, max-age=60")
+		w.Header().Set("ETag", `"some-etag"`)
+		w.Header().Set("Last-Modified", "Wed, 01 Jan 2020 00:00:00 GMT")
+		_, _ = w.Write([]byte(`{"other":"field"}`))
+	}).Methods(http.MethodGet)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/thing", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+
+	// The cache headers described the response that was replaced so mustn't be sent with the error
+	assert.Empty(t, w.Header().Get("Cache-Control"))
+	assert.Empty(t, w.Header().Get("ETag"))
+	assert.Empty(t, w.Header().Get("Last-Modified"))
+
+	var p problem.Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+	assert.Equal(t, errors.ErrUnknown.Error(), p.Detail)
+}
+
+func TestNewValidator_Error(t *testing.T) {
+	_, err := httplistener.NewValidator(httplistener.ValidationConfig{Enabled: true}, []byte("not: [an openapi"))
+	assert.ErrorIs(t, err, httplistener.ErrLoadSpec)
+}
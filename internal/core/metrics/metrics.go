@@ -0,0 +1,107 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	"github.com/speakeasy-api/rest-template-go/internal/core/logging"
+)
+
+const (
+	// ErrRegister is returned when a collector can't be registered.
+	ErrRegister = errors.Error("failed to register metrics collector")
+	// ErrServer is the error returned when the metrics server stops due to an error.
+	ErrServer = errors.Error("metrics listen stopped with error")
+)
+
+const readHeaderTimeout = 60 * time.Second
+
+// registry includes the metrics of the Go runtime, the process and the build of the binary in every scrape.
+var registry = func() *prometheus.Registry {
+	r := prometheus.NewRegistry()
+	r.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		collectors.NewBuildInfoCollector(),
+	)
+	return r
+}()
+
+// Config represents the configuration of exposing metrics.
+type Config struct {
+	// Port is the port of a separate admin listener serving /metrics so it isn't exposed with the API, metrics are
+	// served by the HTTP listener if it isn't set.
+	Port string `yaml:"port"`
+}
+
+// Register registers collectors with the registry exposed by Handler, registering a collector that is already
+// registered is a noop so constructors can register the package level collectors they use.
+func Register(cs ...prometheus.Collector) error {
+	for _, c := range cs {
+		if err := registry.Register(c); err != nil {
+			var are prometheus.AlreadyRegisteredError
+			if errors.As(err, &are) && are.ExistingCollector == c {
+				continue
+			}
+
+			return ErrRegister.Wrap(err)
+		}
+	}
+
+	return nil
+}
+
+// Handler returns a http.Handler that exposes the registered metrics in the prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// Service provides the /metrics route to a HTTP listener.
+type Service struct{}
+
+// AddRoutes will add the /metrics route to the router.
+func (Service) AddRoutes(r *mux.Router) error {
+	r.Handle("/metrics", Handler()).Methods(http.MethodGet).Name("getMetrics")
+	return nil
+}
+
+// Server serves /metrics on its own port.
+type Server struct {
+	server *http.Server
+	port   string
+}
+
+// NewServer will instantiate a new instance of Server.
+func NewServer(cfg Config) *Server {
+	r := mux.NewRouter()
+	_ = Service{}.AddRoutes(r)
+
+	return &Server{
+		server: &http.Server{
+			Addr:              fmt.Sprintf(":%s", cfg.Port),
+			Handler:           r,
+			ReadHeaderTimeout: readHeaderTimeout,
+		},
+		port: cfg.Port,
+	}
+}
+
+// Listen starts the server and listens on the configured port.
+func (s *Server) Listen(ctx context.Context) error {
+	logging.From(ctx).Info(fmt.Sprintf("metrics server starting on port: %s", s.port))
+
+	if err := s.server.ListenAndServe(); err != nil {
+		return ErrServer.Wrap(err)
+	}
+
+	logging.From(ctx).Info("metrics server stopped")
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/speakeasy-api/rest-template-go/internal/core/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegister(t *testing.T) {
+	c := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "test_registered_total",
+		Help: "Test counter.",
+	})
+	c.Inc()
+
+	require.NoError(t, metrics.Register(c))
+	// Registering the same collector again is a noop
+	require.NoError(t, metrics.Register(c))
+
+	// A different collector with the same name conflicts
+	err := metrics.Register(prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "test_registered_total",
+		Help: "Test counter.",
+	}))
+	assert.ErrorIs(t, err, metrics.ErrRegister)
+
+	r := mux.NewRouter()
+	require.NoError(t, metrics.Service{}.AddRoutes(r))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	body := w.Body.String()
+	assert.Contains(t, body, "test_registered_total 1")
+	assert.Contains(t, body, "go_goroutines")
+	assert.Contains(t, body, "process_cpu_seconds_total")
+	assert.Contains(t, body, "go_build_info")
+}
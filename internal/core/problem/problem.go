@@ -0,0 +1,93 @@
+// Package problem renders errors as RFC 7807 problem details (https://www.rfc-editor.org/rfc/rfc7807).
+package problem
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	"github.com/speakeasy-api/rest-template-go/internal/core/logging"
+	"github.com/speakeasy-api/rest-template-go/internal/core/requestid"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// ContentType is the media type of problem details serialized as JSON.
+const ContentType = "application/problem+json"
+
+const typePrefix = "urn:problem-type:"
+
+// Problem represents the details of an error returned to a client.
+type Problem struct {
+	// Type identifies the kind of problem, each kind has the same title.
+	Type string `json:"type"`
+	// Title is a short human readable summary of the kind of problem.
+	Title string `json:"title"`
+	// Status is the HTTP status code of the response.
+	Status int `json:"status"`
+	// Detail is a human readable explanation specific to this occurrence of the problem.
+	Detail string `json:"detail,omitempty"`
+	// Instance identifies this occurrence of the problem so it can be found in the logs.
+	Instance string `json:"instance,omitempty"`
+	// Errors details the fields of the request that failed validation.
+	Errors []errors.FieldError `json:"errors,omitempty"`
+}
+
+// kinds maps the category of an error to the status and generic error that determine its type and title.
+var kinds = map[errors.Category]struct {
+	status int
+	err    errors.Error
+}{
+	errors.CategoryInvalidRequest:  {status: http.StatusBadRequest, err: errors.ErrInvalidRequest},
+	errors.CategoryValidation:      {status: http.StatusBadRequest, err: errors.ErrValidation},
+	errors.CategoryNotFound:        {status: http.StatusNotFound, err: errors.ErrNotFound},
+	errors.CategoryConflict:        {status: http.StatusConflict, err: errors.ErrConflict},
+	errors.CategoryUnauthenticated: {status: http.StatusUnauthorized, err: errors.ErrUnauthenticated},
+	errors.CategoryForbidden:       {status: http.StatusForbidden, err: errors.ErrForbidden},
+	errors.CategoryUnavailable:     {status: http.StatusServiceUnavailable, err: errors.ErrUnavailable},
+	errors.CategoryRateLimited:     {status: http.StatusTooManyRequests, err: errors.ErrRateLimited},
+	errors.CategoryUnknown:         {status: http.StatusInternalServerError, err: errors.ErrUnknown},
+}
+
+// FromError will build the problem describing the error, its type and status are determined by its category.
+func FromError(ctx context.Context, err error) Problem {
+	kind, ok := kinds[errors.CategoryOf(err)]
+	if !ok {
+		kind = kinds[errors.CategoryUnknown]
+	}
+
+	p := Problem{
+		Type:   typePrefix + kind.err.Code(),
+		Title:  kind.err.Message(),
+		Status: kind.status,
+		Detail: errors.PublicMessage(err),
+		Errors: errors.FieldErrors(err),
+	}
+
+	// The request ID is returned to the client and included in every log line of the request so identifies the
+	// occurrence, the trace ID is used outside of requests such as when handling events
+	if id := requestid.From(ctx); id != "" {
+		p.Instance = id
+	} else if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		p.Instance = sc.TraceID().String()
+	}
+
+	return p
+}
+
+// Write will write the problem as the response.
+func Write(ctx context.Context, w http.ResponseWriter, p Problem) {
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(p.Status)
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		logging.From(ctx).Error("failed to serialize problem response", zap.Error(err))
+		return
+	}
+
+	if _, err := w.Write(data); err != nil {
+		logging.From(ctx).Error("failed to write problem response", zap.Error(err))
+	}
+}
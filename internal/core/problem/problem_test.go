@@ -0,0 +1,133 @@
+package problem_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	"github.com/speakeasy-api/rest-template-go/internal/core/problem"
+	"github.com/speakeasy-api/rest-template-go/internal/core/requestid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const errInvalidEmail = errors.Error("invalid_email: email is invalid")
+
+func TestFromError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want problem.Problem
+	}{
+		{
+			name: "validation with fields",
+			err:  errors.WithFieldErrors(errInvalidEmail.Wrap(errors.ErrValidation.Wrap(errors.New("check_violation"))), errInvalidEmail.Field("email")),
+			want: problem.Problem{
+				Type:   "urn:problem-type:err_validation",
+				Title:  "failed validation",
+				Status: http.StatusBadRequest,
+				Detail: "invalid_email: email is invalid",
+				Errors: []errors.FieldError{{Field: "email", Code: "invalid_email", Message: "email is invalid"}},
+			},
+		},
+		{
+			name: "structured conflict",
+			err:  errors.WithFieldErrors(errInvalidEmail.Structured(errors.CategoryConflict).Wrap(errors.New("unique_violation")), errInvalidEmail.Field("email")),
+			want: problem.Problem{
+				Type:   "urn:problem-type:err_conflict",
+				Title:  "conflicts with current state",
+				Status: http.StatusConflict,
+				Detail: "invalid_email: email is invalid",
+				Errors: []errors.FieldError{{Field: "email", Code: "invalid_email", Message: "email is invalid"}},
+			},
+		},
+		{
+			name: "invalid request",
+			err:  errors.ErrInvalidRequest.Wrap(errors.New("unexpected EOF")),
+			want: problem.Problem{
+				Type:   "urn:problem-type:err_invalid_request",
+				Title:  "invalid request received",
+				Status: http.StatusBadRequest,
+				Detail: "err_invalid_request: invalid request received",
+			},
+		},
+		{
+			name: "not found",
+			err:  errors.ErrNotFound,
+			want: problem.Problem{
+				Type:   "urn:problem-type:err_not_found",
+				Title:  "not found",
+				Status: http.StatusNotFound,
+				Detail: "err_not_found: not found",
+			},
+		},
+		{
+			name: "unexpected error",
+			err:  errors.New("connection refused"),
+			want: problem.Problem{
+				Type:   "urn:problem-type:err_unknown",
+				Title:  "unknown error occurred",
+				Status: http.StatusInternalServerError,
+				Detail: "err_unknown: unknown error occurred",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, problem.FromError(context.Background(), tt.err))
+		})
+	}
+}
+
+func TestFromError_Instance(t *testing.T) {
+	traceID, err := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	require.NoError(t, err)
+	spanID, err := trace.SpanIDFromHex("00f067aa0ba902b7")
+	require.NoError(t, err)
+
+	traced := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID}))
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{
+			name: "request id",
+			ctx:  requestid.With(traced, "some-request-id"),
+			want: "some-request-id",
+		},
+		{
+			name: "trace id outside of requests",
+			ctx:  traced,
+			want: "4bf92f3577b34da6a3ce929d0e0e4736",
+		},
+		{
+			name: "neither",
+			ctx:  context.Background(),
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, problem.FromError(tt.ctx, errors.ErrNotFound).Instance)
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	problem.Write(context.Background(), w, problem.Problem{
+		Type:   "urn:problem-type:err_not_found",
+		Title:  "not found",
+		Status: http.StatusNotFound,
+	})
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"type":"urn:problem-type:err_not_found","title":"not found","status":404}`, w.Body.String())
+}
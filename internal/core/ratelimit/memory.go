@@ -0,0 +1,101 @@
+package ratelimit
+
+import (
+	"container/list"
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+const sweepInterval = time.Minute
+
+type bucket struct {
+	key     string
+	tokens  float64
+	updated time.Time
+	full    time.Time
+}
+
+// MemoryBackend keeps token buckets in memory, so each instance of the service limits clients independently.
+type MemoryBackend struct {
+	maxBuckets int
+
+	mu        sync.Mutex
+	buckets   map[string]*list.Element
+	lru       *list.List
+	lastSweep time.Time
+}
+
+// NewMemoryBackend will instantiate a new instance of MemoryBackend keeping up to maxBuckets buckets, defaulting to
+// DefaultMaxBuckets if it isn't positive.
+func NewMemoryBackend(maxBuckets int) *MemoryBackend {
+	if maxBuckets <= 0 {
+		maxBuckets = DefaultMaxBuckets
+	}
+
+	return &MemoryBackend{
+		maxBuckets: maxBuckets,
+		buckets:    map[string]*list.Element{},
+		lru:        list.New(),
+		lastSweep:  timeNow(),
+	}
+}
+
+// Take will refill the bucket at the rate of the rule then remove a token from it if it has one.
+func (m *MemoryBackend) Take(ctx context.Context, key string, rule Rule) (float64, bool, error) {
+	now := timeNow()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sweep(now)
+
+	var b *bucket
+	if e, ok := m.buckets[key]; ok {
+		m.lru.MoveToFront(e)
+		b = e.Value.(*bucket)
+	} else {
+		// The least recently used bucket is evicted once full so clients using many addresses can't exhaust memory or
+		// crowd out new clients, the bucket evicted is the most likely to have refilled
+		if len(m.buckets) >= m.maxBuckets {
+			m.remove(m.lru.Back())
+		}
+
+		b = &bucket{key: key, tokens: rule.Capacity(), updated: now}
+		m.buckets[key] = m.lru.PushFront(b)
+	}
+
+	elapsed := now.Sub(b.updated).Seconds()
+	b.tokens = math.Min(rule.Capacity(), b.tokens+math.Max(0, elapsed)*rule.Rate())
+	b.updated = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+
+	b.full = now.Add(time.Duration((rule.Capacity() - b.tokens) / rule.Rate() * float64(time.Second)))
+
+	return b.tokens, allowed, nil
+}
+
+// sweep will periodically remove buckets that have refilled as they are the same as a new bucket.
+func (m *MemoryBackend) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < sweepInterval {
+		return
+	}
+	m.lastSweep = now
+
+	for _, e := range m.buckets {
+		if !now.Before(e.Value.(*bucket).full) {
+			m.remove(e)
+		}
+	}
+}
+
+// remove will remove the bucket of the element.
+func (m *MemoryBackend) remove(e *list.Element) {
+	m.lru.Remove(e)
+	delete(m.buckets, e.Value.(*bucket).key)
+}
@@ -0,0 +1,282 @@
+//go:generate mockgen -destination=./mocks/ratelimit_mock.go -package mocks github.com/speakeasy-api/rest-template-go/internal/core/ratelimit Backend
+
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	"github.com/speakeasy-api/rest-template-go/internal/core/logging"
+	"github.com/speakeasy-api/rest-template-go/internal/core/problem"
+	"go.uber.org/zap"
+)
+
+const (
+	// ErrInvalidConfig is returned when the rate limiting configuration is invalid.
+	ErrInvalidConfig = errors.Error("invalid rate limit config")
+)
+
+const (
+	// BackendMemory keeps the buckets in memory so limits apply per instance.
+	BackendMemory = "memory"
+	// BackendPostgres keeps the buckets in postgres so limits are shared by all instances.
+	BackendPostgres = "postgres"
+
+	// DefaultMaxBuckets is the number of buckets kept by a backend if the config doesn't set one.
+	DefaultMaxBuckets = 100000
+
+	ipv6PrefixBits = 64
+)
+
+var timeNow = time.Now
+
+// Rule is the limit of a token bucket, clients can make Burst requests at once which are refilled at a rate of
+// Requests per Period.
+type Rule struct {
+	Requests int           `yaml:"requests"`
+	Period   time.Duration `yaml:"period"`
+	// Burst is the capacity of the bucket, defaults to Requests.
+	Burst int `yaml:"burst"`
+}
+
+// Capacity returns the number of tokens in a full bucket.
+func (r Rule) Capacity() float64 {
+	if r.Burst > 0 {
+		return float64(r.Burst)
+	}
+	return float64(r.Requests)
+}
+
+// Rate returns the number of tokens added to the bucket per second.
+func (r Rule) Rate() float64 {
+	return float64(r.Requests) / r.Period.Seconds()
+}
+
+// Unlimited returns whether the rule doesn't limit requests.
+func (r Rule) Unlimited() bool {
+	return r.Requests <= 0
+}
+
+// Config represents the configuration of rate limiting requests.
+type Config struct {
+	// Enabled determines whether requests are rate limited.
+	Enabled bool `yaml:"enabled"`
+	// Backend is where buckets are kept, either memory or postgres, defaults to memory.
+	Backend string `yaml:"backend"`
+	// MaxBuckets is the number of buckets a backend keeps, the least recently used are evicted beyond it. Defaults to
+	// 100000.
+	MaxBuckets int `yaml:"maxBuckets"`
+	// TrustedProxies are the CIDRs of proxies whose X-Forwarded-For header is trusted to contain the client IP.
+	TrustedProxies []string `yaml:"trustedProxies"`
+	// Default is the rule of routes without their own, requests are unlimited if it isn't set.
+	Default Rule `yaml:"default"`
+	// Routes maps routes such as "POST /v1/users/search" to their rule, a rule without requests makes the route unlimited.
+	Routes map[string]Rule `yaml:"routes"`
+}
+
+// Backend represents a type that stores the token buckets of clients.
+type Backend interface {
+	// Take will refill the bucket at the rate of the rule then remove a token from it if it has one, returning
+	// the tokens left and whether a token was taken.
+	Take(ctx context.Context, key string, rule Rule) (float64, bool, error)
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a context identifying the authenticated principal making the request, requests with a
+// principal are limited by it rather than their IP. Only middleware that has verified the credentials of the request,
+// such as an API key, should set it as clients could otherwise get a new bucket for each request.
+func WithPrincipal(ctx context.Context, principal string) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// Limiter limits the rate of requests each client can make to each route.
+type Limiter struct {
+	backend        Backend
+	trustedProxies []*net.IPNet
+	defaultRule    Rule
+	routes         map[string]Rule
+}
+
+// New will instantiate a new instance of Limiter storing buckets in the backend.
+func New(cfg Config, backend Backend) (*Limiter, error) {
+	l := &Limiter{
+		backend:     backend,
+		defaultRule: cfg.Default,
+		routes:      cfg.Routes,
+	}
+
+	switch cfg.Backend {
+	case "", BackendMemory, BackendPostgres:
+	default:
+		return nil, ErrInvalidConfig.Wrap(errors.New("unknown backend " + cfg.Backend))
+	}
+
+	for _, p := range cfg.TrustedProxies {
+		if !strings.Contains(p, "/") {
+			if ip := net.ParseIP(p); ip != nil && ip.To4() != nil {
+				p += "/32"
+			} else {
+				p += "/128"
+			}
+		}
+
+		_, cidr, err := net.ParseCIDR(p)
+		if err != nil {
+			return nil, ErrInvalidConfig.Wrap(err)
+		}
+		l.trustedProxies = append(l.trustedProxies, cidr)
+	}
+
+	rules := map[string]Rule{"default": cfg.Default}
+	for route, rule := range cfg.Routes {
+		rules[route] = rule
+	}
+	for name, rule := range rules {
+		if !rule.Unlimited() && rule.Period <= 0 {
+			return nil, ErrInvalidConfig.Wrap(errors.New("rule " + name + " must have a period"))
+		}
+	}
+
+	return l, nil
+}
+
+// Middleware will reject requests from clients that have exhausted the bucket of the route with a 429, and add the
+// RateLimit headers describing the state of the bucket to every limited response.
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		route := routeOf(r)
+
+		rule, ok := l.routes[route]
+		if !ok {
+			rule = l.defaultRule
+		}
+
+		if rule.Unlimited() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := route + "|" + l.clientKey(r)
+
+		tokens, allowed, err := l.backend.Take(ctx, key, rule)
+		if err != nil {
+			// Fail open so an outage of the backend doesn't take down the API
+			logging.From(ctx).Error("failed to check rate limit", zap.Error(err), zap.String("route", route))
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		setHeaders(w, rule, tokens)
+
+		if !allowed {
+			retryAfter := (1 - tokens) / rule.Rate()
+			w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(retryAfter)))
+
+			logging.From(ctx).Warn("rate limit exceeded", zap.String("route", route))
+
+			problem.Write(ctx, w, problem.FromError(ctx, errors.ErrRateLimited))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientKey identifies the client making the request by its authenticated principal, or by its IP if it hasn't been
+// authenticated. Unverified credentials aren't used as clients could send a new one with each request.
+func (l *Limiter) clientKey(r *http.Request) string {
+	if principal, ok := r.Context().Value(principalKey{}).(string); ok && principal != "" {
+		return "principal:" + principal
+	}
+
+	return "ip:" + l.clientIP(r)
+}
+
+// clientIP returns the IP of the client, using the X-Forwarded-For header if the request came through trusted
+// proxies. The header is read from the right skipping trusted proxies as clients can send their own header which
+// proxies append to. IPv6 clients are identified by their /64 prefix as a single client is usually assigned one.
+func (l *Limiter) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return host
+	}
+	if !l.trusted(ip) {
+		return ipKey(ip)
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			// The header can't be trusted beyond a malformed hop
+			break
+		}
+		ip = hop
+		if !l.trusted(hop) {
+			break
+		}
+	}
+
+	return ipKey(ip)
+}
+
+func ipKey(ip net.IP) string {
+	if ip.To4() != nil {
+		return ip.String()
+	}
+
+	prefix := &net.IPNet{IP: ip.Mask(net.CIDRMask(ipv6PrefixBits, 128)), Mask: net.CIDRMask(ipv6PrefixBits, 128)}
+	return prefix.String()
+}
+
+func (l *Limiter) trusted(ip net.IP) bool {
+	for _, cidr := range l.trustedProxies {
+		if cidr.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// routeOf returns the method and path template of the route handling the request so all requests to a route
+// share a bucket regardless of their path parameters.
+func routeOf(r *http.Request) string {
+	path := r.URL.Path
+	if route := mux.CurrentRoute(r); route != nil {
+		if tmpl, err := route.GetPathTemplate(); err == nil {
+			path = tmpl
+		}
+	}
+
+	return r.Method + " " + path
+}
+
+// setHeaders will set the RateLimit headers from draft-ietf-httpapi-ratelimit-headers describing the bucket.
+func setHeaders(w http.ResponseWriter, rule Rule, tokens float64) {
+	reset := (rule.Capacity() - tokens) / rule.Rate()
+
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(int(rule.Capacity())))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(int(math.Max(0, math.Floor(tokens)))))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(reset)))
+	w.Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d;burst=%d", rule.Requests, ceilSeconds(rule.Period.Seconds()), int(rule.Capacity())))
+}
+
+func ceilSeconds(seconds float64) int {
+	return int(math.Ceil(math.Max(0, seconds)))
+}
@@ -0,0 +1,9 @@
+package ratelimit
+
+import "time"
+
+func ExportSetTimeNow(t time.Time) {
+	timeNow = func() time.Time {
+		return t
+	}
+}
@@ -0,0 +1,359 @@
+package ratelimit_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	"github.com/speakeasy-api/rest-template-go/internal/core/problem"
+	"github.com/speakeasy-api/rest-template-go/internal/core/ratelimit"
+	"github.com/speakeasy-api/rest-template-go/internal/core/ratelimit/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRouter(t *testing.T, cfg ratelimit.Config, backend ratelimit.Backend) *mux.Router {
+	t.Helper()
+
+	l, err := ratelimit.New(cfg, backend)
+	require.NoError(t, err)
+
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	r := mux.NewRouter()
+	r.Use(l.Middleware)
+	r.HandleFunc("/health", ok).Methods(http.MethodGet)
+	r.HandleFunc("/v1/user/{id}", ok).Methods(http.MethodGet)
+	r.HandleFunc("/v1/users/search", ok).Methods(http.MethodPost)
+
+	return r
+}
+
+func TestLimiter_Middleware(t *testing.T) {
+	start := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	cfg := ratelimit.Config{
+		Enabled: true,
+		Default: ratelimit.Rule{Requests: 60, Period: time.Minute, Burst: 3},
+		Routes: map[string]ratelimit.Rule{
+			"POST /v1/users/search": {Requests: 1, Period: 10 * time.Second},
+			"GET /health":           {},
+		},
+	}
+
+	type request struct {
+		method        string
+		path          string
+		after         time.Duration
+		wantCode      int
+		wantLimit     string
+		wantRemaining string
+		wantReset     string
+		wantRetry     string
+	}
+
+	tests := []struct {
+		name     string
+		requests []request
+	}{
+		{
+			name: "burst exhausted then refilled",
+			requests: []request{
+				{method: http.MethodGet, path: "/v1/user/1", wantCode: http.StatusOK, wantLimit: "3", wantRemaining: "2", wantReset: "1"},
+				{method: http.MethodGet, path: "/v1/user/2", wantCode: http.StatusOK, wantLimit: "3", wantRemaining: "1", wantReset: "2"},
+				{method: http.MethodGet, path: "/v1/user/3", wantCode: http.StatusOK, wantLimit: "3", wantRemaining: "0", wantReset: "3"},
+				{method: http.MethodGet, path: "/v1/user/1", wantCode: http.StatusTooManyRequests, wantLimit: "3", wantRemaining: "0", wantReset: "3", wantRetry: "1"},
+				{method: http.MethodGet, path: "/v1/user/1", after: 500 * time.Millisecond, wantCode: http.StatusTooManyRequests, wantLimit: "3", wantRemaining: "0", wantReset: "3", wantRetry: "1"},
+				{method: http.MethodGet, path: "/v1/user/1", after: time.Second, wantCode: http.StatusOK, wantLimit: "3", wantRemaining: "0", wantReset: "3"},
+			},
+		},
+		{
+			name: "route rule",
+			requests: []request{
+				{method: http.MethodPost, path: "/v1/users/search", wantCode: http.StatusOK, wantLimit: "1", wantRemaining: "0", wantReset: "10"},
+				{method: http.MethodPost, path: "/v1/users/search", after: 4 * time.Second, wantCode: http.StatusTooManyRequests, wantLimit: "1", wantRemaining: "0", wantReset: "6", wantRetry: "6"},
+				// Other routes have their own buckets
+				{method: http.MethodGet, path: "/v1/user/1", wantCode: http.StatusOK, wantLimit: "3", wantRemaining: "2", wantReset: "1"},
+				{method: http.MethodPost, path: "/v1/users/search", after: 6 * time.Second, wantCode: http.StatusOK, wantLimit: "1", wantRemaining: "0", wantReset: "10"},
+			},
+		},
+		{
+			name: "unlimited route",
+			requests: []request{
+				{method: http.MethodGet, path: "/health", wantCode: http.StatusOK},
+				{method: http.MethodGet, path: "/health", wantCode: http.StatusOK},
+				{method: http.MethodGet, path: "/health", wantCode: http.StatusOK},
+				{method: http.MethodGet, path: "/health", wantCode: http.StatusOK},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := start
+			ratelimit.ExportSetTimeNow(now)
+
+			r := newRouter(t, cfg, ratelimit.NewMemoryBackend(0))
+
+			for i, req := range tt.requests {
+				now = now.Add(req.after)
+				ratelimit.ExportSetTimeNow(now)
+
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, httptest.NewRequest(req.method, req.path, nil))
+
+				assert.Equal(t, req.wantCode, w.Code, "request %d", i)
+				assert.Equal(t, req.wantLimit, w.Header().Get("RateLimit-Limit"), "request %d", i)
+				assert.Equal(t, req.wantRemaining, w.Header().Get("RateLimit-Remaining"), "request %d", i)
+				assert.Equal(t, req.wantReset, w.Header().Get("RateLimit-Reset"), "request %d", i)
+				assert.Equal(t, req.wantRetry, w.Header().Get("Retry-After"), "request %d", i)
+
+				if req.wantCode == http.StatusTooManyRequests {
+					assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+
+					var p problem.Problem
+					require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+					assert.Equal(t, http.StatusTooManyRequests, p.Status)
+					assert.Equal(t, errors.ErrRateLimited.Error(), p.Detail)
+				}
+			}
+		})
+	}
+}
+
+func TestLimiter_Middleware_Policy(t *testing.T) {
+	r := newRouter(t, ratelimit.Config{
+		Enabled: true,
+		Default: ratelimit.Rule{Requests: 100, Period: time.Minute, Burst: 20},
+	}, ratelimit.NewMemoryBackend(0))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/user/1", nil))
+
+	assert.Equal(t, "100;w=60;burst=20", w.Header().Get("RateLimit-Policy"))
+}
+
+func TestLimiter_Middleware_ClientKey(t *testing.T) {
+	const route = "GET /v1/user/{id}|"
+
+	tests := []struct {
+		name       string
+		proxies    []string
+		remoteAddr string
+		headers    map[string][]string
+		principal  string
+		wantKey    string
+	}{
+		{
+			name:       "client ip",
+			remoteAddr: "203.0.113.10:51234",
+			wantKey:    route + "ip:203.0.113.10",
+		},
+		{
+			name:       "forwarded for ignored from untrusted client",
+			remoteAddr: "203.0.113.10:51234",
+			headers:    map[string][]string{"X-Forwarded-For": {"198.51.100.1"}},
+			wantKey:    route + "ip:203.0.113.10",
+		},
+		{
+			name:       "forwarded for from trusted proxy",
+			proxies:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.5:51234",
+			headers:    map[string][]string{"X-Forwarded-For": {"198.51.100.1"}},
+			wantKey:    route + "ip:198.51.100.1",
+		},
+		{
+			name:       "spoofed forwarded for skipped",
+			proxies:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.5:51234",
+			headers:    map[string][]string{"X-Forwarded-For": {"1.2.3.4, 198.51.100.1"}},
+			wantKey:    route + "ip:198.51.100.1",
+		},
+		{
+			name:       "chain of trusted proxies",
+			proxies:    []string{"10.0.0.0/8", "192.0.2.7"},
+			remoteAddr: "10.0.0.5:51234",
+			headers:    map[string][]string{"X-Forwarded-For": {"1.2.3.4, 198.51.100.1", "192.0.2.7, 10.1.1.1"}},
+			wantKey:    route + "ip:198.51.100.1",
+		},
+		{
+			name:       "only trusted proxies forwarded",
+			proxies:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.5:51234",
+			headers:    map[string][]string{"X-Forwarded-For": {"10.0.0.9"}},
+			wantKey:    route + "ip:10.0.0.9",
+		},
+		{
+			name:       "malformed forwarded for",
+			proxies:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.5:51234",
+			headers:    map[string][]string{"X-Forwarded-For": {"198.51.100.1, unknown"}},
+			wantKey:    route + "ip:10.0.0.5",
+		},
+		{
+			name:       "ipv6 client",
+			proxies:    []string{"::1"},
+			remoteAddr: "[::1]:51234",
+			headers:    map[string][]string{"X-Forwarded-For": {"2001:db8::1"}},
+			wantKey:    route + "ip:2001:db8::/64",
+		},
+		{
+			name:       "ipv6 clients share their /64",
+			remoteAddr: "[2001:db8:0:1:aaaa::1]:51234",
+			wantKey:    route + "ip:2001:db8:0:1::/64",
+		},
+		{
+			name:       "unverified api key ignored",
+			remoteAddr: "203.0.113.10:51234",
+			headers:    map[string][]string{"X-API-Key": {"secret"}},
+			wantKey:    route + "ip:203.0.113.10",
+		},
+		{
+			name:       "principal",
+			remoteAddr: "203.0.113.10:51234",
+			headers:    map[string][]string{"X-API-Key": {"secret"}},
+			principal:  "user-1",
+			wantKey:    route + "principal:user-1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			b := mocks.NewMockBackend(ctrl)
+
+			r := newRouter(t, ratelimit.Config{
+				Enabled:        true,
+				TrustedProxies: tt.proxies,
+				Default:        ratelimit.Rule{Requests: 10, Period: time.Second},
+			}, b)
+
+			b.EXPECT().Take(gomock.Any(), tt.wantKey, gomock.Any()).Return(9.0, true, nil).Times(1)
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/user/1", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for k, values := range tt.headers {
+				for _, v := range values {
+					req.Header.Add(k, v)
+				}
+			}
+			if tt.principal != "" {
+				req = req.WithContext(ratelimit.WithPrincipal(req.Context(), tt.principal))
+			}
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+		})
+	}
+}
+
+func TestLimiter_Middleware_BackendError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	b := mocks.NewMockBackend(ctrl)
+
+	r := newRouter(t, ratelimit.Config{
+		Enabled: true,
+		Default: ratelimit.Rule{Requests: 10, Period: time.Second},
+	}, b)
+
+	b.EXPECT().Take(gomock.Any(), gomock.Any(), gomock.Any()).Return(0.0, false, errors.New("connection refused")).Times(1)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/user/1", nil).WithContext(context.Background()))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+}
+
+func TestMemoryBackend_Take_MaxBuckets(t *testing.T) {
+	ratelimit.ExportSetTimeNow(time.Date(2022, 4, 1, 12, 0, 0, 0, time.UTC))
+
+	b := ratelimit.NewMemoryBackend(3)
+	ctx := context.Background()
+	rule := ratelimit.Rule{Requests: 1, Period: time.Hour, Burst: 1}
+
+	// An attacker using many addresses fills the backend with drained buckets
+	for i := 0; i < 10; i++ {
+		key := fmt.Sprintf("ip:198.51.100.%d", i)
+		for j := 0; j < 2; j++ {
+			_, _, err := b.Take(ctx, key, rule)
+			require.NoError(t, err)
+		}
+	}
+
+	// New clients still get their own bucket
+	_, allowed, err := b.Take(ctx, "ip:203.0.113.10", rule)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	_, allowed, err = b.Take(ctx, "ip:203.0.113.11", rule)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}
+
+func TestMemoryBackend_Take_EvictsLeastRecentlyUsed(t *testing.T) {
+	ratelimit.ExportSetTimeNow(time.Date(2022, 4, 1, 12, 0, 0, 0, time.UTC))
+
+	b := ratelimit.NewMemoryBackend(2)
+	ctx := context.Background()
+	rule := ratelimit.Rule{Requests: 1, Period: time.Hour, Burst: 1}
+
+	take := func(key string) bool {
+		_, allowed, err := b.Take(ctx, key, rule)
+		require.NoError(t, err)
+		return allowed
+	}
+
+	assert.True(t, take("ip:203.0.113.10"))
+	assert.True(t, take("ip:203.0.113.11"))
+
+	// Using the first bucket again makes the second the least recently used, so it is evicted for the third
+	assert.False(t, take("ip:203.0.113.10"))
+	assert.True(t, take("ip:203.0.113.12"))
+
+	assert.False(t, take("ip:203.0.113.10"))
+	assert.True(t, take("ip:203.0.113.11"))
+}
+
+func TestNew_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  ratelimit.Config
+	}{
+		{
+			name: "invalid trusted proxy",
+			cfg:  ratelimit.Config{TrustedProxies: []string{"proxy.internal"}},
+		},
+		{
+			name: "default without period",
+			cfg:  ratelimit.Config{Default: ratelimit.Rule{Requests: 10}},
+		},
+		{
+			name: "route without period",
+			cfg:  ratelimit.Config{Routes: map[string]ratelimit.Rule{"GET /health": {Requests: 10}}},
+		},
+		{
+			name: "unknown backend",
+			cfg:  ratelimit.Config{Backend: "redis"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ratelimit.New(tt.cfg, ratelimit.NewMemoryBackend(0))
+			assert.ErrorIs(t, err, ratelimit.ErrInvalidConfig)
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	"github.com/speakeasy-api/rest-template-go/internal/core/logging"
+	"github.com/speakeasy-api/rest-template-go/internal/core/ratelimit"
+	"go.uber.org/zap"
+)
+
+const pruneInterval = time.Minute
+
+// takeQuery refills and takes a token from a bucket in a single statement so concurrent requests from every
+// instance see a consistent bucket. Times come from the database so instances don't need synchronized clocks.
+const takeQuery = `
+INSERT INTO rate_limits AS b (key, tokens, allowed, updated_at, full_at)
+VALUES ($1, $2::float8 - 1, true, now(), now() + make_interval(secs => $2::float8 / $3::float8))
+ON CONFLICT (key) DO UPDATE SET
+	tokens = LEAST($2::float8, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at)::float8 * $3::float8)
+		- CASE WHEN LEAST($2::float8, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at)::float8 * $3::float8) >= 1 THEN 1 ELSE 0 END,
+	allowed = LEAST($2::float8, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at)::float8 * $3::float8) >= 1,
+	updated_at = now(),
+	full_at = now() + make_interval(secs => $2::float8 / $3::float8)
+RETURNING tokens, allowed`
+
+// DB represents a type for interfacing with a postgres database.
+type DB interface {
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// Store keeps token buckets in a postgres database so limits are shared by every instance of the service.
+type Store struct {
+	db         DB
+	maxBuckets int
+
+	stop context.CancelFunc
+	wg   sync.WaitGroup
+}
+
+var _ ratelimit.Backend = (*Store)(nil)
+
+// New will instantiate a new instance of Store keeping up to maxBuckets buckets, defaulting to
+// ratelimit.DefaultMaxBuckets if it isn't positive.
+func New(db DB, maxBuckets int) *Store {
+	if maxBuckets <= 0 {
+		maxBuckets = ratelimit.DefaultMaxBuckets
+	}
+
+	return &Store{
+		db:         db,
+		maxBuckets: maxBuckets,
+	}
+}
+
+// Take will refill the bucket at the rate of the rule then remove a token from it if it has one.
+func (s *Store) Take(ctx context.Context, key string, rule ratelimit.Rule) (float64, bool, error) {
+	var b struct {
+		Tokens  float64 `db:"tokens"`
+		Allowed bool    `db:"allowed"`
+	}
+
+	if err := s.db.GetContext(ctx, &b, takeQuery, key, rule.Capacity(), rule.Rate()); err != nil {
+		return 0, false, errors.ErrUnknown.Wrap(err)
+	}
+
+	return b.Tokens, b.Allowed, nil
+}
+
+// Start will start pruning buckets every minute in the background, so requests never wait for it.
+func (s *Store) Start(ctx context.Context) {
+	ctx, s.stop = context.WithCancel(ctx)
+
+	s.wg.Add(1)
+
+	go func() {
+		defer s.wg.Done()
+
+		t := time.NewTicker(pruneInterval)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+
+			s.prune(ctx)
+		}
+	}()
+}
+
+// Close will stop pruning buckets and wait for any prune in progress to finish.
+func (s *Store) Close() {
+	if s.stop != nil {
+		s.stop()
+	}
+	s.wg.Wait()
+}
+
+// prune will delete buckets that have refilled as they are the same as a new bucket, then the least recently used
+// buckets beyond the maximum so clients using many addresses can't grow the table without bound.
+func (s *Store) prune(ctx context.Context) {
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM rate_limits WHERE full_at < now()"); err != nil {
+		logging.From(ctx).Error("failed to prune rate limits", zap.Error(err))
+		return
+	}
+
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM rate_limits WHERE key IN (SELECT key FROM rate_limits ORDER BY updated_at DESC OFFSET $1)", s.maxBuckets); err != nil {
+		logging.From(ctx).Error("failed to trim rate limits", zap.Error(err))
+	}
+}
@@ -0,0 +1,8 @@
+package store
+
+import "context"
+
+// ExportPrune prunes the buckets immediately rather than waiting for the background ticker.
+func (s *Store) ExportPrune(ctx context.Context) {
+	s.prune(ctx)
+}
@@ -0,0 +1,75 @@
+// Package requestid identifies requests with an ID clients can quote to support, correlating the logs, traces and
+// events of the request.
+package requestid
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/speakeasy-api/rest-template-go/internal/core/logging"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// Header is the header the request ID is accepted from and returned in.
+const Header = "X-Request-ID"
+
+// AttributeKey is the span attribute the request ID is recorded in.
+const AttributeKey = attribute.Key("http.request_id")
+
+const maxLength = 128
+
+type contextKey struct{}
+
+// With returns a new context identifying the request with the ID.
+func With(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// From returns the ID of the request the context belongs to, or an empty string if it doesn't belong to one.
+func From(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// Middleware will identify each request with the ID in its X-Request-ID header, generating one if it doesn't have a
+// valid one, and return it in the X-Request-ID header of the response. The ID is added to the context, logger and
+// span of the request.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		id := r.Header.Get(Header)
+		if !valid(id) {
+			id = uuid.NewString()
+		}
+
+		ctx = With(ctx, id)
+		ctx = logging.WithFields(ctx, zap.String("request_id", id))
+
+		trace.SpanFromContext(ctx).SetAttributes(AttributeKey.String(id))
+
+		// Set before calling the handler so it is included however the response is written
+		w.Header().Set(Header, id)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// valid returns whether the ID supplied by the client can be used, IDs are limited to printable ASCII without
+// spaces so they can't be used to inject content into the logs or response headers.
+func valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,86 @@
+package requestid_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/speakeasy-api/rest-template-go/internal/core/requestid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name      string
+		header    string
+		wantID    string
+		generated bool
+	}{
+		{
+			name:   "client id",
+			header: "support-ticket-1234",
+			wantID: "support-ticket-1234",
+		},
+		{
+			name:      "missing id",
+			generated: true,
+		},
+		{
+			name:      "id with spaces",
+			header:    "some id",
+			generated: true,
+		},
+		{
+			name:      "id with control characters",
+			header:    "some-id\x1b[31m",
+			generated: true,
+		},
+		{
+			name:      "id too long",
+			header:    strings.Repeat("a", 129),
+			generated: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+			var gotID string
+
+			h := requestid.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotID = requestid.From(r.Context())
+				w.WriteHeader(http.StatusNoContent)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/user/1", nil)
+			if tt.header != "" {
+				req.Header.Set(requestid.Header, tt.header)
+			}
+
+			ctx, span := tp.Tracer("test").Start(req.Context(), "request")
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req.WithContext(ctx))
+			span.End()
+
+			if tt.generated {
+				_, err := uuid.Parse(gotID)
+				require.NoError(t, err)
+			} else {
+				assert.Equal(t, tt.wantID, gotID)
+			}
+
+			assert.Equal(t, gotID, w.Header().Get(requestid.Header))
+
+			spans := recorder.Ended()
+			require.Len(t, spans, 1)
+			assert.Contains(t, spans[0].Attributes(), requestid.AttributeKey.String(gotID))
+		})
+	}
+}
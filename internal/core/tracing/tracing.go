@@ -2,57 +2,195 @@ package tracing
 
 import (
 	"context"
-	"io"
+	"os"
+	"runtime/debug"
+	"time"
 
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
 	"github.com/speakeasy-api/rest-template-go/internal/core/logging"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
 	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
 	"go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.uber.org/zap"
+)
+
+const (
+	// ErrUnknownExporter is returned when tracing is configured with an exporter that isn't supported.
+	ErrUnknownExporter = errors.Error("unknown trace exporter")
+	// ErrSampleRatio is returned when the sample ratio isn't between 0 and 1.
+	ErrSampleRatio = errors.Error("trace sample ratio must be between 0 and 1")
+	// ErrExporter is returned when the trace exporter can't be created.
+	ErrExporter = errors.Error("failed to create trace exporter")
+	// ErrResource is returned when the resource describing the service can't be created.
+	ErrResource = errors.Error("failed to create trace resource")
+)
+
+const shutdownTimeout = 5 * time.Second
+
+const (
+	// ExporterNone doesn't export spans, they are still created so trace context is propagated.
+	ExporterNone = "none"
+	// ExporterStdout writes spans to stdout.
+	ExporterStdout = "stdout"
+	// ExporterOTLPGRPC exports spans to an OTLP receiver over gRPC.
+	ExporterOTLPGRPC = "otlpgrpc"
+	// ExporterOTLPHTTP exports spans to an OTLP receiver over HTTP.
+	ExporterOTLPHTTP = "otlphttp"
 )
 
 var tp *trace.TracerProvider
 
+// Config represents the configuration of exporting and sampling traces.
+type Config struct {
+	// Exporter is one of none, stdout, otlpgrpc or otlphttp, defaults to none.
+	Exporter string `yaml:"exporter"`
+	// Endpoint is the host and port of the OTLP receiver, defaults to localhost:4317 for gRPC and localhost:4318 for
+	// HTTP.
+	Endpoint string `yaml:"endpoint"`
+	// Insecure disables TLS when connecting to the OTLP receiver.
+	Insecure bool `yaml:"insecure"`
+	// Headers are sent with each export to the OTLP receiver, such as the API key of a tracing vendor.
+	Headers map[string]string `yaml:"headers"`
+	// SampleRatio is the ratio of traces started by this service that are sampled, traces started by callers follow
+	// their sampling decision. Defaults to sampling every trace.
+	SampleRatio *float64 `yaml:"sampleRatio"`
+	// ServiceName defaults to the name of the app.
+	ServiceName string `yaml:"serviceName"`
+	// ServiceVersion defaults to the version of the main module the binary was built from.
+	ServiceVersion string `yaml:"serviceVersion"`
+	// Environment is the deployment environment such as dev or prod.
+	Environment string `yaml:"environment" env:"SPEAKEASY_ENVIRONMENT"`
+}
+
 // OnShutdowner is an interface that allows a caller to register a function to be called when the application is shutting down.
 type OnShutdowner interface {
 	OnShutdown(onShutdown func())
 }
 
-// EnableTracing enables tracing.
-func EnableTracing(ctx context.Context, appName string, s OnShutdowner) error {
-	exp, err := stdouttrace.New(
-		stdouttrace.WithWriter(io.Discard),
-		stdouttrace.WithPrettyPrint(),
-		stdouttrace.WithoutTimestamps(),
-	)
+// EnableTracing enables tracing, exporting the sampled spans of the app with the configured exporter.
+func EnableTracing(ctx context.Context, appName string, cfg Config, s OnShutdowner) error {
+	ratio := 1.0
+	if cfg.SampleRatio != nil {
+		ratio = *cfg.SampleRatio
+	}
+	if ratio < 0 || ratio > 1 {
+		return ErrSampleRatio
+	}
+
+	res, err := newResource(appName, cfg)
 	if err != nil {
 		return err
 	}
 
-	tp = trace.NewTracerProvider(
-		trace.WithBatcher(exp),
-		trace.WithResource(newResource(appName)),
-	)
+	opts := []trace.TracerProviderOption{
+		trace.WithSampler(trace.ParentBased(trace.TraceIDRatioBased(ratio))),
+		trace.WithResource(res),
+	}
+
+	exp, err := newExporter(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	if exp != nil {
+		opts = append(opts, trace.WithBatcher(exp))
+	}
+
+	tp = trace.NewTracerProvider(opts...)
 	s.OnShutdown(func() {
 		logging.From(ctx).Info("shutting down tracing provider")
 
-		if err := tp.Shutdown(ctx); err != nil {
-			logging.From(ctx).Error("failed to shutdown tracing provider")
+		// Flushes the spans waiting to be exported, bounded so an unreachable receiver doesn't block shutdown
+		shutdownCtx, cancel := context.WithTimeout(ctx, shutdownTimeout)
+		defer cancel()
+
+		if err := tp.Shutdown(shutdownCtx); err != nil {
+			logging.From(ctx).Error("failed to shutdown tracing provider", zap.Error(err))
 		}
 	})
 	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(Propagator())
 
 	return nil
 }
 
-func newResource(appName string) *resource.Resource {
-	r, _ := resource.Merge(
-		resource.Default(),
-		resource.NewWithAttributes(
-			semconv.SchemaURL,
-			semconv.ServiceNameKey.String(appName),
-		),
+// Propagator returns the propagator used to carry W3C trace context and baggage across service boundaries
+// such as incoming requests and produced events.
+func Propagator() propagation.TextMapPropagator {
+	return propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+}
+
+func newExporter(ctx context.Context, cfg Config) (trace.SpanExporter, error) {
+	var (
+		exp trace.SpanExporter
+		err error
 	)
-	return r
+
+	switch cfg.Exporter {
+	case "", ExporterNone:
+		return nil, nil
+	case ExporterStdout:
+		exp, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterOTLPGRPC:
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithHeaders(cfg.Headers)}
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		exp, err = otlptrace.New(ctx, otlptracegrpc.NewClient(opts...))
+	case ExporterOTLPHTTP:
+		opts := []otlptracehttp.Option{otlptracehttp.WithHeaders(cfg.Headers)}
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		exp, err = otlptrace.New(ctx, otlptracehttp.NewClient(opts...))
+	default:
+		return nil, ErrUnknownExporter.Wrap(errors.New(cfg.Exporter))
+	}
+	if err != nil {
+		return nil, ErrExporter.Wrap(err)
+	}
+
+	return exp, nil
+}
+
+func newResource(appName string, cfg Config) (*resource.Resource, error) {
+	name := cfg.ServiceName
+	if name == "" {
+		name = appName
+	}
+
+	version := cfg.ServiceVersion
+	if version == "" {
+		if info, ok := debug.ReadBuildInfo(); ok {
+			version = info.Main.Version
+		}
+	}
+
+	attrs := []attribute.KeyValue{semconv.ServiceNameKey.String(name)}
+	if version != "" {
+		attrs = append(attrs, semconv.ServiceVersionKey.String(version))
+	}
+	if cfg.Environment != "" {
+		attrs = append(attrs, semconv.DeploymentEnvironmentKey.String(cfg.Environment))
+	}
+
+	// Schemaless as the default resource may use a different version of the semantic conventions, which can't be merged
+	r, err := resource.Merge(resource.Default(), resource.NewSchemaless(attrs...))
+	if err != nil {
+		return nil, ErrResource.Wrap(err)
+	}
+
+	return r, nil
 }
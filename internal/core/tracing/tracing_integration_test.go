@@ -0,0 +1,250 @@
+package tracing_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/speakeasy-api/rest-template-go/internal/core/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+	collectortrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// receiver is an in-process OTLP receiver recording the exports it receives.
+type receiver struct {
+	collectortrace.UnimplementedTraceServiceServer
+
+	mu       sync.Mutex
+	requests []*collectortrace.ExportTraceServiceRequest
+	apiKeys  []string
+}
+
+func (rc *receiver) Export(ctx context.Context, req *collectortrace.ExportTraceServiceRequest) (*collectortrace.ExportTraceServiceResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	rc.record(req, strings.Join(md.Get("x-api-key"), ","))
+
+	return &collectortrace.ExportTraceServiceResponse{}, nil
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	req := &collectortrace.ExportTraceServiceRequest{}
+	if err := proto.Unmarshal(data, req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	rc.record(req, r.Header.Get("X-Api-Key"))
+
+	res, _ := proto.Marshal(&collectortrace.ExportTraceServiceResponse{})
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	_, _ = w.Write(res)
+}
+
+func (rc *receiver) record(req *collectortrace.ExportTraceServiceRequest, apiKey string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	rc.requests = append(rc.requests, req)
+	rc.apiKeys = append(rc.apiKeys, apiKey)
+}
+
+// spans returns the names of the exported spans and the resource attributes they were exported with.
+func (rc *receiver) spans() ([]string, map[string]string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	names := []string{}
+	attrs := map[string]string{}
+
+	for _, req := range rc.requests {
+		for _, rs := range req.GetResourceSpans() {
+			for _, a := range rs.GetResource().GetAttributes() {
+				attrs[a.GetKey()] = a.GetValue().GetStringValue()
+			}
+			for _, ils := range rs.GetInstrumentationLibrarySpans() {
+				for _, s := range ils.GetSpans() {
+					names = append(names, s.GetName())
+				}
+			}
+		}
+	}
+
+	return names, attrs
+}
+
+func startGRPCReceiver(t *testing.T, rc *receiver) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := grpc.NewServer()
+	collectortrace.RegisterTraceServiceServer(s, rc)
+	go func() {
+		_ = s.Serve(lis)
+	}()
+	t.Cleanup(s.Stop)
+
+	return lis.Addr().String()
+}
+
+func startHTTPReceiver(t *testing.T, rc *receiver) string {
+	t.Helper()
+
+	s := httptest.NewServer(rc)
+	t.Cleanup(s.Close)
+
+	return strings.TrimPrefix(s.URL, "http://")
+}
+
+type shutdowner struct {
+	funcs []func()
+}
+
+func (s *shutdowner) OnShutdown(f func()) {
+	s.funcs = append(s.funcs, f)
+}
+
+func (s *shutdowner) shutdown() {
+	for _, f := range s.funcs {
+		f()
+	}
+}
+
+func ratio(r float64) *float64 {
+	return &r
+}
+
+func TestEnableTracing_OTLP_Success(t *testing.T) {
+	tests := []struct {
+		name     string
+		exporter string
+		start    func(t *testing.T, rc *receiver) string
+	}{
+		{
+			name:     "grpc",
+			exporter: tracing.ExporterOTLPGRPC,
+			start:    startGRPCReceiver,
+		},
+		{
+			name:     "http",
+			exporter: tracing.ExporterOTLPHTTP,
+			start:    startHTTPReceiver,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			rc := &receiver{}
+			s := &shutdowner{}
+
+			err := tracing.EnableTracing(ctx, "test-app", tracing.Config{
+				Exporter:       tt.exporter,
+				Endpoint:       tt.start(t, rc),
+				Insecure:       true,
+				Headers:        map[string]string{"X-Api-Key": "some-api-key"},
+				ServiceName:    "users",
+				ServiceVersion: "v1.2.3",
+				Environment:    "test",
+			}, s)
+			require.NoError(t, err)
+
+			_, span := otel.Tracer("test").Start(ctx, "get user")
+			span.End()
+
+			// Flushes the batched span to the receiver
+			s.shutdown()
+
+			names, attrs := rc.spans()
+			assert.Equal(t, []string{"get user"}, names)
+			assert.Equal(t, "users", attrs["service.name"])
+			assert.Equal(t, "v1.2.3", attrs["service.version"])
+			assert.Equal(t, "test", attrs["deployment.environment"])
+			assert.Equal(t, []string{"some-api-key"}, rc.apiKeys)
+		})
+	}
+}
+
+func TestEnableTracing_Sampling(t *testing.T) {
+	ctx := context.Background()
+
+	rc := &receiver{}
+	s := &shutdowner{}
+
+	err := tracing.EnableTracing(ctx, "test-app", tracing.Config{
+		Exporter:    tracing.ExporterOTLPGRPC,
+		Endpoint:    startGRPCReceiver(t, rc),
+		Insecure:    true,
+		SampleRatio: ratio(0),
+	}, s)
+	require.NoError(t, err)
+
+	_, unsampled := otel.Tracer("test").Start(ctx, "started here")
+	unsampled.End()
+
+	// Traces started by callers that sampled them are sampled regardless of the ratio
+	parent := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{1},
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	})
+	_, sampled := otel.Tracer("test").Start(trace.ContextWithRemoteSpanContext(ctx, parent), "started by caller")
+	sampled.End()
+
+	s.shutdown()
+
+	assert.False(t, unsampled.SpanContext().IsSampled())
+	assert.True(t, sampled.SpanContext().IsSampled())
+
+	names, attrs := rc.spans()
+	assert.Equal(t, []string{"started by caller"}, names)
+	assert.Equal(t, "test-app", attrs["service.name"])
+}
+
+func TestEnableTracing_Error(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     tracing.Config
+		wantErr error
+	}{
+		{
+			name:    "unknown exporter",
+			cfg:     tracing.Config{Exporter: "zipkin"},
+			wantErr: tracing.ErrUnknownExporter,
+		},
+		{
+			name:    "sample ratio above 1",
+			cfg:     tracing.Config{SampleRatio: ratio(1.5)},
+			wantErr: tracing.ErrSampleRatio,
+		},
+		{
+			name:    "negative sample ratio",
+			cfg:     tracing.Config{SampleRatio: ratio(-0.1)},
+			wantErr: tracing.ErrSampleRatio,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tracing.EnableTracing(context.Background(), "test-app", tt.cfg, &shutdowner{})
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,375 @@
+//go:generate mockgen -destination=./mocks/consumer_mock.go -package mocks github.com/speakeasy-api/rest-template-go/internal/events/consumer Source,ProcessedStore
+
+// Package consumer provides a framework for consuming events from a broker, delivering each event
+// once to the handlers subscribed to its topic.
+package consumer
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/google/uuid"
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	"github.com/speakeasy-api/rest-template-go/internal/core/logging"
+	"github.com/speakeasy-api/rest-template-go/internal/core/requestid"
+	"github.com/speakeasy-api/rest-template-go/internal/events"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	// ErrSubscribe is returned when the consumer can't subscribe to a topic.
+	ErrSubscribe = errors.Error("subscribe_failed: failed to subscribe to topic")
+	// ErrHandle is returned when an event couldn't be handled.
+	ErrHandle = errors.Error("handle_failed: failed to handle event")
+	// ErrClaim is returned when an event couldn't be claimed for processing.
+	ErrClaim = errors.Error("claim_failed: failed to claim event")
+)
+
+// HeaderDeadLetterConsumer is set on dead lettered events to the name of the consumer that failed to handle them.
+const HeaderDeadLetterConsumer = "dead_letter_consumer"
+
+const (
+	defaultConcurrency  = 10
+	defaultMaxAttempts  = 3
+	defaultClaimTimeout = 5 * time.Minute
+)
+
+const tracerName = "github.com/speakeasy-api/rest-template-go/internal/events/consumer"
+
+var newBackOff = func() backoff.BackOff {
+	b := backoff.NewExponentialBackOff()
+	b.InitialInterval = 100 * time.Millisecond
+	b.MaxInterval = time.Second
+	return b
+}
+
+// Config represents the configuration of a consumer.
+type Config struct {
+	// Name identifies the consumer, instances sharing a name handle each event once between them. An instance claims
+	// an event before handling it, so another instance only handles it again if the claim expires or handling fails.
+	Name string `yaml:"name"`
+	// Concurrency is the number of events handled at once, events with the same key are always handled in order.
+	Concurrency int `yaml:"concurrency"`
+	// MaxAttempts is the number of times handling an event is attempted before it is dead lettered.
+	MaxAttempts int `yaml:"maxAttempts"`
+	// ClaimTimeout is how long an instance has to handle an event before others can claim it, it should exceed the
+	// time taken by every attempt so events are only handled again if the instance crashed. Defaults to 5m.
+	ClaimTimeout time.Duration `yaml:"claimTimeout"`
+	// Sink is the name of the events sink the source receives events from. Events that can't be handled are dead
+	// lettered against it, so redriving them delivers them to the consumer again.
+	Sink string `yaml:"sink"`
+}
+
+// Source represents a broker events can be consumed from such as a Kafka consumer group.
+type Source interface {
+	// Subscribe returns a channel of envelopes encoded with events.EncodeEnvelope that is closed when ctx is done.
+	Subscribe(ctx context.Context, topic events.Topic) (<-chan []byte, error)
+}
+
+// ProcessedStore represents a type for recording the events a consumer is handling and has processed.
+type ProcessedStore interface {
+	// ClaimProcessing atomically claims the event for the consumer until ttl has passed, returning false if it has
+	// already been processed or is claimed by another instance.
+	ClaimProcessing(ctx context.Context, consumer, eventID string, ttl time.Duration) (bool, error)
+	MarkProcessed(ctx context.Context, consumer, eventID string) error
+	ReleaseProcessing(ctx context.Context, consumer, eventID string) error
+}
+
+// Handler handles an event consumed from a topic, returning an error will cause the event to be retried
+// unless it is wrapped with Permanent.
+type Handler func(ctx context.Context, msg events.Message) error
+
+// UserEventHandler adapts a function handling user events to a Handler.
+func UserEventHandler(h func(ctx context.Context, e events.UserEvent) error) Handler {
+	return func(ctx context.Context, msg events.Message) error {
+		e, err := events.DecodeUserEvent(msg)
+		if err != nil {
+			return Permanent(err)
+		}
+
+		return h(ctx, e)
+	}
+}
+
+// Permanent wraps an error returned by a handler to dead letter the event immediately instead of retrying it.
+func Permanent(err error) error {
+	return backoff.Permanent(err)
+}
+
+type delivery struct {
+	topic events.Topic
+	msg   events.Message
+}
+
+// Consumer consumes events from a source and delivers them to the handlers subscribed to their topic.
+type Consumer struct {
+	name         string
+	concurrency  int
+	maxAttempts  int
+	claimTimeout time.Duration
+	sink         string
+	source       Source
+	processed    ProcessedStore
+	deadLetters  events.DeadLetterStore
+	handlers     map[events.Topic][]Handler
+}
+
+// New will instantiate a new instance of Consumer. Events that can't be handled are stored in the dead letter
+// store alongside those that couldn't be published, so they can be inspected and redriven the same way.
+func New(cfg Config, s Source, ps ProcessedStore, dl events.DeadLetterStore) *Consumer {
+	c := &Consumer{
+		name:         cfg.Name,
+		concurrency:  cfg.Concurrency,
+		maxAttempts:  cfg.MaxAttempts,
+		claimTimeout: cfg.ClaimTimeout,
+		sink:         cfg.Sink,
+		source:       s,
+		processed:    ps,
+		deadLetters:  dl,
+		handlers:     map[events.Topic][]Handler{},
+	}
+	if c.concurrency <= 0 {
+		c.concurrency = defaultConcurrency
+	}
+	if c.maxAttempts <= 0 {
+		c.maxAttempts = defaultMaxAttempts
+	}
+	if c.claimTimeout <= 0 {
+		c.claimTimeout = defaultClaimTimeout
+	}
+
+	return c
+}
+
+// Handle will subscribe the handler to events on the topic, it must be called before Listen.
+func (c *Consumer) Handle(topic events.Topic, h Handler) {
+	c.handlers[topic] = append(c.handlers[topic], h)
+}
+
+// Listen will consume events from all subscribed topics until the context is done.
+func (c *Consumer) Listen(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(logging.WithFields(ctx, zap.String("consumer", c.name)))
+	defer cancel()
+
+	workers := make([]chan delivery, c.concurrency)
+
+	var workersWG sync.WaitGroup
+
+	for i := range workers {
+		workers[i] = make(chan delivery)
+		workersWG.Add(1)
+
+		go func(deliveries <-chan delivery) {
+			defer workersWG.Done()
+
+			for d := range deliveries {
+				c.process(ctx, d)
+			}
+		}(workers[i])
+	}
+
+	var subscriptionsWG sync.WaitGroup
+	var err error
+
+	for topic := range c.handlers {
+		envelopes, serr := c.source.Subscribe(ctx, topic)
+		if serr != nil {
+			err = ErrSubscribe.Wrap(serr)
+			cancel()
+			break
+		}
+
+		logging.From(ctx).Info("consuming events", zap.String("topic", string(topic)))
+
+		subscriptionsWG.Add(1)
+
+		go func(topic events.Topic, envelopes <-chan []byte) {
+			defer subscriptionsWG.Done()
+
+			for data := range envelopes {
+				c.dispatch(ctx, workers, topic, data)
+			}
+		}(topic, envelopes)
+	}
+
+	subscriptionsWG.Wait()
+
+	for _, w := range workers {
+		close(w)
+	}
+	workersWG.Wait()
+
+	return err
+}
+
+// dispatch will decode the envelope and hand it to the worker for its key so events with the same key are handled in order.
+func (c *Consumer) dispatch(ctx context.Context, workers []chan delivery, topic events.Topic, data []byte) {
+	msg, err := events.DecodeEnvelope(data)
+	if err != nil {
+		logging.From(ctx).Error("failed to decode event", zap.Error(err), zap.String("topic", string(topic)))
+
+		c.deadLetter(ctx, topic, events.Message{
+			ID:          uuid.NewString(),
+			Topic:       topic,
+			ContentType: "application/octet-stream",
+			Headers:     events.Headers{},
+			Payload:     data,
+			Timestamp:   time.Now().UTC(),
+		}, 0, err)
+
+		return
+	}
+
+	// Events without a key have no ordering requirements so can be spread across workers
+	key := msg.Key
+	if key == "" {
+		key = msg.ID
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+
+	select {
+	case workers[h.Sum32()%uint32(len(workers))] <- delivery{topic: topic, msg: msg}:
+	case <-ctx.Done():
+	}
+}
+
+// process will handle the event in a span continuing the trace of the request that produced it.
+func (c *Consumer) process(ctx context.Context, d delivery) {
+	// Only the trace context and baggage are taken from the producer, cancellation comes from the consumer
+	producerCtx := otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(d.msg.Headers))
+
+	ctx, span := otel.Tracer(tracerName).Start(producerCtx, string(d.topic)+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingDestinationKey.String(string(d.topic)),
+			semconv.MessagingDestinationKindTopic,
+			semconv.MessagingMessageIDKey.String(d.msg.ID),
+			semconv.MessagingOperationProcess,
+		),
+	)
+	defer span.End()
+
+	ctx = logging.WithFields(ctx,
+		zap.String("event_id", d.msg.ID),
+		zap.String("topic", string(d.topic)),
+		zap.String("trace_id", span.SpanContext().TraceID().String()),
+		zap.String("span_id", span.SpanContext().SpanID().String()),
+	)
+
+	// Correlates handling the event with the request that produced it
+	if requestID := d.msg.Headers[events.HeaderRequestID]; requestID != "" {
+		ctx = requestid.With(ctx, requestID)
+		ctx = logging.WithFields(ctx, zap.String("request_id", requestID))
+		span.SetAttributes(requestid.AttributeKey.String(requestID))
+	}
+
+	attempts := 0
+	claimed, skipped := false, false
+
+	err := backoff.Retry(func() error {
+		attempts++
+
+		if !claimed {
+			ok, err := c.processed.ClaimProcessing(ctx, c.name, d.msg.ID, c.claimTimeout)
+			if err != nil {
+				return ErrClaim.Wrap(err)
+			}
+			if !ok {
+				skipped = true
+				return nil
+			}
+			claimed = true
+		}
+
+		return c.handle(ctx, d)
+	}, backoff.WithContext(backoff.WithMaxRetries(newBackOff(), uint64(c.maxAttempts-1)), ctx))
+	if skipped {
+		logging.From(ctx).Debug("skipping event already processed or being handled by another instance")
+		return
+	}
+	if err == nil {
+		// Not retried as that would run the handlers again, the claim expires and the event is handled again if
+		// redelivered
+		if err := c.processed.MarkProcessed(ctx, c.name, d.msg.ID); err != nil {
+			logging.From(ctx).Error("failed to record event as processed", zap.Error(err))
+		}
+		return
+	}
+
+	// Events interrupted by shutting down will be redelivered once their claim expires so shouldn't be dead lettered
+	if ctx.Err() != nil {
+		return
+	}
+
+	// Released so a redelivery or re-drive of the event is handled rather than skipped
+	if claimed {
+		if err := c.processed.ReleaseProcessing(ctx, c.name, d.msg.ID); err != nil {
+			logging.From(ctx).Error("failed to release event", zap.Error(err))
+		}
+	}
+
+	logging.From(ctx).Error("failed to handle event", zap.Error(err), zap.Int("attempts", attempts))
+	span.RecordError(err)
+	span.SetStatus(codes.Error, "failed to handle event")
+
+	c.deadLetter(ctx, d.topic, d.msg, attempts, err)
+}
+
+// handle will run the handlers for the event. The event is only recorded as processed once every handler has
+// succeeded, so an event interrupted by a crash is handled again when redelivered and handlers must be idempotent.
+func (c *Consumer) handle(ctx context.Context, d delivery) error {
+	for _, h := range c.handlers[d.topic] {
+		if err := h(ctx, d.msg); err != nil {
+			var perr *backoff.PermanentError
+			if errors.As(err, &perr) {
+				return backoff.Permanent(ErrHandle.Wrap(perr.Err))
+			}
+
+			return ErrHandle.Wrap(err)
+		}
+	}
+
+	return nil
+}
+
+func (c *Consumer) deadLetter(ctx context.Context, topic events.Topic, msg events.Message, attempts int, cause error) {
+	if c.deadLetters == nil {
+		logging.From(ctx).Error("event dropped as no dead letter store is configured", zap.Error(cause))
+		return
+	}
+
+	headers := events.Headers{}
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	headers[HeaderDeadLetterConsumer] = c.name
+
+	now := time.Now().UTC()
+
+	if _, err := c.deadLetters.InsertDeadLetter(ctx, &events.DeadLetter{
+		EventID:       msg.ID,
+		Topic:         topic,
+		Sink:          c.sink,
+		Key:           msg.Key,
+		ContentType:   msg.ContentType,
+		Headers:       headers,
+		Payload:       msg.Payload,
+		Error:         cause.Error(),
+		Attempts:      attempts,
+		ProducedAt:    msg.Timestamp,
+		FirstFailedAt: now,
+		LastFailedAt:  now,
+	}); err != nil {
+		logging.From(ctx).Error("event dropped as it couldn't be dead lettered", zap.Error(err), zap.NamedError("cause", cause))
+	}
+}
@@ -0,0 +1,397 @@
+package consumer_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	"github.com/speakeasy-api/rest-template-go/internal/core/requestid"
+	"github.com/speakeasy-api/rest-template-go/internal/core/tracing"
+	"github.com/speakeasy-api/rest-template-go/internal/events"
+	"github.com/speakeasy-api/rest-template-go/internal/events/consumer"
+	"github.com/speakeasy-api/rest-template-go/internal/events/consumer/mocks"
+	eventsmocks "github.com/speakeasy-api/rest-template-go/internal/events/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type processedStore struct {
+	mu        sync.Mutex
+	claimed   map[string]bool
+	processed map[string]bool
+}
+
+func newProcessedStore() *processedStore {
+	return &processedStore{claimed: map[string]bool{}, processed: map[string]bool{}}
+}
+
+func (s *processedStore) ClaimProcessing(ctx context.Context, consumer, eventID string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.claimed[consumer+eventID] || s.processed[consumer+eventID] {
+		return false, nil
+	}
+	s.claimed[consumer+eventID] = true
+
+	return true, nil
+}
+
+func (s *processedStore) MarkProcessed(ctx context.Context, consumer, eventID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.claimed[consumer+eventID] = true
+	s.processed[consumer+eventID] = true
+
+	return nil
+}
+
+func (s *processedStore) ReleaseProcessing(ctx context.Context, consumer, eventID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.processed[consumer+eventID] {
+		delete(s.claimed, consumer+eventID)
+	}
+
+	return nil
+}
+
+func (s *processedStore) isProcessed(consumer, eventID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.processed[consumer+eventID]
+}
+
+func (s *processedStore) isClaimed(consumer, eventID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.claimed[consumer+eventID]
+}
+
+func contains(ids []string, id string) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+func envelope(t *testing.T, id, key string) []byte {
+	t.Helper()
+
+	data, err := events.EncodeEnvelope(events.Message{
+		ID:          id,
+		Topic:       events.TopicUsers,
+		Key:         key,
+		ContentType: "application/json",
+		Payload:     []byte(`{"event_type":"user_updated","id":"` + key + `","changed_fields":[]}`),
+	})
+	require.NoError(t, err)
+
+	return data
+}
+
+func TestConsumer_Listen(t *testing.T) {
+	consumer.ExportDisableBackOff()
+
+	type received struct {
+		id  string
+		key string
+	}
+
+	tests := []struct {
+		name            string
+		envelopes       [][]byte
+		processed       []string
+		failures        map[string]error
+		wantReceived    []received
+		wantDeadLetters map[string]int
+		wantProcessed   []string
+	}{
+		{
+			name: "handles events in order per key",
+			envelopes: [][]byte{
+				envelope(t, "1", "user-a"),
+				envelope(t, "2", "user-b"),
+				envelope(t, "3", "user-a"),
+				envelope(t, "4", "user-b"),
+				envelope(t, "5", "user-a"),
+			},
+			wantReceived:  []received{{"1", "user-a"}, {"3", "user-a"}, {"5", "user-a"}, {"2", "user-b"}, {"4", "user-b"}},
+			wantProcessed: []string{"1", "2", "3", "4", "5"},
+		},
+		{
+			name: "skips redelivered events",
+			envelopes: [][]byte{
+				envelope(t, "1", "user-a"),
+				envelope(t, "1", "user-a"),
+				envelope(t, "2", "user-a"),
+			},
+			wantReceived:  []received{{"1", "user-a"}, {"2", "user-a"}},
+			wantProcessed: []string{"1", "2"},
+		},
+		{
+			name: "skips events processed before a restart",
+			envelopes: [][]byte{
+				envelope(t, "1", "user-a"),
+				envelope(t, "2", "user-a"),
+			},
+			processed:     []string{"1"},
+			wantReceived:  []received{{"2", "user-a"}},
+			wantProcessed: []string{"1", "2"},
+		},
+		{
+			name: "dead letters events that keep failing",
+			envelopes: [][]byte{
+				envelope(t, "1", "user-a"),
+				envelope(t, "2", "user-a"),
+			},
+			failures:        map[string]error{"1": errors.New("test fail")},
+			wantReceived:    []received{{"2", "user-a"}},
+			wantDeadLetters: map[string]int{"1": 3},
+			wantProcessed:   []string{"2"},
+		},
+		{
+			name: "dead letters permanent failures without retrying",
+			envelopes: [][]byte{
+				envelope(t, "1", "user-a"),
+			},
+			failures:        map[string]error{"1": consumer.Permanent(errors.New("test fail"))},
+			wantDeadLetters: map[string]int{"1": 1},
+		},
+		{
+			name: "dead letters undecodable envelopes",
+			envelopes: [][]byte{
+				[]byte("not an envelope"),
+				envelope(t, "1", "user-a"),
+			},
+			wantReceived:    []received{{"1", "user-a"}},
+			wantDeadLetters: map[string]int{"": 0},
+			wantProcessed:   []string{"1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			src := mocks.NewMockSource(ctrl)
+			dl := eventsmocks.NewMockDeadLetterStore(ctrl)
+
+			var mu sync.Mutex
+			gotDeadLetters := map[string]int{}
+
+			dl.EXPECT().InsertDeadLetter(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, d *events.DeadLetter) (*events.DeadLetter, error) {
+				// Stored on the original topic and sink so redriving delivers the event to the consumer again
+				assert.Equal(t, events.TopicUsers, d.Topic)
+				assert.Equal(t, "stream", d.Sink)
+				assert.Equal(t, "test", d.Headers[consumer.HeaderDeadLetterConsumer])
+				assert.NotEmpty(t, d.Error)
+
+				id := d.EventID
+				if d.Key == "" {
+					// Undecodable envelopes are given a new ID
+					id = ""
+				}
+
+				mu.Lock()
+				defer mu.Unlock()
+				gotDeadLetters[id] = d.Attempts
+
+				return d, nil
+			}).AnyTimes()
+
+			envelopes := make(chan []byte, len(tt.envelopes))
+			for _, e := range tt.envelopes {
+				envelopes <- e
+			}
+			close(envelopes)
+
+			src.EXPECT().Subscribe(gomock.Any(), events.TopicUsers).Return((<-chan []byte)(envelopes), nil).Times(1)
+
+			ps := newProcessedStore()
+			for _, id := range tt.processed {
+				require.NoError(t, ps.MarkProcessed(ctx, "test", id))
+			}
+
+			c := consumer.New(consumer.Config{Name: "test", Concurrency: 4, Sink: "stream"}, src, ps, dl)
+
+			gotReceived := map[string][]received{}
+
+			c.Handle(events.TopicUsers, func(ctx context.Context, msg events.Message) error {
+				// Only recorded once handled so the event is handled again if redelivered after a crash
+				assert.False(t, ps.isProcessed("test", msg.ID))
+
+				if err := tt.failures[msg.ID]; err != nil {
+					return err
+				}
+
+				mu.Lock()
+				defer mu.Unlock()
+				gotReceived[msg.Key] = append(gotReceived[msg.Key], received{msg.ID, msg.Key})
+
+				return nil
+			})
+
+			// Listen returns once the source closes and every event has been handled
+			require.NoError(t, c.Listen(ctx))
+
+			wantReceived := map[string][]received{}
+			for _, r := range tt.wantReceived {
+				wantReceived[r.key] = append(wantReceived[r.key], r)
+			}
+			assert.Equal(t, wantReceived, gotReceived)
+
+			if tt.wantDeadLetters == nil {
+				tt.wantDeadLetters = map[string]int{}
+			}
+			assert.Equal(t, tt.wantDeadLetters, gotDeadLetters)
+
+			for _, id := range []string{"1", "2", "3", "4", "5"} {
+				assert.Equal(t, contains(tt.wantProcessed, id), ps.isProcessed("test", id), "event %s", id)
+				// Events that failed are released so they are handled again if redelivered
+				assert.Equal(t, contains(tt.wantProcessed, id), ps.isClaimed("test", id), "event %s", id)
+			}
+		})
+	}
+}
+
+func TestConsumer_Listen_SharedName(t *testing.T) {
+	consumer.ExportDisableBackOff()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ps := newProcessedStore()
+
+	var mu sync.Mutex
+	handled := map[string]int{}
+
+	// Sources such as postgres NOTIFY deliver every event to each instance
+	listen := func() {
+		src := mocks.NewMockSource(ctrl)
+
+		envelopes := make(chan []byte, 5)
+		for _, id := range []string{"1", "2", "3", "4", "5"} {
+			envelopes <- envelope(t, id, "user-"+id)
+		}
+		close(envelopes)
+
+		src.EXPECT().Subscribe(gomock.Any(), events.TopicUsers).Return((<-chan []byte)(envelopes), nil).Times(1)
+
+		c := consumer.New(consumer.Config{Name: "test", Concurrency: 4}, src, ps, nil)
+		c.Handle(events.TopicUsers, func(ctx context.Context, msg events.Message) error {
+			mu.Lock()
+			defer mu.Unlock()
+			handled[msg.ID]++
+			return nil
+		})
+
+		assert.NoError(t, c.Listen(context.Background()))
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			listen()
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, map[string]int{"1": 1, "2": 1, "3": 1, "4": 1, "5": 1}, handled)
+}
+
+func TestConsumer_UserEventHandler(t *testing.T) {
+	ctx := context.Background()
+
+	data, err := events.JSONSerializer{}.Serialize(events.UserEvent{EventType: events.EventTypeUserDeleted, ID: "some-user-id", ChangedFields: []string{}})
+	require.NoError(t, err)
+
+	var got events.UserEvent
+	h := consumer.UserEventHandler(func(ctx context.Context, e events.UserEvent) error {
+		got = e
+		return nil
+	})
+
+	require.NoError(t, h(ctx, events.Message{ContentType: "application/json", Payload: data}))
+	assert.Equal(t, events.UserEvent{EventType: events.EventTypeUserDeleted, ID: "some-user-id", ChangedFields: []string{}}, got)
+
+	err = h(ctx, events.Message{ContentType: "text/plain", Payload: data})
+	assert.ErrorIs(t, err, events.ErrDecode)
+}
+
+func TestConsumer_Listen_PropagatesTrace(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(tracing.Propagator())
+	t.Cleanup(func() {
+		otel.SetTracerProvider(trace.NewNoopTracerProvider())
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+	})
+
+	src := mocks.NewMockSource(ctrl)
+
+	data, err := events.EncodeEnvelope(events.Message{
+		ID:    "1",
+		Topic: events.TopicUsers,
+		Key:   "user-a",
+		Headers: events.Headers{
+			"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			"baggage":     "signup_source=test",
+			"request_id":  "some-request-id",
+		},
+	})
+	require.NoError(t, err)
+
+	envelopes := make(chan []byte, 1)
+	envelopes <- data
+	close(envelopes)
+
+	src.EXPECT().Subscribe(gomock.Any(), events.TopicUsers).Return((<-chan []byte)(envelopes), nil).Times(1)
+
+	c := consumer.New(consumer.Config{Name: "test"}, src, newProcessedStore(), nil)
+
+	var gotTraceID, gotBaggage, gotRequestID string
+
+	c.Handle(events.TopicUsers, func(ctx context.Context, msg events.Message) error {
+		gotTraceID = trace.SpanContextFromContext(ctx).TraceID().String()
+		gotBaggage = baggage.FromContext(ctx).Member("signup_source").Value()
+		gotRequestID = requestid.From(ctx)
+		return nil
+	})
+
+	require.NoError(t, c.Listen(context.Background()))
+
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", gotTraceID)
+	assert.Equal(t, "test", gotBaggage)
+	assert.Equal(t, "some-request-id", gotRequestID)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "users process", spans[0].Name())
+	assert.Equal(t, trace.SpanKindConsumer, spans[0].SpanKind())
+	assert.Equal(t, "00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+	assert.Contains(t, spans[0].Attributes(), requestid.AttributeKey.String("some-request-id"))
+}
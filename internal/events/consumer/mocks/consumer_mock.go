@@ -0,0 +1,118 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/speakeasy-api/rest-template-go/internal/events/consumer (interfaces: Source,ProcessedStore)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+	events "github.com/speakeasy-api/rest-template-go/internal/events"
+)
+
+// MockSource is a mock of Source interface.
+type MockSource struct {
+	ctrl     *gomock.Controller
+	recorder *MockSourceMockRecorder
+}
+
+// MockSourceMockRecorder is the mock recorder for MockSource.
+type MockSourceMockRecorder struct {
+	mock *MockSource
+}
+
+// NewMockSource creates a new mock instance.
+func NewMockSource(ctrl *gomock.Controller) *MockSource {
+	mock := &MockSource{ctrl: ctrl}
+	mock.recorder = &MockSourceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSource) EXPECT() *MockSourceMockRecorder {
+	return m.recorder
+}
+
+// Subscribe mocks base method.
+func (m *MockSource) Subscribe(arg0 context.Context, arg1 events.Topic) (<-chan []byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Subscribe", arg0, arg1)
+	ret0, _ := ret[0].(<-chan []byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Subscribe indicates an expected call of Subscribe.
+func (mr *MockSourceMockRecorder) Subscribe(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockSource)(nil).Subscribe), arg0, arg1)
+}
+
+// MockProcessedStore is a mock of ProcessedStore interface.
+type MockProcessedStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockProcessedStoreMockRecorder
+}
+
+// MockProcessedStoreMockRecorder is the mock recorder for MockProcessedStore.
+type MockProcessedStoreMockRecorder struct {
+	mock *MockProcessedStore
+}
+
+// NewMockProcessedStore creates a new mock instance.
+func NewMockProcessedStore(ctrl *gomock.Controller) *MockProcessedStore {
+	mock := &MockProcessedStore{ctrl: ctrl}
+	mock.recorder = &MockProcessedStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockProcessedStore) EXPECT() *MockProcessedStoreMockRecorder {
+	return m.recorder
+}
+
+// ClaimProcessing mocks base method.
+func (m *MockProcessedStore) ClaimProcessing(arg0 context.Context, arg1, arg2 string, arg3 time.Duration) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimProcessing", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimProcessing indicates an expected call of ClaimProcessing.
+func (mr *MockProcessedStoreMockRecorder) ClaimProcessing(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimProcessing", reflect.TypeOf((*MockProcessedStore)(nil).ClaimProcessing), arg0, arg1, arg2, arg3)
+}
+
+// MarkProcessed mocks base method.
+func (m *MockProcessedStore) MarkProcessed(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkProcessed", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkProcessed indicates an expected call of MarkProcessed.
+func (mr *MockProcessedStoreMockRecorder) MarkProcessed(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkProcessed", reflect.TypeOf((*MockProcessedStore)(nil).MarkProcessed), arg0, arg1, arg2)
+}
+
+// ReleaseProcessing mocks base method.
+func (m *MockProcessedStore) ReleaseProcessing(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseProcessing", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseProcessing indicates an expected call of ReleaseProcessing.
+func (mr *MockProcessedStoreMockRecorder) ReleaseProcessing(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseProcessing", reflect.TypeOf((*MockProcessedStore)(nil).ReleaseProcessing), arg0, arg1, arg2)
+}
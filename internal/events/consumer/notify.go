@@ -0,0 +1,108 @@
+package consumer
+
+import (
+	"context"
+	"sync"
+
+	"github.com/lib/pq"
+	"github.com/speakeasy-api/rest-template-go/internal/core/logging"
+	"github.com/speakeasy-api/rest-template-go/internal/events"
+)
+
+const notifyBufferSize = 100
+
+// Listener represents a type that can receive postgres notifications such as a pq.Listener.
+type Listener interface {
+	Listen(channel string) error
+	NotificationChannel() <-chan *pq.Notification
+	Close() error
+}
+
+// NotifySource is a Source consuming the events published by an events.NotifySink. Every instance receives each
+// event so instances sharing a consumer name rely on claiming events to handle them once, and events published while
+// an instance is disconnected from postgres are missed.
+type NotifySource struct {
+	listener Listener
+
+	mu          sync.Mutex
+	started     bool
+	subscribers map[string]chan []byte
+}
+
+var _ Source = (*NotifySource)(nil)
+
+// NewNotifySource will instantiate a new instance of NotifySource receiving notifications from the listener.
+func NewNotifySource(l Listener) *NotifySource {
+	return &NotifySource{
+		listener:    l,
+		subscribers: map[string]chan []byte{},
+	}
+}
+
+// Subscribe will listen on the postgres channel of the topic, returning a channel of the envelopes notified on it.
+// The listener is closed along with every subscription once the context of the first subscription is done.
+func (s *NotifySource) Subscribe(ctx context.Context, topic events.Topic) (<-chan []byte, error) {
+	channel := events.NotifyChannel(topic)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.listener.Listen(channel); err != nil {
+		return nil, err
+	}
+
+	envelopes := make(chan []byte, notifyBufferSize)
+	s.subscribers[channel] = envelopes
+
+	if !s.started {
+		s.started = true
+		go s.run(ctx)
+	}
+
+	return envelopes, nil
+}
+
+// run will route notifications to the subscription for their channel until the context is done.
+func (s *NotifySource) run(ctx context.Context) {
+	defer func() {
+		_ = s.listener.Close()
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		for _, envelopes := range s.subscribers {
+			close(envelopes)
+		}
+		s.subscribers = map[string]chan []byte{}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n, ok := <-s.listener.NotificationChannel():
+			if !ok {
+				return
+			}
+
+			// A nil notification is sent after the connection is re-established, anything sent in between is lost
+			if n == nil {
+				logging.From(ctx).Warn("reconnected to postgres, events may have been missed")
+				continue
+			}
+
+			s.mu.Lock()
+			envelopes, ok := s.subscribers[n.Channel]
+			s.mu.Unlock()
+			if !ok {
+				continue
+			}
+
+			select {
+			case envelopes <- []byte(n.Extra):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
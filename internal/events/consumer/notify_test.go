@@ -0,0 +1,78 @@
+package consumer_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/speakeasy-api/rest-template-go/internal/events"
+	"github.com/speakeasy-api/rest-template-go/internal/events/consumer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testListener struct {
+	mu            sync.Mutex
+	channels      []string
+	closed        bool
+	notifications chan *pq.Notification
+}
+
+func (l *testListener) Listen(channel string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.channels = append(l.channels, channel)
+	return nil
+}
+
+func (l *testListener) NotificationChannel() <-chan *pq.Notification {
+	return l.notifications
+}
+
+func (l *testListener) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.closed = true
+	return nil
+}
+
+func TestNotifySource_Subscribe(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	l := &testListener{notifications: make(chan *pq.Notification, 3)}
+	s := consumer.NewNotifySource(l)
+
+	envelopes, err := s.Subscribe(ctx, events.TopicUsers)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"events_users"}, l.channels)
+
+	l.notifications <- &pq.Notification{Channel: "events_other", Extra: "other"}
+	l.notifications <- nil
+	l.notifications <- &pq.Notification{Channel: "events_users", Extra: "some-envelope"}
+
+	select {
+	case data := <-envelopes:
+		assert.Equal(t, "some-envelope", string(data))
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for envelope")
+	}
+
+	// Subscriptions end with the context so the consumer stops listening
+	cancel()
+
+	select {
+	case _, ok := <-envelopes:
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for subscription to close")
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	assert.True(t, l.closed)
+}
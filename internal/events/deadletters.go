@@ -0,0 +1,244 @@
+package events
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	"github.com/speakeasy-api/rest-template-go/internal/core/logging"
+	"go.uber.org/zap"
+)
+
+const (
+	// ErrUnknownSink is returned when a dead letter references a sink that is no longer configured.
+	ErrUnknownSink = errors.Error("unknown_sink: sink is not configured")
+	// ErrDeadLetterStore is returned when a failed event couldn't be persisted as a dead letter.
+	ErrDeadLetterStore = errors.Error("dead_letter_store_failed: failed to store dead letter")
+	// ErrEmptyFilter is returned when dead letters are discarded or redriven in bulk without a filter.
+	ErrEmptyFilter = errors.Error("empty_filter: filter must select dead letters by topic, sink or failed before")
+)
+
+const redriveBatchSize = 100
+
+// Headers represents the metadata attached to an event alongside its payload.
+type Headers map[string]string
+
+// Value implements driver.Valuer allowing headers to be stored as jsonb.
+func (h Headers) Value() (driver.Value, error) {
+	if h == nil {
+		return "{}", nil
+	}
+
+	data, err := json.Marshal(h)
+	if err != nil {
+		return nil, err
+	}
+
+	// Returned as a string as the postgres driver would otherwise encode bytes as bytea
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner allowing headers to be read from jsonb.
+func (h *Headers) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, h)
+	case string:
+		return json.Unmarshal([]byte(v), h)
+	case nil:
+		*h = Headers{}
+		return nil
+	default:
+		return errors.ErrUnknown
+	}
+}
+
+// DeadLetter represents an event that could not be published to a sink after exhausting its retries.
+type DeadLetter struct {
+	ID            *string   `json:"id" db:"id"`
+	EventID       string    `json:"event_id" db:"event_id"`
+	Topic         Topic     `json:"topic" db:"topic"`
+	Sink          string    `json:"sink" db:"sink"`
+	Key           string    `json:"key" db:"key"`
+	ContentType   string    `json:"content_type" db:"content_type"`
+	Headers       Headers   `json:"headers" db:"headers"`
+	Payload       []byte    `json:"payload" db:"payload"`
+	Error         string    `json:"error" db:"error"`
+	Attempts      int       `json:"attempts" db:"attempts"`
+	ProducedAt    time.Time `json:"produced_at" db:"produced_at"`
+	FirstFailedAt time.Time `json:"first_failed_at" db:"first_failed_at"`
+	LastFailedAt  time.Time `json:"last_failed_at" db:"last_failed_at"`
+}
+
+// DeadLetterFilter is used to select dead letters, empty fields match all dead letters.
+// FailedBefore matches dead letters that first failed before the given time.
+type DeadLetterFilter struct {
+	Topic        Topic      `json:"topic"`
+	Sink         string     `json:"sink"`
+	FailedBefore *time.Time `json:"failed_before"`
+}
+
+// IsEmpty returns whether the filter matches every dead letter.
+func (f DeadLetterFilter) IsEmpty() bool {
+	return f.Topic == "" && f.Sink == "" && f.FailedBefore == nil
+}
+
+// DeadLetterCount represents the number of dead letters for a topic and sink.
+type DeadLetterCount struct {
+	Topic Topic  `db:"topic"`
+	Sink  string `db:"sink"`
+	Count int64  `db:"count"`
+}
+
+// DeadLetterStore represents a type for persisting dead letters.
+type DeadLetterStore interface {
+	InsertDeadLetter(ctx context.Context, dl *DeadLetter) (*DeadLetter, error)
+	GetDeadLetter(ctx context.Context, id string) (*DeadLetter, error)
+	FindDeadLetters(ctx context.Context, filter DeadLetterFilter, offset, limit int64) ([]*DeadLetter, error)
+	UpdateDeadLetter(ctx context.Context, dl *DeadLetter) (*DeadLetter, error)
+	DeleteDeadLetter(ctx context.Context, id string) error
+	DeleteDeadLetters(ctx context.Context, filter DeadLetterFilter) (int64, error)
+	CountDeadLetters(ctx context.Context) ([]DeadLetterCount, error)
+}
+
+// GetDeadLetter will retrieve a dead letter via its ID.
+func (e *Events) GetDeadLetter(ctx context.Context, id string) (*DeadLetter, error) {
+	return e.deadLetters.GetDeadLetter(ctx, id)
+}
+
+// FindDeadLetters will retrieve the dead letters matching the filter using pagination if limit is gt 0.
+func (e *Events) FindDeadLetters(ctx context.Context, filter DeadLetterFilter, offset, limit int64) ([]*DeadLetter, error) {
+	return e.deadLetters.FindDeadLetters(ctx, filter, offset, limit)
+}
+
+// DiscardDeadLetter will permanently remove a dead letter without publishing it.
+func (e *Events) DiscardDeadLetter(ctx context.Context, id string) error {
+	return e.deadLetters.DeleteDeadLetter(ctx, id)
+}
+
+// DiscardDeadLetters will permanently remove all dead letters matching the filter returning the number removed, the
+// filter must not be empty so every dead letter can't be removed by mistake.
+func (e *Events) DiscardDeadLetters(ctx context.Context, filter DeadLetterFilter) (int64, error) {
+	if err := validateBulkFilter(filter); err != nil {
+		return 0, err
+	}
+
+	return e.deadLetters.DeleteDeadLetters(ctx, filter)
+}
+
+// RedriveDeadLetter will attempt to publish a dead letter to its sink again, removing it on success.
+func (e *Events) RedriveDeadLetter(ctx context.Context, id string) error {
+	dl, err := e.deadLetters.GetDeadLetter(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return e.redrive(ctx, dl)
+}
+
+// RedriveDeadLetters will attempt to publish all dead letters matching the filter again returning the
+// number successfully published. Dead letters that fail again remain in the store with updated attempts. The filter
+// must not be empty.
+func (e *Events) RedriveDeadLetters(ctx context.Context, filter DeadLetterFilter) (int64, error) {
+	if err := validateBulkFilter(filter); err != nil {
+		return 0, err
+	}
+
+	var redriven, offset int64
+
+	for {
+		dls, err := e.deadLetters.FindDeadLetters(ctx, filter, offset, redriveBatchSize)
+		if err != nil {
+			if errors.Is(err, errors.ErrNotFound) {
+				return redriven, nil
+			}
+			return redriven, err
+		}
+
+		for _, dl := range dls {
+			if err := e.redrive(ctx, dl); err != nil {
+				logging.From(ctx).Error("failed to redrive dead letter", zap.Error(err), zap.String("dead_letter_id", *dl.ID))
+				// Failed dead letters stay in the store so skip past them for the next page
+				offset++
+				continue
+			}
+			redriven++
+		}
+
+		if len(dls) < redriveBatchSize {
+			return redriven, nil
+		}
+	}
+}
+
+func (e *Events) redrive(ctx context.Context, dl *DeadLetter) error {
+	d, ok := e.destination(dl.Sink)
+	if !ok {
+		return ErrUnknownSink.Wrap(errors.ErrValidation)
+	}
+
+	// The outcome is recorded even if the caller gives up waiting so the dead letter isn't left out of date
+	ctx, cancel := e.deliveryContext(ctx)
+	defer cancel()
+
+	msg := Message{
+		ID:          dl.EventID,
+		Topic:       dl.Topic,
+		Key:         dl.Key,
+		ContentType: dl.ContentType,
+		Headers:     dl.Headers,
+		Payload:     dl.Payload,
+		Timestamp:   dl.ProducedAt,
+	}
+
+	attempts, err := e.deliver(ctx, d, msg)
+	if err != nil {
+		dl.Attempts += attempts
+		dl.Error = err.Error()
+		dl.LastFailedAt = *timeNow()
+
+		if _, uerr := e.deadLetters.UpdateDeadLetter(ctx, dl); uerr != nil {
+			logging.From(ctx).Error("failed to update dead letter", zap.Error(uerr), zap.String("dead_letter_id", *dl.ID))
+		}
+
+		return ErrPublish.Wrap(err)
+	}
+
+	return e.deadLetters.DeleteDeadLetter(ctx, *dl.ID)
+}
+
+func (e *Events) deadLetter(ctx context.Context, d Destination, msg Message, attempts int, cause error) {
+	if e.deadLetters == nil {
+		logging.From(ctx).Error("event dropped as no dead letter store is configured", zap.Error(cause))
+		return
+	}
+
+	now := timeNow()
+
+	if _, err := e.deadLetters.InsertDeadLetter(ctx, &DeadLetter{
+		EventID:       msg.ID,
+		Topic:         msg.Topic,
+		Sink:          d.Name,
+		Key:           msg.Key,
+		ContentType:   msg.ContentType,
+		Headers:       msg.Headers,
+		Payload:       msg.Payload,
+		Error:         cause.Error(),
+		Attempts:      attempts,
+		ProducedAt:    msg.Timestamp,
+		FirstFailedAt: *now,
+		LastFailedAt:  *now,
+	}); err != nil {
+		logging.From(ctx).Error("event dropped as it couldn't be dead lettered", zap.Error(ErrDeadLetterStore.Wrap(err)), zap.NamedError("cause", cause))
+	}
+}
+
+func validateBulkFilter(filter DeadLetterFilter) error {
+	if filter.IsEmpty() {
+		return errors.WithFieldErrors(ErrEmptyFilter.Wrap(errors.ErrValidation), ErrEmptyFilter.Field("filter"))
+	}
+
+	return nil
+}
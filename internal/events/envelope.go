@@ -0,0 +1,47 @@
+package events
+
+import (
+	"encoding/json"
+
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	eventsv1 "github.com/speakeasy-api/rest-template-go/internal/events/pb/events/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrDecode is returned when an envelope or payload can't be decoded.
+const ErrDecode = errors.Error("decode_failed: failed to decode event")
+
+// EncodeEnvelope will encode the message along with its metadata for transports that can't carry headers natively.
+func EncodeEnvelope(msg Message) ([]byte, error) {
+	return json.Marshal(msg)
+}
+
+// DecodeEnvelope will decode a message encoded with EncodeEnvelope.
+func DecodeEnvelope(data []byte) (Message, error) {
+	msg := Message{}
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return Message{}, ErrDecode.Wrap(err)
+	}
+
+	return msg, nil
+}
+
+// DecodeUserEvent will decode the payload of a message produced from a UserEvent in any supported format.
+func DecodeUserEvent(msg Message) (UserEvent, error) {
+	switch msg.ContentType {
+	case contentTypeJSON:
+		e := UserEvent{}
+		if err := json.Unmarshal(msg.Payload, &e); err != nil {
+			return UserEvent{}, ErrDecode.Wrap(err)
+		}
+		return e, nil
+	case contentTypeProtobuf:
+		pe := &eventsv1.UserEvent{}
+		if err := proto.Unmarshal(msg.Payload, pe); err != nil {
+			return UserEvent{}, ErrDecode.Wrap(err)
+		}
+		return userEventFromProto(pe), nil
+	default:
+		return UserEvent{}, ErrDecode.Wrap(ErrUnsupportedFormat)
+	}
+}
@@ -0,0 +1,71 @@
+package events_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/AlekSi/pointer"
+	"github.com/speakeasy-api/rest-template-go/internal/events"
+	"github.com/speakeasy-api/rest-template-go/internal/users/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeUserEvent(t *testing.T) {
+	createdAt := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	event := events.NewUserEvent(events.EventTypeUserUpdated, "some-user-id", &model.User{
+		ID:        pointer.ToString("some-user-id"),
+		FirstName: pointer.ToString("first"),
+		Email:     pointer.ToString("test@test.com"),
+		CreatedAt: &createdAt,
+	}, &model.User{
+		ID:        pointer.ToString("some-user-id"),
+		FirstName: pointer.ToString("second"),
+		Email:     pointer.ToString("test@test.com"),
+		CreatedAt: &createdAt,
+	})
+
+	tests := []struct {
+		name    string
+		format  events.Format
+		wantErr error
+	}{
+		{
+			name:   "json",
+			format: events.FormatJSON,
+		},
+		{
+			name:   "protobuf",
+			format: events.FormatProtobuf,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := events.NewSerializer(tt.format)
+			require.NoError(t, err)
+
+			payload, err := s.Serialize(event)
+			require.NoError(t, err)
+
+			data, err := events.EncodeEnvelope(events.Message{ID: "some-event-id", ContentType: s.ContentType(), Payload: payload})
+			require.NoError(t, err)
+
+			msg, err := events.DecodeEnvelope(data)
+			require.NoError(t, err)
+			assert.Equal(t, "some-event-id", msg.ID)
+
+			got, err := events.DecodeUserEvent(msg)
+			require.NoError(t, err)
+			assert.Equal(t, event, got)
+		})
+	}
+}
+
+func TestDecodeUserEvent_Error(t *testing.T) {
+	_, err := events.DecodeUserEvent(events.Message{ContentType: "text/plain", Payload: []byte("hello")})
+	assert.ErrorIs(t, err, events.ErrDecode)
+
+	_, err = events.DecodeEnvelope([]byte("hello"))
+	assert.ErrorIs(t, err, events.ErrDecode)
+}
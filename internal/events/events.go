@@ -1,8 +1,40 @@
+//go:generate mockgen -destination=./mocks/events_mock.go -package mocks github.com/speakeasy-api/rest-template-go/internal/events Sink,DeadLetterStore,DB
+
 // Package events represents a stub for producing events to a Kafka topic,
 // a real implementation would contain logic for retrying failed events etc
 package events
 
-import "context"
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/google/uuid"
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	"github.com/speakeasy-api/rest-template-go/internal/core/logging"
+	"github.com/speakeasy-api/rest-template-go/internal/core/requestid"
+	"github.com/speakeasy-api/rest-template-go/internal/events/spool"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	// ErrUnsupportedFormat is returned when a sink is configured with an unknown serialization format.
+	ErrUnsupportedFormat = errors.Error("unsupported_format: unsupported event serialization format")
+	// ErrUnsupportedSink is returned when a sink is configured with an unknown type.
+	ErrUnsupportedSink = errors.Error("unsupported_sink: unsupported event sink type")
+	// ErrSerialize is returned when a payload can't be serialized for a sink.
+	ErrSerialize = errors.Error("serialize_failed: failed to serialize event payload")
+	// ErrPublish is returned when a sink fails to publish an event.
+	ErrPublish = errors.Error("publish_failed: failed to publish event")
+)
 
 // Topic represents a topic in Kafka.
 type Topic string
@@ -12,19 +44,293 @@ const (
 	TopicUsers Topic = "users"
 )
 
+// SinkType represents the type of sink events are delivered to.
+type SinkType string
+
+const (
+	// SinkTypeLog will write events to the application log, useful for development.
+	SinkTypeLog SinkType = "log"
+	// SinkTypePostgres will publish events using postgres NOTIFY, allowing other instances to stream them.
+	SinkTypePostgres SinkType = "postgres"
+)
+
+// HeaderRequestID is the header of events carrying the ID of the request that produced them.
+const HeaderRequestID = "request_id"
+
+const defaultMaxAttempts = 3
+
+const tracerName = "github.com/speakeasy-api/rest-template-go/internal/events"
+
+var timeNow = func() *time.Time {
+	now := time.Now().UTC()
+	return &now
+}
+
+var newBackOff = func() backoff.BackOff {
+	b := backoff.NewExponentialBackOff()
+	b.InitialInterval = 100 * time.Millisecond
+	b.MaxInterval = time.Second
+	return b
+}
+
+// Config represents the configuration of where events are delivered.
+type Config struct {
+	Sinks []SinkConfig `yaml:"sinks"`
+	// MaxAttempts is the number of times publishing an event to a sink is attempted before it is dead lettered.
+	MaxAttempts int `yaml:"maxAttempts"`
+	// QueueSize is the number of events per sink waiting to be delivered before producing blocks, defaults to 1000.
+	QueueSize int `yaml:"queueSize"`
+	// DeliveryTimeout bounds delivering an event to a sink including its retries and dead lettering, defaults to 30s.
+	DeliveryTimeout time.Duration `yaml:"deliveryTimeout"`
+	// Spool configures buffering events on disk while a sink is unavailable.
+	Spool spool.Config `yaml:"spool"`
+}
+
+// SinkConfig represents the configuration of a single sink.
+type SinkConfig struct {
+	Name   string   `yaml:"name"`
+	Type   SinkType `yaml:"type"`
+	Format Format   `yaml:"format"`
+}
+
+// Message represents a serialized event ready to be delivered to a sink.
+type Message struct {
+	ID          string    `json:"id"`
+	Topic       Topic     `json:"topic"`
+	Key         string    `json:"key"`
+	ContentType string    `json:"content_type"`
+	Headers     Headers   `json:"headers"`
+	Payload     []byte    `json:"payload"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+// Sink represents a destination events can be published to such as a Kafka cluster.
+type Sink interface {
+	Publish(ctx context.Context, msg Message) error
+}
+
+// DB represents a type that can execute statements against the database for sinks backed by postgres.
+type DB interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// Keyer can be implemented by payloads to provide a key used for partitioning events.
+type Keyer interface {
+	Key() string
+}
+
+// Destination pairs a sink with the serializer used to encode events delivered to it.
+// If a spool is provided events are buffered in it while the sink is unavailable.
+type Destination struct {
+	Name       string
+	Sink       Sink
+	Serializer Serializer
+	Spool      *spool.Spool
+}
+
 // Events represents an implementation that can produce events.
-type Events struct{}
+type Events struct {
+	destinations    []Destination
+	spoolers        map[string]*spooler
+	deadLetters     DeadLetterStore
+	maxAttempts     int
+	queueSize       int
+	deliveryTimeout time.Duration
+	stop            context.CancelFunc
+	wg              sync.WaitGroup
 
-// New will instantiate a new instance of Events.
-func New() *Events {
-	return &Events{}
+	mu         sync.RWMutex
+	startOnce  sync.Once
+	closed     bool
+	queues     map[string]chan pending
+	deliveries sync.WaitGroup
 }
 
-// Produce will produce an event on the given topic using the supplied payload.
+// New will instantiate a new instance of Events delivering to the provided destinations.
+// Events that fail to publish are persisted to the dead letter store if one is provided.
+func New(dl DeadLetterStore, destinations ...Destination) *Events {
+	spoolers := map[string]*spooler{}
+	for _, d := range destinations {
+		if d.Spool != nil {
+			spoolers[d.Name] = newSpooler(d)
+		}
+	}
+
+	return &Events{
+		destinations:    destinations,
+		spoolers:        spoolers,
+		deadLetters:     dl,
+		maxAttempts:     defaultMaxAttempts,
+		queueSize:       defaultQueueSize,
+		deliveryTimeout: defaultDeliveryTimeout,
+	}
+}
+
+// NewFromConfig will instantiate a new instance of Events with the sinks described by the config.
+func NewFromConfig(cfg Config, dl DeadLetterStore, db DB) (*Events, error) {
+	destinations := []Destination{}
+
+	for _, sc := range cfg.Sinks {
+		serializer, err := NewSerializer(sc.Format)
+		if err != nil {
+			return nil, err
+		}
+
+		var sink Sink
+
+		switch sc.Type {
+		case SinkTypeLog:
+			sink = NewLogSink()
+		case SinkTypePostgres:
+			sink = NewNotifySink(db)
+		default:
+			return nil, ErrUnsupportedSink
+		}
+
+		var sp *spool.Spool
+
+		if cfg.Spool.Dir != "" {
+			sp, err = spool.Open(filepath.Join(cfg.Spool.Dir, sc.Name), cfg.Spool)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		destinations = append(destinations, Destination{
+			Name:       sc.Name,
+			Sink:       sink,
+			Serializer: serializer,
+			Spool:      sp,
+		})
+	}
+
+	e := New(dl, destinations...)
+	if cfg.MaxAttempts > 0 {
+		e.maxAttempts = cfg.MaxAttempts
+	}
+	if cfg.QueueSize > 0 {
+		e.queueSize = cfg.QueueSize
+	}
+	if cfg.DeliveryTimeout > 0 {
+		e.deliveryTimeout = cfg.DeliveryTimeout
+	}
+
+	return e, nil
+}
+
+// Produce will produce an event on the given topic using the supplied payload, it is delivered to each sink in the
+// background in the order events were produced. The trace context, baggage and request ID of ctx are propagated to
+// consumers in the event headers.
 func (e *Events) Produce(ctx context.Context, topic Topic, payload interface{}) {
-	// TODO implement a Kafka producer implementation
-	// ideally the payload would be a protobuf message from a shared schema package
-	// for this exercise we will pass a struct that can be marshalled to JSON
+	id := uuid.NewString()
+	now := timeNow()
+
+	key := ""
+	if k, ok := payload.(Keyer); ok {
+		key = k.Key()
+	}
+
+	ctx, span := otel.Tracer(tracerName).Start(ctx, string(topic)+" send",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingDestinationKey.String(string(topic)),
+			semconv.MessagingDestinationKindTopic,
+			semconv.MessagingMessageIDKey.String(id),
+		),
+	)
+	defer span.End()
+
+	// Headers are persisted with spooled and dead lettered events so the trace survives redelivery. There is no outbox,
+	// events are produced after the change that caused them is committed, so these are the only places events are
+	// stored before they are delivered
+	headers := Headers{}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(headers))
+
+	if requestID := requestid.From(ctx); requestID != "" {
+		headers[HeaderRequestID] = requestID
+		span.SetAttributes(requestid.AttributeKey.String(requestID))
+	}
+
+	for _, d := range e.destinations {
+		ctx := logging.WithFields(ctx, zap.String("event_id", id), zap.String("topic", string(topic)), zap.String("sink", d.Name))
+
+		data, err := d.Serializer.Serialize(payload)
+		if err != nil {
+			logging.From(ctx).Error("failed to serialize event", zap.Error(ErrSerialize.Wrap(err)))
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to serialize event")
+			failedTotal.WithLabelValues(string(topic), d.Name, failureSerialize).Inc()
+			continue
+		}
+
+		// Delivered in the background so retrying an unhealthy sink doesn't hold up the producer
+		e.enqueue(ctx, d, Message{
+			ID:          id,
+			Topic:       topic,
+			Key:         key,
+			ContentType: d.Serializer.ContentType(),
+			Headers:     headers,
+			Payload:     data,
+			Timestamp:   *now,
+		})
+	}
+}
+
+// Start will start replaying any spooled events to their sinks in the background.
+func (e *Events) Start(ctx context.Context) {
+	ctx, e.stop = context.WithCancel(ctx)
+
+	for _, sp := range e.spoolers {
+		e.wg.Add(1)
+
+		go func(sp *spooler) {
+			defer e.wg.Done()
+			sp.run(ctx)
+		}(sp)
+	}
+}
+
+// Close will wait for queued events to be delivered then stop replaying spooled events and close the spools,
+// unreplayed events are kept on disk.
+func (e *Events) Close(ctx context.Context) error {
+	e.closeQueues()
+
+	if e.stop != nil {
+		e.stop()
+	}
+	e.wg.Wait()
+
+	var err error
+	for _, sp := range e.spoolers {
+		if cerr := sp.d.Spool.Close(); cerr != nil {
+			logging.From(ctx).Error("failed to close spool", zap.Error(cerr), zap.String("sink", sp.d.Name))
+			err = cerr
+		}
+	}
+
+	return err
+}
+
+// deliver will publish the message to the destination retrying with backoff, returning the number of attempts made.
+func (e *Events) deliver(ctx context.Context, d Destination, msg Message) (int, error) {
+	attempts := 0
+
+	err := backoff.Retry(func() error {
+		attempts++
+		return d.Sink.Publish(ctx, msg)
+	}, backoff.WithContext(backoff.WithMaxRetries(newBackOff(), uint64(e.maxAttempts-1)), ctx))
+	if err == nil {
+		producedTotal.WithLabelValues(string(msg.Topic), d.Name).Inc()
+	}
+
+	return attempts, err
+}
 
-	// The main implementation would happen asynchronously to avoid blocking the producing call
+func (e *Events) destination(name string) (Destination, bool) {
+	for _, d := range e.destinations {
+		if d.Name == name {
+			return d, true
+		}
+	}
+	return Destination{}, false
 }
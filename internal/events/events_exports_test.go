@@ -0,0 +1,29 @@
+package events
+
+import (
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	ExportProducedTotal *prometheus.CounterVec = producedTotal
+	ExportFailedTotal   *prometheus.CounterVec = failedTotal
+)
+
+func ExportSetTimeNow(t time.Time) {
+	timeNow = func() *time.Time {
+		return &t
+	}
+}
+
+func ExportDisableBackOff() {
+	newBackOff = func() backoff.BackOff {
+		return &backoff.ZeroBackOff{}
+	}
+}
+
+func ExportSetReplayInterval(d time.Duration) {
+	replayInterval = d
+}
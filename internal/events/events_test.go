@@ -0,0 +1,409 @@
+package events_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/AlekSi/pointer"
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	"github.com/speakeasy-api/rest-template-go/internal/core/requestid"
+	"github.com/speakeasy-api/rest-template-go/internal/core/tracing"
+	"github.com/speakeasy-api/rest-template-go/internal/events"
+	"github.com/speakeasy-api/rest-template-go/internal/events/mocks"
+	"github.com/speakeasy-api/rest-template-go/internal/events/spool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var testEvent = events.UserEvent{
+	EventType:     events.EventTypeUserDeleted,
+	ID:            "some-test-id",
+	ChangedFields: []string{},
+}
+
+func TestEvents_Produce_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s := mocks.NewMockSink(ctrl)
+	dl := mocks.NewMockDeadLetterStore(ctrl)
+
+	e := events.New(dl, events.Destination{Name: "test", Sink: s, Serializer: events.JSONSerializer{}})
+
+	wantPayload, err := json.Marshal(testEvent)
+	require.NoError(t, err)
+
+	s.EXPECT().Publish(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, msg events.Message) error {
+		assert.NotEmpty(t, msg.ID)
+		assert.Equal(t, events.TopicUsers, msg.Topic)
+		assert.Equal(t, "some-test-id", msg.Key)
+		assert.Equal(t, "application/json", msg.ContentType)
+		assert.Equal(t, wantPayload, msg.Payload)
+		return nil
+	}).Times(1)
+
+	produced := testutil.ToFloat64(events.ExportProducedTotal.WithLabelValues("users", "test"))
+
+	e.Produce(context.Background(), events.TopicUsers, testEvent)
+	e.Flush()
+
+	assert.Equal(t, produced+1, testutil.ToFloat64(events.ExportProducedTotal.WithLabelValues("users", "test")))
+}
+
+func TestEvents_Produce_DeadLettered(t *testing.T) {
+	events.ExportDisableBackOff()
+	events.ExportSetTimeNow(time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC))
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s := mocks.NewMockSink(ctrl)
+	dl := mocks.NewMockDeadLetterStore(ctrl)
+
+	e := events.New(dl, events.Destination{Name: "test", Sink: s, Serializer: events.JSONSerializer{}})
+
+	s.EXPECT().Publish(gomock.Any(), gomock.Any()).Return(errors.New("test fail")).Times(3)
+	dl.EXPECT().InsertDeadLetter(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, d *events.DeadLetter) (*events.DeadLetter, error) {
+		assert.NoError(t, ctx.Err())
+		assert.Equal(t, events.TopicUsers, d.Topic)
+		assert.Equal(t, "test", d.Sink)
+		assert.Equal(t, "some-test-id", d.Key)
+		assert.Equal(t, "test fail", d.Error)
+		assert.Equal(t, 3, d.Attempts)
+		assert.Equal(t, time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC), d.FirstFailedAt)
+		return d, nil
+	}).Times(1)
+
+	produced := testutil.ToFloat64(events.ExportProducedTotal.WithLabelValues("users", "test"))
+	failed := testutil.ToFloat64(events.ExportFailedTotal.WithLabelValues("users", "test", "publish"))
+
+	// Delivery and dead lettering continue after the request that produced the event is cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	e.Produce(ctx, events.TopicUsers, testEvent)
+	cancel()
+	e.Flush()
+
+	assert.Equal(t, produced, testutil.ToFloat64(events.ExportProducedTotal.WithLabelValues("users", "test")))
+	assert.Equal(t, failed+1, testutil.ToFloat64(events.ExportFailedTotal.WithLabelValues("users", "test", "publish")))
+}
+
+func TestEvents_RedriveDeadLetter_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s := mocks.NewMockSink(ctrl)
+	dl := mocks.NewMockDeadLetterStore(ctrl)
+
+	e := events.New(dl, events.Destination{Name: "test", Sink: s, Serializer: events.JSONSerializer{}})
+
+	deadLetter := &events.DeadLetter{
+		ID:          pointer.ToString("some-dead-letter-id"),
+		EventID:     "some-event-id",
+		Topic:       events.TopicUsers,
+		Sink:        "test",
+		Key:         "some-test-id",
+		ContentType: "application/json",
+		Payload:     []byte(`{}`),
+		Attempts:    3,
+	}
+
+	dl.EXPECT().GetDeadLetter(gomock.Any(), "some-dead-letter-id").Return(deadLetter, nil).Times(1)
+	s.EXPECT().Publish(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, msg events.Message) error {
+		assert.Equal(t, "some-event-id", msg.ID)
+		assert.Equal(t, []byte(`{}`), msg.Payload)
+		return nil
+	}).Times(1)
+	dl.EXPECT().DeleteDeadLetter(gomock.Any(), "some-dead-letter-id").Return(nil).Times(1)
+
+	err := e.RedriveDeadLetter(context.Background(), "some-dead-letter-id")
+	assert.NoError(t, err)
+}
+
+func TestEvents_RedriveDeadLetter_Error(t *testing.T) {
+	events.ExportDisableBackOff()
+	events.ExportSetTimeNow(time.Date(2020, time.January, 2, 0, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		name        string
+		sink        string
+		publishErr  error
+		wantErr     error
+		wantUpdated bool
+	}{
+		{
+			name:        "fails to publish again",
+			sink:        "test",
+			publishErr:  errors.New("test fail"),
+			wantErr:     events.ErrPublish,
+			wantUpdated: true,
+		},
+		{
+			name:    "sink no longer configured",
+			sink:    "removed",
+			wantErr: events.ErrUnknownSink,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := mocks.NewMockSink(ctrl)
+			dl := mocks.NewMockDeadLetterStore(ctrl)
+
+			e := events.New(dl, events.Destination{Name: "test", Sink: s, Serializer: events.JSONSerializer{}})
+
+			dl.EXPECT().GetDeadLetter(gomock.Any(), "some-dead-letter-id").Return(&events.DeadLetter{
+				ID:       pointer.ToString("some-dead-letter-id"),
+				Sink:     tt.sink,
+				Attempts: 3,
+			}, nil).Times(1)
+
+			if tt.wantUpdated {
+				s.EXPECT().Publish(gomock.Any(), gomock.Any()).Return(tt.publishErr).Times(3)
+				dl.EXPECT().UpdateDeadLetter(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, d *events.DeadLetter) (*events.DeadLetter, error) {
+					assert.Equal(t, 6, d.Attempts)
+					assert.Equal(t, time.Date(2020, time.January, 2, 0, 0, 0, 0, time.UTC), d.LastFailedAt)
+					return d, nil
+				}).Times(1)
+			}
+
+			err := e.RedriveDeadLetter(context.Background(), "some-dead-letter-id")
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestEvents_RedriveDeadLetters_Success(t *testing.T) {
+	events.ExportDisableBackOff()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s := mocks.NewMockSink(ctrl)
+	dl := mocks.NewMockDeadLetterStore(ctrl)
+
+	e := events.New(dl, events.Destination{Name: "test", Sink: s, Serializer: events.JSONSerializer{}})
+
+	filter := events.DeadLetterFilter{Topic: events.TopicUsers}
+
+	dl.EXPECT().FindDeadLetters(gomock.Any(), filter, int64(0), int64(100)).Return([]*events.DeadLetter{
+		{ID: pointer.ToString("1"), Sink: "test"},
+		{ID: pointer.ToString("2"), Sink: "test"},
+	}, nil).Times(1)
+	gomock.InOrder(
+		s.EXPECT().Publish(gomock.Any(), gomock.Any()).Return(nil).Times(1),
+		s.EXPECT().Publish(gomock.Any(), gomock.Any()).Return(errors.New("test fail")).Times(3),
+	)
+	dl.EXPECT().DeleteDeadLetter(gomock.Any(), "1").Return(nil).Times(1)
+	dl.EXPECT().UpdateDeadLetter(gomock.Any(), gomock.Any()).Return(nil, nil).Times(1)
+
+	count, err := e.RedriveDeadLetters(context.Background(), filter)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestEvents_DeadLetters_EmptyFilter(t *testing.T) {
+	tests := []struct {
+		name string
+		call func(e *events.Events) (int64, error)
+	}{
+		{
+			name: "redrive",
+			call: func(e *events.Events) (int64, error) {
+				return e.RedriveDeadLetters(context.Background(), events.DeadLetterFilter{})
+			},
+		},
+		{
+			name: "discard",
+			call: func(e *events.Events) (int64, error) {
+				return e.DiscardDeadLetters(context.Background(), events.DeadLetterFilter{})
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// No calls are expected as an empty filter would select every dead letter
+			dl := mocks.NewMockDeadLetterStore(ctrl)
+
+			e := events.New(dl, events.Destination{Name: "test", Sink: mocks.NewMockSink(ctrl), Serializer: events.JSONSerializer{}})
+
+			count, err := tt.call(e)
+			assert.ErrorIs(t, err, events.ErrEmptyFilter)
+			assert.ErrorIs(t, err, errors.ErrValidation)
+			assert.Zero(t, count)
+		})
+	}
+}
+
+func TestEvents_Produce_Spooled(t *testing.T) {
+	events.ExportDisableBackOff()
+	events.ExportSetReplayInterval(10 * time.Millisecond)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s := mocks.NewMockSink(ctrl)
+	dl := mocks.NewMockDeadLetterStore(ctrl)
+
+	sp, err := spool.Open(t.TempDir(), spool.Config{Fsync: spool.FsyncAlways})
+	require.NoError(t, err)
+
+	e := events.New(dl, events.Destination{Name: "test", Sink: s, Serializer: events.JSONSerializer{}, Spool: sp})
+
+	published := make(chan string, 3)
+	healthy := false
+
+	// The sink fails until it is marked healthy, no events should be dead lettered while spooling
+	s.EXPECT().Publish(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, msg events.Message) error {
+		if !healthy {
+			return errors.New("test fail")
+		}
+		published <- msg.Key
+		return nil
+	}).AnyTimes()
+
+	for _, id := range []string{"1", "2", "3"} {
+		e.Produce(context.Background(), events.TopicUsers, events.UserEvent{EventType: events.EventTypeUserDeleted, ID: id})
+	}
+	e.Flush()
+	assert.False(t, sp.Empty())
+
+	healthy = true
+
+	e.Start(context.Background())
+
+	for _, want := range []string{"1", "2", "3"} {
+		select {
+		case got := <-published:
+			assert.Equal(t, want, got)
+		case <-time.After(time.Second):
+			require.FailNow(t, "timed out waiting for spooled event to be replayed")
+		}
+	}
+
+	require.NoError(t, e.Close(context.Background()))
+	assert.True(t, sp.Empty())
+}
+
+func TestEvents_Produce_SpooledKeepsTrace(t *testing.T) {
+	events.ExportDisableBackOff()
+	events.ExportSetReplayInterval(10 * time.Millisecond)
+
+	tp := sdktrace.NewTracerProvider()
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(tracing.Propagator())
+	t.Cleanup(func() {
+		otel.SetTracerProvider(trace.NewNoopTracerProvider())
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+	})
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s := mocks.NewMockSink(ctrl)
+
+	sp, err := spool.Open(t.TempDir(), spool.Config{Fsync: spool.FsyncAlways})
+	require.NoError(t, err)
+
+	e := events.New(mocks.NewMockDeadLetterStore(ctrl), events.Destination{Name: "test", Sink: s, Serializer: events.JSONSerializer{}, Spool: sp})
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "request")
+
+	published := make(chan events.Headers, 1)
+	healthy := false
+
+	s.EXPECT().Publish(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, msg events.Message) error {
+		if !healthy {
+			return errors.New("test fail")
+		}
+		published <- msg.Headers
+		return nil
+	}).AnyTimes()
+
+	e.Produce(ctx, events.TopicUsers, testEvent)
+	e.Flush()
+	parent.End()
+	assert.False(t, sp.Empty())
+
+	healthy = true
+
+	// Replayed outside of the request, the trace context comes from the spooled headers
+	e.Start(context.Background())
+
+	select {
+	case headers := <-published:
+		assert.Contains(t, headers["traceparent"], parent.SpanContext().TraceID().String())
+	case <-time.After(time.Second):
+		require.FailNow(t, "timed out waiting for spooled event to be replayed")
+	}
+
+	require.NoError(t, e.Close(context.Background()))
+}
+
+func TestEvents_Produce_PropagatesTrace(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(tracing.Propagator())
+	t.Cleanup(func() {
+		otel.SetTracerProvider(trace.NewNoopTracerProvider())
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+	})
+
+	s := mocks.NewMockSink(ctrl)
+
+	e := events.New(nil, events.Destination{Name: "test", Sink: s, Serializer: events.JSONSerializer{}})
+
+	member, err := baggage.NewMember("signup_source", "test")
+	require.NoError(t, err)
+	bag, err := baggage.New(member)
+	require.NoError(t, err)
+
+	ctx, parent := tp.Tracer("test").Start(baggage.ContextWithBaggage(context.Background(), bag), "request")
+	ctx = requestid.With(ctx, "some-request-id")
+
+	s.EXPECT().Publish(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, msg events.Message) error {
+		assert.Contains(t, msg.Headers["traceparent"], parent.SpanContext().TraceID().String())
+		assert.Equal(t, "signup_source=test", msg.Headers["baggage"])
+		assert.Equal(t, "some-request-id", msg.Headers[events.HeaderRequestID])
+		return nil
+	}).Times(1)
+
+	e.Produce(ctx, events.TopicUsers, testEvent)
+	e.Flush()
+	parent.End()
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, s := range recorder.Ended() {
+		spans[s.Name()] = s
+	}
+	require.Len(t, spans, 3)
+
+	send := spans["users send"]
+	require.NotNil(t, send)
+	assert.Equal(t, trace.SpanKindProducer, send.SpanKind())
+	assert.Equal(t, parent.SpanContext().SpanID(), send.Parent().SpanID())
+	assert.Contains(t, send.Attributes(), requestid.AttributeKey.String("some-request-id"))
+
+	// Delivered in the background as part of the same trace
+	publish := spans["users publish"]
+	require.NotNil(t, publish)
+	assert.Equal(t, send.SpanContext().SpanID(), publish.Parent().SpanID())
+}
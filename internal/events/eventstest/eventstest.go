@@ -0,0 +1,135 @@
+// Package eventstest provides an in-memory broker for asserting on the events produced in tests.
+package eventstest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/speakeasy-api/rest-template-go/internal/events"
+	"github.com/speakeasy-api/rest-template-go/internal/events/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const pollInterval = 5 * time.Millisecond
+
+// Broker is an in-memory broker that events can be produced to and consumed from in tests.
+// Events are delivered with the same serialization and retries as in production so tests
+// assert on what consumers would actually receive.
+type Broker struct {
+	*memory.Broker
+	t       testing.TB
+	events  *events.Events
+	mu      sync.Mutex
+	offsets map[events.Topic]int
+}
+
+// New will instantiate a new instance of Broker serializing events as JSON.
+func New(t testing.TB) *Broker {
+	b := memory.New(memory.Config{})
+
+	e := events.New(nil, events.Destination{Name: "memory", Sink: b, Serializer: events.JSONSerializer{}})
+	t.Cleanup(func() {
+		_ = e.Close(context.Background())
+	})
+
+	return &Broker{
+		Broker:  b,
+		t:       t,
+		events:  e,
+		offsets: map[events.Topic]int{},
+	}
+}
+
+// Produce will produce an event on the given topic using the supplied payload, returning once it has been
+// delivered so AssertNoEvents can't miss events still being delivered in the background.
+func (b *Broker) Produce(ctx context.Context, topic events.Topic, payload interface{}) {
+	b.events.Produce(ctx, topic, payload)
+	b.events.Flush()
+}
+
+// AwaitEvent will wait for an event matching the predicate to be produced on the topic, failing the test if
+// there is none before the timeout. Events are matched in order and once an event is matched it and all the
+// events before it are considered seen, so later calls and AssertNoEvents only consider events after it.
+func (b *Broker) AwaitEvent(topic events.Topic, predicate func(msg events.Message) bool, timeout time.Duration) events.Message {
+	b.t.Helper()
+
+	deadline := time.Now().Add(timeout)
+
+	for {
+		if msg, ok := b.match(topic, predicate); ok {
+			return msg
+		}
+
+		if time.Now().After(deadline) {
+			require.FailNow(b.t, "no matching event produced", "topic %s, unseen events: %v", topic, b.unseen(topic))
+			return events.Message{}
+		}
+
+		time.Sleep(pollInterval)
+	}
+}
+
+// AwaitUserEvent will wait for a user event matching the predicate to be produced, see AwaitEvent.
+func (b *Broker) AwaitUserEvent(predicate func(e events.UserEvent) bool, timeout time.Duration) events.UserEvent {
+	b.t.Helper()
+
+	var e events.UserEvent
+
+	b.AwaitEvent(events.TopicUsers, func(msg events.Message) bool {
+		ue, err := events.DecodeUserEvent(msg)
+		if err != nil || !predicate(ue) {
+			return false
+		}
+		e = ue
+		return true
+	}, timeout)
+
+	return e
+}
+
+// AssertNoEvents will assert that no events have been produced on the topic since the last event that was awaited.
+func (b *Broker) AssertNoEvents(topic events.Topic) bool {
+	b.t.Helper()
+
+	return assert.Empty(b.t, b.unseen(topic), "unexpected events produced on topic %s", topic)
+}
+
+// IsUserEvent returns a predicate matching user events of the given type for the user.
+func IsUserEvent(eventType events.EventType, id string) func(e events.UserEvent) bool {
+	return func(e events.UserEvent) bool {
+		return e.EventType == eventType && e.ID == id
+	}
+}
+
+func (b *Broker) match(topic events.Topic, predicate func(msg events.Message) bool) (events.Message, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	records := b.Records(topic)
+
+	for i := b.offsets[topic]; i < len(records); i++ {
+		if predicate(records[i].Message) {
+			b.offsets[topic] = i + 1
+			return records[i].Message, true
+		}
+	}
+
+	return events.Message{}, false
+}
+
+func (b *Broker) unseen(topic events.Topic) []events.Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	records := b.Records(topic)
+
+	msgs := []events.Message{}
+	for _, r := range records[b.offsets[topic]:] {
+		msgs = append(msgs, r.Message)
+	}
+
+	return msgs
+}
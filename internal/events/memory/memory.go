@@ -0,0 +1,192 @@
+// Package memory provides an in-process event broker, useful for tests and local development.
+package memory
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+
+	"github.com/speakeasy-api/rest-template-go/internal/events"
+)
+
+const defaultPartitions = 4
+
+// Config represents the configuration of a Broker.
+type Config struct {
+	// Partitions is the number of partitions per topic, events with the same key are always in the same partition.
+	Partitions int
+}
+
+// Record represents an event retained by the broker along with its position in the topic.
+type Record struct {
+	Partition int
+	Offset    int
+	Message   events.Message
+	envelope  []byte
+}
+
+type topic struct {
+	partitions [][]Record
+	// records holds every record in the order it was published across all partitions
+	records []Record
+	// published is closed and replaced whenever a record is published to wake up subscribers
+	published chan struct{}
+	// next is used to spread events without a key across partitions
+	next int
+}
+
+// Broker retains every event published to it, delivering them to subscribers in order within each partition.
+// It implements both events.Sink and consumer.Source.
+type Broker struct {
+	mu         sync.Mutex
+	partitions int
+	topics     map[events.Topic]*topic
+}
+
+// New will instantiate a new instance of Broker.
+func New(cfg Config) *Broker {
+	partitions := cfg.Partitions
+	if partitions <= 0 {
+		partitions = defaultPartitions
+	}
+
+	return &Broker{
+		partitions: partitions,
+		topics:     map[events.Topic]*topic{},
+	}
+}
+
+// Publish will append the message to the partition for its key.
+func (b *Broker) Publish(ctx context.Context, msg events.Message) error {
+	data, err := events.EncodeEnvelope(msg)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	t := b.topic(msg.Topic)
+
+	partition := 0
+	if msg.Key != "" {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(msg.Key))
+		partition = int(h.Sum32() % uint32(b.partitions))
+	} else {
+		partition = t.next
+		t.next = (t.next + 1) % b.partitions
+	}
+
+	r := Record{
+		Partition: partition,
+		Offset:    len(t.partitions[partition]),
+		Message:   msg,
+		envelope:  data,
+	}
+	t.partitions[partition] = append(t.partitions[partition], r)
+	t.records = append(t.records, r)
+
+	close(t.published)
+	t.published = make(chan struct{})
+
+	return nil
+}
+
+// Subscribe will return a channel receiving the envelopes of events published to the topic from now on,
+// the channel is closed once the context is done.
+func (b *Broker) Subscribe(ctx context.Context, topic events.Topic) (<-chan []byte, error) {
+	b.mu.Lock()
+	t := b.topic(topic)
+	offsets := make([]int, b.partitions)
+	for i, p := range t.partitions {
+		offsets[i] = len(p)
+	}
+	b.mu.Unlock()
+
+	return b.consume(ctx, t, offsets), nil
+}
+
+// Replay will return a channel receiving the envelopes of every event retained for the topic followed by
+// any published from now on, the channel is closed once the context is done.
+func (b *Broker) Replay(ctx context.Context, topic events.Topic) (<-chan []byte, error) {
+	b.mu.Lock()
+	t := b.topic(topic)
+	b.mu.Unlock()
+
+	return b.consume(ctx, t, make([]int, b.partitions)), nil
+}
+
+// Records returns every event published to the topic in the order they were published.
+func (b *Broker) Records(topic events.Topic) []Record {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	t := b.topic(topic)
+
+	records := make([]Record, len(t.records))
+	copy(records, t.records)
+
+	return records
+}
+
+// consume will deliver the records of each partition from the given offsets, partitions are consumed
+// independently so a partition with a slow handler doesn't hold up the others.
+func (b *Broker) consume(ctx context.Context, t *topic, offsets []int) <-chan []byte {
+	envelopes := make(chan []byte)
+
+	var wg sync.WaitGroup
+
+	for partition, offset := range offsets {
+		wg.Add(1)
+
+		go func(partition, offset int) {
+			defer wg.Done()
+
+			for {
+				b.mu.Lock()
+				if offset == len(t.partitions[partition]) {
+					published := t.published
+					b.mu.Unlock()
+
+					select {
+					case <-published:
+						continue
+					case <-ctx.Done():
+						return
+					}
+				}
+				r := t.partitions[partition][offset]
+				b.mu.Unlock()
+
+				select {
+				case envelopes <- r.envelope:
+					offset++
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(partition, offset)
+	}
+
+	go func() {
+		wg.Wait()
+		close(envelopes)
+	}()
+
+	return envelopes
+}
+
+// topic returns the topic creating it if needed, b.mu must be held.
+func (b *Broker) topic(name events.Topic) *topic {
+	t, ok := b.topics[name]
+	if !ok {
+		t = &topic{
+			partitions: make([][]Record, b.partitions),
+			published:  make(chan struct{}),
+		}
+		b.topics[name] = t
+	}
+
+	return t
+}
@@ -0,0 +1,120 @@
+package memory_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/speakeasy-api/rest-template-go/internal/events"
+	"github.com/speakeasy-api/rest-template-go/internal/events/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func publish(t *testing.T, b *memory.Broker, topic events.Topic, id, key string) {
+	t.Helper()
+
+	require.NoError(t, b.Publish(context.Background(), events.Message{ID: id, Topic: topic, Key: key, Headers: events.Headers{}, Payload: []byte(`{}`)}))
+}
+
+func receive(t *testing.T, envelopes <-chan []byte, n int) map[string][]string {
+	t.Helper()
+
+	got := map[string][]string{}
+
+	for i := 0; i < n; i++ {
+		select {
+		case data := <-envelopes:
+			msg, err := events.DecodeEnvelope(data)
+			require.NoError(t, err)
+			got[msg.Key] = append(got[msg.Key], msg.ID)
+		case <-time.After(time.Second):
+			require.FailNow(t, "timed out waiting for events", "got %v", got)
+		}
+	}
+
+	return got
+}
+
+func TestBroker_Subscribe(t *testing.T) {
+	tests := []struct {
+		name   string
+		replay bool
+		want   map[string][]string
+	}{
+		{
+			name: "only receives new events",
+			want: map[string][]string{"a": {"3", "5"}, "b": {"4"}},
+		},
+		{
+			name:   "replays retained events",
+			replay: true,
+			want:   map[string][]string{"a": {"1", "3", "5"}, "b": {"2", "4"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			b := memory.New(memory.Config{Partitions: 2})
+
+			publish(t, b, events.TopicUsers, "1", "a")
+			publish(t, b, events.TopicUsers, "2", "b")
+
+			subscribe := b.Subscribe
+			if tt.replay {
+				subscribe = b.Replay
+			}
+
+			envelopes, err := subscribe(ctx, events.TopicUsers)
+			require.NoError(t, err)
+
+			publish(t, b, events.TopicUsers, "3", "a")
+			publish(t, b, events.TopicUsers, "4", "b")
+			publish(t, b, "others", "other", "a")
+			publish(t, b, events.TopicUsers, "5", "a")
+
+			n := 0
+			for _, ids := range tt.want {
+				n += len(ids)
+			}
+
+			// Events are ordered within a key but may be interleaved across partitions
+			assert.Equal(t, tt.want, receive(t, envelopes, n))
+
+			cancel()
+
+			// Subscriptions are closed once their context is done
+			assert.Eventually(t, func() bool {
+				_, ok := <-envelopes
+				return !ok
+			}, time.Second, 10*time.Millisecond)
+		})
+	}
+}
+
+func TestBroker_Records(t *testing.T) {
+	b := memory.New(memory.Config{Partitions: 3})
+
+	for i := 0; i < 6; i++ {
+		publish(t, b, events.TopicUsers, fmt.Sprint(i), "some-key")
+	}
+	publish(t, b, events.TopicUsers, "no-key-1", "")
+	publish(t, b, events.TopicUsers, "no-key-2", "")
+
+	records := b.Records(events.TopicUsers)
+	require.Len(t, records, 8)
+
+	for i, r := range records[:6] {
+		assert.Equal(t, fmt.Sprint(i), r.Message.ID)
+		assert.Equal(t, records[0].Partition, r.Partition, "events with the same key share a partition")
+		assert.Equal(t, i, r.Offset)
+	}
+
+	// Events without a key are spread across partitions
+	assert.NotEqual(t, records[6].Partition, records[7].Partition)
+
+	assert.Empty(t, b.Records("others"))
+}
@@ -0,0 +1,95 @@
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/speakeasy-api/rest-template-go/internal/core/logging"
+	"go.uber.org/zap"
+)
+
+const collectTimeout = 5 * time.Second
+
+const (
+	failureSerialize = "serialize"
+	failurePublish   = "publish"
+)
+
+var (
+	producedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "events_produced_total",
+		Help: "Number of events published to a sink, including those replayed from the spool or redriven.",
+	}, []string{"topic", "sink"})
+	failedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "events_failed_total",
+		Help: "Number of events that couldn't be published to a sink when produced by reason, serialize or publish.",
+	}, []string{"topic", "sink", "reason"})
+)
+
+// Collectors returns the collectors counting the events produced and failed.
+func Collectors() []prometheus.Collector {
+	return []prometheus.Collector{producedTotal, failedTotal}
+}
+
+var deadLettersDesc = prometheus.NewDesc(
+	"events_dead_letters",
+	"Number of events waiting in the dead letter store.",
+	[]string{"topic", "sink"},
+	nil,
+)
+
+type deadLetterPair struct {
+	topic Topic
+	sink  string
+}
+
+// DeadLetterCollector is a prometheus.Collector that reports the depth of the dead letter store.
+type DeadLetterCollector struct {
+	store DeadLetterStore
+
+	mu   sync.Mutex
+	seen map[deadLetterPair]struct{}
+}
+
+// NewDeadLetterCollector will instantiate a new instance of DeadLetterCollector.
+func NewDeadLetterCollector(s DeadLetterStore) *DeadLetterCollector {
+	return &DeadLetterCollector{
+		store: s,
+		seen:  map[deadLetterPair]struct{}{},
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *DeadLetterCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- deadLettersDesc
+}
+
+// Collect implements prometheus.Collector counting the dead letters for each topic and sink. Pairs that had dead
+// letters before are reported as 0 once they are all redriven or discarded so alerts on them resolve.
+func (c *DeadLetterCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+
+	counts, err := c.store.CountDeadLetters(ctx)
+	if err != nil {
+		logging.From(ctx).Error("failed to count dead letters", zap.Error(err))
+		ch <- prometheus.NewInvalidMetric(deadLettersDesc, err)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	depths := make(map[deadLetterPair]int64, len(counts))
+	for _, count := range counts {
+		pair := deadLetterPair{topic: count.Topic, sink: count.Sink}
+		depths[pair] = count.Count
+		c.seen[pair] = struct{}{}
+	}
+
+	for pair := range c.seen {
+		ch <- prometheus.MustNewConstMetric(deadLettersDesc, prometheus.GaugeValue, float64(depths[pair]), string(pair.topic), pair.sink)
+	}
+}
@@ -0,0 +1,44 @@
+package events_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/speakeasy-api/rest-template-go/internal/events"
+	"github.com/speakeasy-api/rest-template-go/internal/events/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeadLetterCollector_Collect(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dl := mocks.NewMockDeadLetterStore(ctrl)
+	c := events.NewDeadLetterCollector(dl)
+
+	dl.EXPECT().CountDeadLetters(gomock.Any()).Return([]events.DeadLetterCount{
+		{Topic: events.TopicUsers, Sink: "kafka", Count: 2},
+		{Topic: events.TopicUsers, Sink: "notify", Count: 1},
+	}, nil).Times(1)
+
+	assert.NoError(t, testutil.CollectAndCompare(c, strings.NewReader(`
+# HELP events_dead_letters Number of events waiting in the dead letter store.
+# TYPE events_dead_letters gauge
+events_dead_letters{sink="kafka",topic="users"} 2
+events_dead_letters{sink="notify",topic="users"} 1
+`)))
+
+	// Once every dead letter of a pair is redriven or discarded it is reported as empty rather than disappearing
+	dl.EXPECT().CountDeadLetters(gomock.Any()).Return([]events.DeadLetterCount{
+		{Topic: events.TopicUsers, Sink: "kafka", Count: 1},
+	}, nil).Times(1)
+
+	assert.NoError(t, testutil.CollectAndCompare(c, strings.NewReader(`
+# HELP events_dead_letters Number of events waiting in the dead letter store.
+# TYPE events_dead_letters gauge
+events_dead_letters{sink="kafka",topic="users"} 1
+events_dead_letters{sink="notify",topic="users"} 0
+`)))
+}
@@ -0,0 +1,221 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/speakeasy-api/rest-template-go/internal/events (interfaces: Sink,DeadLetterStore,DB)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	sql "database/sql"
+	events "github.com/speakeasy-api/rest-template-go/internal/events"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockSink is a mock of Sink interface.
+type MockSink struct {
+	ctrl     *gomock.Controller
+	recorder *MockSinkMockRecorder
+}
+
+// MockSinkMockRecorder is the mock recorder for MockSink.
+type MockSinkMockRecorder struct {
+	mock *MockSink
+}
+
+// NewMockSink creates a new mock instance.
+func NewMockSink(ctrl *gomock.Controller) *MockSink {
+	mock := &MockSink{ctrl: ctrl}
+	mock.recorder = &MockSinkMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSink) EXPECT() *MockSinkMockRecorder {
+	return m.recorder
+}
+
+// Publish mocks base method.
+func (m *MockSink) Publish(arg0 context.Context, arg1 events.Message) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Publish", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Publish indicates an expected call of Publish.
+func (mr *MockSinkMockRecorder) Publish(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Publish", reflect.TypeOf((*MockSink)(nil).Publish), arg0, arg1)
+}
+
+// MockDeadLetterStore is a mock of DeadLetterStore interface.
+type MockDeadLetterStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockDeadLetterStoreMockRecorder
+}
+
+// MockDeadLetterStoreMockRecorder is the mock recorder for MockDeadLetterStore.
+type MockDeadLetterStoreMockRecorder struct {
+	mock *MockDeadLetterStore
+}
+
+// NewMockDeadLetterStore creates a new mock instance.
+func NewMockDeadLetterStore(ctrl *gomock.Controller) *MockDeadLetterStore {
+	mock := &MockDeadLetterStore{ctrl: ctrl}
+	mock.recorder = &MockDeadLetterStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDeadLetterStore) EXPECT() *MockDeadLetterStoreMockRecorder {
+	return m.recorder
+}
+
+// CountDeadLetters mocks base method.
+func (m *MockDeadLetterStore) CountDeadLetters(arg0 context.Context) ([]events.DeadLetterCount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountDeadLetters", arg0)
+	ret0, _ := ret[0].([]events.DeadLetterCount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountDeadLetters indicates an expected call of CountDeadLetters.
+func (mr *MockDeadLetterStoreMockRecorder) CountDeadLetters(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountDeadLetters", reflect.TypeOf((*MockDeadLetterStore)(nil).CountDeadLetters), arg0)
+}
+
+// DeleteDeadLetter mocks base method.
+func (m *MockDeadLetterStore) DeleteDeadLetter(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteDeadLetter", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteDeadLetter indicates an expected call of DeleteDeadLetter.
+func (mr *MockDeadLetterStoreMockRecorder) DeleteDeadLetter(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDeadLetter", reflect.TypeOf((*MockDeadLetterStore)(nil).DeleteDeadLetter), arg0, arg1)
+}
+
+// DeleteDeadLetters mocks base method.
+func (m *MockDeadLetterStore) DeleteDeadLetters(arg0 context.Context, arg1 events.DeadLetterFilter) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteDeadLetters", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteDeadLetters indicates an expected call of DeleteDeadLetters.
+func (mr *MockDeadLetterStoreMockRecorder) DeleteDeadLetters(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDeadLetters", reflect.TypeOf((*MockDeadLetterStore)(nil).DeleteDeadLetters), arg0, arg1)
+}
+
+// FindDeadLetters mocks base method.
+func (m *MockDeadLetterStore) FindDeadLetters(arg0 context.Context, arg1 events.DeadLetterFilter, arg2, arg3 int64) ([]*events.DeadLetter, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindDeadLetters", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]*events.DeadLetter)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindDeadLetters indicates an expected call of FindDeadLetters.
+func (mr *MockDeadLetterStoreMockRecorder) FindDeadLetters(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindDeadLetters", reflect.TypeOf((*MockDeadLetterStore)(nil).FindDeadLetters), arg0, arg1, arg2, arg3)
+}
+
+// GetDeadLetter mocks base method.
+func (m *MockDeadLetterStore) GetDeadLetter(arg0 context.Context, arg1 string) (*events.DeadLetter, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeadLetter", arg0, arg1)
+	ret0, _ := ret[0].(*events.DeadLetter)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeadLetter indicates an expected call of GetDeadLetter.
+func (mr *MockDeadLetterStoreMockRecorder) GetDeadLetter(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeadLetter", reflect.TypeOf((*MockDeadLetterStore)(nil).GetDeadLetter), arg0, arg1)
+}
+
+// InsertDeadLetter mocks base method.
+func (m *MockDeadLetterStore) InsertDeadLetter(arg0 context.Context, arg1 *events.DeadLetter) (*events.DeadLetter, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertDeadLetter", arg0, arg1)
+	ret0, _ := ret[0].(*events.DeadLetter)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InsertDeadLetter indicates an expected call of InsertDeadLetter.
+func (mr *MockDeadLetterStoreMockRecorder) InsertDeadLetter(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertDeadLetter", reflect.TypeOf((*MockDeadLetterStore)(nil).InsertDeadLetter), arg0, arg1)
+}
+
+// UpdateDeadLetter mocks base method.
+func (m *MockDeadLetterStore) UpdateDeadLetter(arg0 context.Context, arg1 *events.DeadLetter) (*events.DeadLetter, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateDeadLetter", arg0, arg1)
+	ret0, _ := ret[0].(*events.DeadLetter)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateDeadLetter indicates an expected call of UpdateDeadLetter.
+func (mr *MockDeadLetterStoreMockRecorder) UpdateDeadLetter(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDeadLetter", reflect.TypeOf((*MockDeadLetterStore)(nil).UpdateDeadLetter), arg0, arg1)
+}
+
+// MockDB is a mock of DB interface.
+type MockDB struct {
+	ctrl     *gomock.Controller
+	recorder *MockDBMockRecorder
+}
+
+// MockDBMockRecorder is the mock recorder for MockDB.
+type MockDBMockRecorder struct {
+	mock *MockDB
+}
+
+// NewMockDB creates a new mock instance.
+func NewMockDB(ctrl *gomock.Controller) *MockDB {
+	mock := &MockDB{ctrl: ctrl}
+	mock.recorder = &MockDBMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDB) EXPECT() *MockDBMockRecorder {
+	return m.recorder
+}
+
+// ExecContext mocks base method.
+func (m *MockDB) ExecContext(arg0 context.Context, arg1 string, arg2 ...interface{}) (sql.Result, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ExecContext", varargs...)
+	ret0, _ := ret[0].(sql.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExecContext indicates an expected call of ExecContext.
+func (mr *MockDBMockRecorder) ExecContext(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecContext", reflect.TypeOf((*MockDB)(nil).ExecContext), varargs...)
+}
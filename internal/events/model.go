@@ -1,6 +1,12 @@
 package events
 
-import "github.com/speakeasy-api/rest-template-go/internal/users/model"
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/speakeasy-api/rest-template-go/internal/users/model"
+)
 
 // EventType represents the type of event that occurred.
 type EventType string
@@ -14,9 +20,98 @@ const (
 	EventTypeUserDeleted EventType = "user_deleted"
 )
 
+// redactedFields are the json names of user fields that should never leave the service in an event.
+var redactedFields = map[string]struct{}{
+	"password": {},
+}
+
 // UserEvent represents an event that occurs on a user entity.
+// Before is nil for created users and After is nil for deleted users.
 type UserEvent struct {
-	EventType EventType   `json:"event_type"`
-	ID        string      `json:"id"`
-	User      *model.User `json:"user"`
+	EventType     EventType   `json:"event_type"`
+	ID            string      `json:"id"`
+	Before        *model.User `json:"before"`
+	After         *model.User `json:"after"`
+	ChangedFields []string    `json:"changed_fields"`
+}
+
+// NewUserEvent will create a UserEvent describing the change between the before and after state of a user,
+// sensitive fields are redacted from both states but are still reported in the changed fields.
+func NewUserEvent(eventType EventType, id string, before, after *model.User) UserEvent {
+	return UserEvent{
+		EventType:     eventType,
+		ID:            id,
+		Before:        redact(before),
+		After:         redact(after),
+		ChangedFields: changedFields(before, after),
+	}
+}
+
+func redact(u *model.User) *model.User {
+	if u == nil {
+		return nil
+	}
+
+	redacted := *u
+	v := reflect.ValueOf(&redacted).Elem()
+
+	for i := 0; i < v.NumField(); i++ {
+		if _, ok := redactedFields[jsonName(v.Type().Field(i))]; ok {
+			v.Field(i).Set(reflect.Zero(v.Field(i).Type()))
+		}
+	}
+
+	return &redacted
+}
+
+func changedFields(before, after *model.User) []string {
+	var b, a reflect.Value
+	if before != nil {
+		b = reflect.ValueOf(*before)
+	}
+	if after != nil {
+		a = reflect.ValueOf(*after)
+	}
+
+	t := reflect.TypeOf(model.User{})
+	changed := []string{}
+
+	for i := 0; i < t.NumField(); i++ {
+		var bf, af reflect.Value
+		if b.IsValid() {
+			bf = b.Field(i)
+		}
+		if a.IsValid() {
+			af = a.Field(i)
+		}
+
+		if !fieldEqual(bf, af) {
+			changed = append(changed, jsonName(t.Field(i)))
+		}
+	}
+
+	return changed
+}
+
+func fieldEqual(a, b reflect.Value) bool {
+	aNil := !a.IsValid() || a.IsNil()
+	bNil := !b.IsValid() || b.IsNil()
+
+	switch {
+	case aNil && bNil:
+		return true
+	case aNil || bNil:
+		return false
+	}
+
+	if at, ok := a.Interface().(*time.Time); ok {
+		bt, _ := b.Interface().(*time.Time)
+		return at.Equal(*bt)
+	}
+
+	return reflect.DeepEqual(a.Elem().Interface(), b.Elem().Interface())
+}
+
+func jsonName(f reflect.StructField) string {
+	return strings.Split(f.Tag.Get("json"), ",")[0]
 }
@@ -0,0 +1,106 @@
+//go:generate buf generate --template ../../buf.gen.yaml --output ../.. --path ../../proto/events ../../proto
+
+package events
+
+import (
+	eventsv1 "github.com/speakeasy-api/rest-template-go/internal/events/pb/events/v1"
+	"github.com/speakeasy-api/rest-template-go/internal/users/model"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var userEventTypes = map[EventType]eventsv1.UserEventType{
+	EventTypeUserCreated: eventsv1.UserEventType_USER_EVENT_TYPE_CREATED,
+	EventTypeUserUpdated: eventsv1.UserEventType_USER_EVENT_TYPE_UPDATED,
+	EventTypeUserDeleted: eventsv1.UserEventType_USER_EVENT_TYPE_DELETED,
+}
+
+var userEventTypesFromProto = map[eventsv1.UserEventType]EventType{
+	eventsv1.UserEventType_USER_EVENT_TYPE_CREATED: EventTypeUserCreated,
+	eventsv1.UserEventType_USER_EVENT_TYPE_UPDATED: EventTypeUserUpdated,
+	eventsv1.UserEventType_USER_EVENT_TYPE_DELETED: EventTypeUserDeleted,
+}
+
+// Key returns the ID of the user so all events for a user are delivered in order.
+func (e UserEvent) Key() string {
+	return e.ID
+}
+
+// ToProto converts the event to its protobuf representation.
+func (e UserEvent) ToProto() proto.Message {
+	return &eventsv1.UserEvent{
+		EventType:     userEventTypes[e.EventType],
+		Id:            e.ID,
+		Before:        userToProto(e.Before),
+		After:         userToProto(e.After),
+		ChangedFields: e.ChangedFields,
+	}
+}
+
+func userToProto(u *model.User) *eventsv1.User {
+	if u == nil {
+		return nil
+	}
+
+	pu := &eventsv1.User{
+		FirstName: u.FirstName,
+		LastName:  u.LastName,
+		Nickname:  u.Nickname,
+		Email:     u.Email,
+		Country:   u.Country,
+	}
+	if u.ID != nil {
+		pu.Id = *u.ID
+	}
+	if u.CreatedAt != nil {
+		pu.CreatedAt = timestamppb.New(*u.CreatedAt)
+	}
+	if u.UpdatedAt != nil {
+		pu.UpdatedAt = timestamppb.New(*u.UpdatedAt)
+	}
+
+	return pu
+}
+
+func userEventFromProto(pe *eventsv1.UserEvent) UserEvent {
+	changedFields := pe.ChangedFields
+	if changedFields == nil {
+		changedFields = []string{}
+	}
+
+	return UserEvent{
+		EventType:     userEventTypesFromProto[pe.EventType],
+		ID:            pe.Id,
+		Before:        userFromProto(pe.Before),
+		After:         userFromProto(pe.After),
+		ChangedFields: changedFields,
+	}
+}
+
+func userFromProto(pu *eventsv1.User) *model.User {
+	if pu == nil {
+		return nil
+	}
+
+	u := &model.User{
+		FirstName: pu.FirstName,
+		LastName:  pu.LastName,
+		Nickname:  pu.Nickname,
+		Email:     pu.Email,
+		Country:   pu.Country,
+	}
+	if pu.Id != "" {
+		id := pu.Id
+		u.ID = &id
+	}
+	if pu.CreatedAt != nil {
+		createdAt := pu.CreatedAt.AsTime()
+		u.CreatedAt = &createdAt
+	}
+	if pu.UpdatedAt != nil {
+		updatedAt := pu.UpdatedAt.AsTime()
+		u.UpdatedAt = &updatedAt
+	}
+
+	return u
+}
@@ -0,0 +1,170 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"github.com/speakeasy-api/rest-template-go/internal/core/logging"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultQueueSize       = 1000
+	defaultDeliveryTimeout = 30 * time.Second
+)
+
+// pending is an event waiting in the queue of a destination, events without a message mark the point a flush is
+// waiting for.
+type pending struct {
+	ctx   context.Context
+	msg   Message
+	flush chan struct{}
+}
+
+// detachedContext keeps the values of a context such as its logger, span and request ID without its deadline or
+// cancellation, so events are still delivered once the request that produced them has finished.
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+// deliveryContext returns a context for delivering an event that isn't cancelled with ctx, bounded by the delivery
+// timeout so retries and dead lettering can't run forever.
+func (e *Events) deliveryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(detachedContext{ctx}, e.deliveryTimeout)
+}
+
+// startQueues will start a worker per destination delivering its queued events in the order they were produced.
+func (e *Events) startQueues() {
+	e.queues = make(map[string]chan pending, len(e.destinations))
+
+	for _, d := range e.destinations {
+		q := make(chan pending, e.queueSize)
+		e.queues[d.Name] = q
+
+		e.deliveries.Add(1)
+
+		go func(d Destination, q chan pending) {
+			defer e.deliveries.Done()
+
+			for p := range q {
+				if p.flush != nil {
+					close(p.flush)
+					continue
+				}
+
+				e.publish(p.ctx, d, p.msg)
+			}
+		}(d, q)
+	}
+}
+
+// enqueue will queue the message to be delivered to the destination in the background. Events produced once the
+// queues are closed are delivered before returning so they aren't lost while shutting down.
+func (e *Events) enqueue(ctx context.Context, d Destination, msg Message) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.closed {
+		e.publish(ctx, d, msg)
+		return
+	}
+
+	e.startOnce.Do(e.startQueues)
+
+	// Blocks while the queue is full so a sink that can't keep up slows down producers rather than exhausting memory
+	e.queues[d.Name] <- pending{ctx: detachedContext{ctx}, msg: msg}
+}
+
+// Flush will wait until the events produced before it was called have been delivered, spooled or dead lettered.
+func (e *Events) Flush() {
+	e.mu.RLock()
+
+	if e.closed || e.queues == nil {
+		e.mu.RUnlock()
+		return
+	}
+
+	flushes := make([]chan struct{}, 0, len(e.queues))
+	for _, q := range e.queues {
+		flush := make(chan struct{})
+		q <- pending{flush: flush}
+		flushes = append(flushes, flush)
+	}
+
+	e.mu.RUnlock()
+
+	for _, flush := range flushes {
+		<-flush
+	}
+}
+
+// closeQueues will stop queueing events and wait for the queued events to be delivered.
+func (e *Events) closeQueues() {
+	e.mu.Lock()
+	if !e.closed {
+		e.closed = true
+		for _, q := range e.queues {
+			close(q)
+		}
+	}
+	e.mu.Unlock()
+
+	e.deliveries.Wait()
+}
+
+// publish will deliver the message to the destination with retries, spooling it if the sink is unhealthy or dead
+// lettering it if it can't be delivered.
+func (e *Events) publish(ctx context.Context, d Destination, msg Message) {
+	ctx, cancel := e.deliveryContext(ctx)
+	defer cancel()
+
+	ctx, span := otel.Tracer(tracerName).Start(ctx, string(msg.Topic)+" publish",
+		trace.WithAttributes(
+			semconv.MessagingDestinationKey.String(string(msg.Topic)),
+			semconv.MessagingMessageIDKey.String(msg.ID),
+			attribute.String("messaging.sink", d.Name),
+		),
+	)
+	defer span.End()
+
+	sp, spooled := e.spoolers[d.Name]
+
+	// While a sink is unhealthy events go straight to the spool to preserve their order
+	if spooled && sp.appendIfSpooling(ctx, msg) {
+		return
+	}
+
+	attempts, err := e.deliver(ctx, d, msg)
+	if err == nil {
+		return
+	}
+
+	failedTotal.WithLabelValues(string(msg.Topic), d.Name, failurePublish).Inc()
+
+	logging.From(ctx).Error("failed to publish event", zap.Error(ErrPublish.Wrap(err)), zap.Int("attempts", attempts))
+	span.RecordError(err)
+	span.SetStatus(codes.Error, "failed to publish event")
+
+	if spooled && sp.append(ctx, msg) {
+		return
+	}
+
+	e.deadLetter(ctx, d, msg, attempts, err)
+}
@@ -0,0 +1,266 @@
+// Package schema provides tooling for checking the event schemas in proto/events remain
+// backwards compatible with the schemas that have previously been released.
+package schema
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	eventsv1 "github.com/speakeasy-api/rest-template-go/internal/events/pb/events/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const (
+	// ErrRead is returned when a descriptor set can't be read from disk.
+	ErrRead = errors.Error("failed to read descriptor set")
+	// ErrWrite is returned when a descriptor set can't be written to disk.
+	ErrWrite = errors.Error("failed to write descriptor set")
+	// ErrUnmarshal is returned when a descriptor set can't be decoded.
+	ErrUnmarshal = errors.Error("failed to unmarshal descriptor set")
+)
+
+// files are the schema files that are published to consumers of our events.
+var files = []protoreflect.FileDescriptor{
+	eventsv1.File_events_v1_user_events_proto,
+}
+
+// Violation represents a change between two schemas that breaks existing consumers.
+type Violation struct {
+	File    string
+	Element string
+	Reason  string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s: %s", v.File, v.Element, v.Reason)
+}
+
+// Current returns the descriptor set of the schemas compiled into this binary including their dependencies.
+func Current() *descriptorpb.FileDescriptorSet {
+	set := &descriptorpb.FileDescriptorSet{}
+	seen := map[string]bool{}
+
+	var add func(fd protoreflect.FileDescriptor)
+	add = func(fd protoreflect.FileDescriptor) {
+		if seen[fd.Path()] {
+			return
+		}
+		seen[fd.Path()] = true
+
+		imports := fd.Imports()
+		for i := 0; i < imports.Len(); i++ {
+			add(imports.Get(i).FileDescriptor)
+		}
+
+		set.File = append(set.File, protodesc.ToFileDescriptorProto(fd))
+	}
+
+	for _, fd := range files {
+		add(fd)
+	}
+
+	return set
+}
+
+// Load reads a binary encoded descriptor set from disk.
+func Load(path string) (*descriptorpb.FileDescriptorSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, ErrRead.Wrap(err)
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(data, set); err != nil {
+		return nil, ErrUnmarshal.Wrap(err)
+	}
+
+	return set, nil
+}
+
+// Save writes the descriptor set to disk in its binary encoding.
+func Save(path string, set *descriptorpb.FileDescriptorSet) error {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(set)
+	if err != nil {
+		return ErrWrite.Wrap(err)
+	}
+
+	//nolint:gosec
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return ErrWrite.Wrap(err)
+	}
+
+	return nil
+}
+
+// Check compares the current descriptor set against a previously released one and returns
+// any changes that would break consumers relying on either the wire or JSON encodings.
+func Check(previous, current *descriptorpb.FileDescriptorSet) []Violation {
+	currentFiles := map[string]*descriptorpb.FileDescriptorProto{}
+	for _, f := range current.GetFile() {
+		currentFiles[f.GetName()] = f
+	}
+
+	violations := []Violation{}
+
+	for _, pf := range previous.GetFile() {
+		cf, ok := currentFiles[pf.GetName()]
+		if !ok {
+			violations = append(violations, Violation{File: pf.GetName(), Element: pf.GetName(), Reason: "file was removed"})
+			continue
+		}
+
+		c := checker{file: pf.GetName()}
+		c.checkFile(pf, cf)
+		violations = append(violations, c.violations...)
+	}
+
+	return violations
+}
+
+type checker struct {
+	file       string
+	violations []Violation
+}
+
+func (c *checker) add(element, format string, args ...interface{}) {
+	c.violations = append(c.violations, Violation{File: c.file, Element: element, Reason: fmt.Sprintf(format, args...)})
+}
+
+func (c *checker) checkFile(previous, current *descriptorpb.FileDescriptorProto) {
+	if previous.GetPackage() != current.GetPackage() {
+		c.add(previous.GetName(), "package changed from %q to %q", previous.GetPackage(), current.GetPackage())
+		return
+	}
+
+	c.checkMessages(previous.GetPackage(), previous.GetMessageType(), current.GetMessageType())
+	c.checkEnums(previous.GetPackage(), previous.GetEnumType(), current.GetEnumType())
+}
+
+func (c *checker) checkMessages(scope string, previous, current []*descriptorpb.DescriptorProto) {
+	currentMessages := map[string]*descriptorpb.DescriptorProto{}
+	for _, m := range current {
+		currentMessages[m.GetName()] = m
+	}
+
+	for _, pm := range previous {
+		name := scope + "." + pm.GetName()
+
+		cm, ok := currentMessages[pm.GetName()]
+		if !ok {
+			c.add(name, "message was removed")
+			continue
+		}
+
+		c.checkFields(name, pm, cm)
+		c.checkMessages(name, pm.GetNestedType(), cm.GetNestedType())
+		c.checkEnums(name, pm.GetEnumType(), cm.GetEnumType())
+	}
+}
+
+func (c *checker) checkFields(scope string, previous, current *descriptorpb.DescriptorProto) {
+	currentFields := map[int32]*descriptorpb.FieldDescriptorProto{}
+	for _, f := range current.GetField() {
+		currentFields[f.GetNumber()] = f
+	}
+
+	for _, pf := range previous.GetField() {
+		name := scope + "." + pf.GetName()
+
+		cf, ok := currentFields[pf.GetNumber()]
+		if !ok {
+			if !fieldReserved(current, pf) {
+				c.add(name, "field %d was removed without reserving its number and name", pf.GetNumber())
+			}
+			continue
+		}
+
+		if pf.GetName() != cf.GetName() {
+			c.add(name, "field %d was renamed to %q", pf.GetNumber(), cf.GetName())
+		}
+		if pf.GetType() != cf.GetType() || pf.GetTypeName() != cf.GetTypeName() {
+			c.add(name, "field %d changed type from %s to %s", pf.GetNumber(), fieldType(pf), fieldType(cf))
+		}
+		if pf.GetLabel() != cf.GetLabel() {
+			c.add(name, "field %d changed label from %s to %s", pf.GetNumber(), pf.GetLabel(), cf.GetLabel())
+		}
+		if pf.GetProto3Optional() != cf.GetProto3Optional() {
+			c.add(name, "field %d changed presence", pf.GetNumber())
+		}
+	}
+}
+
+func (c *checker) checkEnums(scope string, previous, current []*descriptorpb.EnumDescriptorProto) {
+	currentEnums := map[string]*descriptorpb.EnumDescriptorProto{}
+	for _, e := range current {
+		currentEnums[e.GetName()] = e
+	}
+
+	for _, pe := range previous {
+		name := scope + "." + pe.GetName()
+
+		ce, ok := currentEnums[pe.GetName()]
+		if !ok {
+			c.add(name, "enum was removed")
+			continue
+		}
+
+		currentValues := map[int32]*descriptorpb.EnumValueDescriptorProto{}
+		for _, v := range ce.GetValue() {
+			currentValues[v.GetNumber()] = v
+		}
+
+		for _, pv := range pe.GetValue() {
+			cv, ok := currentValues[pv.GetNumber()]
+			switch {
+			case !ok && !enumValueReserved(ce, pv):
+				c.add(name+"."+pv.GetName(), "enum value %d was removed without reserving its number and name", pv.GetNumber())
+			case ok && cv.GetName() != pv.GetName():
+				c.add(name+"."+pv.GetName(), "enum value %d was renamed to %q", pv.GetNumber(), cv.GetName())
+			}
+		}
+	}
+}
+
+func fieldReserved(m *descriptorpb.DescriptorProto, f *descriptorpb.FieldDescriptorProto) bool {
+	numberReserved := false
+	for _, r := range m.GetReservedRange() {
+		// Reserved message ranges are exclusive of the end
+		if f.GetNumber() >= r.GetStart() && f.GetNumber() < r.GetEnd() {
+			numberReserved = true
+		}
+	}
+
+	return numberReserved && contains(m.GetReservedName(), f.GetName())
+}
+
+func enumValueReserved(e *descriptorpb.EnumDescriptorProto, v *descriptorpb.EnumValueDescriptorProto) bool {
+	numberReserved := false
+	for _, r := range e.GetReservedRange() {
+		// Reserved enum ranges are inclusive of the end
+		if v.GetNumber() >= r.GetStart() && v.GetNumber() <= r.GetEnd() {
+			numberReserved = true
+		}
+	}
+
+	return numberReserved && contains(e.GetReservedName(), v.GetName())
+}
+
+func fieldType(f *descriptorpb.FieldDescriptorProto) string {
+	if f.GetTypeName() != "" {
+		return f.GetTypeName()
+	}
+	return f.GetType().String()
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+package schema_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/speakeasy-api/rest-template-go/internal/events/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const userEventsFile = "events/v1/user_events.proto"
+
+func TestCheck_Released(t *testing.T) {
+	released, err := schema.Load(filepath.Join("..", "..", "..", "proto", "released", "events.binpb"))
+	require.NoError(t, err)
+
+	assert.Empty(t, schema.Check(released, schema.Current()))
+}
+
+func TestCheck_Success(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(f *descriptorpb.FileDescriptorProto)
+	}{
+		{
+			name:   "no changes",
+			mutate: func(f *descriptorpb.FileDescriptorProto) {},
+		},
+		{
+			name: "field added",
+			mutate: func(f *descriptorpb.FileDescriptorProto) {
+				m := message(f, "UserEvent")
+				m.Field = append(m.Field, &descriptorpb.FieldDescriptorProto{
+					Name:   proto.String("request_id"),
+					Number: proto.Int32(6),
+					Type:   descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+					Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				})
+			},
+		},
+		{
+			name: "field removed and reserved",
+			mutate: func(f *descriptorpb.FileDescriptorProto) {
+				m := message(f, "UserEvent")
+				m.Field = m.Field[:len(m.Field)-1]
+				m.ReservedRange = append(m.ReservedRange, &descriptorpb.DescriptorProto_ReservedRange{Start: proto.Int32(5), End: proto.Int32(6)})
+				m.ReservedName = append(m.ReservedName, "changed_fields")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous := schema.Current()
+			current := proto.Clone(previous).(*descriptorpb.FileDescriptorSet)
+			tt.mutate(file(current, userEventsFile))
+
+			assert.Empty(t, schema.Check(previous, current))
+		})
+	}
+}
+
+func TestCheck_Error(t *testing.T) {
+	tests := []struct {
+		name        string
+		mutate      func(s *descriptorpb.FileDescriptorSet)
+		wantElement string
+	}{
+		{
+			name: "file removed",
+			mutate: func(s *descriptorpb.FileDescriptorSet) {
+				s.File = s.File[:len(s.File)-1]
+			},
+			wantElement: userEventsFile,
+		},
+		{
+			name: "package changed",
+			mutate: func(s *descriptorpb.FileDescriptorSet) {
+				file(s, userEventsFile).Package = proto.String("events.v2")
+			},
+			wantElement: userEventsFile,
+		},
+		{
+			name: "message removed",
+			mutate: func(s *descriptorpb.FileDescriptorSet) {
+				f := file(s, userEventsFile)
+				f.MessageType = f.MessageType[1:]
+			},
+			wantElement: "events.v1.User",
+		},
+		{
+			name: "field removed",
+			mutate: func(s *descriptorpb.FileDescriptorSet) {
+				m := message(file(s, userEventsFile), "UserEvent")
+				m.Field = m.Field[:len(m.Field)-1]
+			},
+			wantElement: "events.v1.UserEvent.changed_fields",
+		},
+		{
+			name: "field renamed",
+			mutate: func(s *descriptorpb.FileDescriptorSet) {
+				message(file(s, userEventsFile), "UserEvent").Field[1].Name = proto.String("user_id")
+			},
+			wantElement: "events.v1.UserEvent.id",
+		},
+		{
+			name: "field type changed",
+			mutate: func(s *descriptorpb.FileDescriptorSet) {
+				message(file(s, userEventsFile), "UserEvent").Field[1].Type = descriptorpb.FieldDescriptorProto_TYPE_BYTES.Enum()
+			},
+			wantElement: "events.v1.UserEvent.id",
+		},
+		{
+			name: "field label changed",
+			mutate: func(s *descriptorpb.FileDescriptorSet) {
+				message(file(s, userEventsFile), "UserEvent").Field[1].Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+			},
+			wantElement: "events.v1.UserEvent.id",
+		},
+		{
+			name: "enum value removed",
+			mutate: func(s *descriptorpb.FileDescriptorSet) {
+				e := file(s, userEventsFile).EnumType[0]
+				e.Value = e.Value[:len(e.Value)-1]
+			},
+			wantElement: "events.v1.UserEventType.USER_EVENT_TYPE_DELETED",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous := schema.Current()
+			current := proto.Clone(previous).(*descriptorpb.FileDescriptorSet)
+			tt.mutate(current)
+
+			violations := schema.Check(previous, current)
+			require.Len(t, violations, 1)
+			assert.Equal(t, tt.wantElement, violations[0].Element)
+		})
+	}
+}
+
+func file(s *descriptorpb.FileDescriptorSet, name string) *descriptorpb.FileDescriptorProto {
+	for _, f := range s.File {
+		if f.GetName() == name {
+			return f
+		}
+	}
+	return nil
+}
+
+func message(f *descriptorpb.FileDescriptorProto, name string) *descriptorpb.DescriptorProto {
+	for _, m := range f.MessageType {
+		if m.GetName() == name {
+			return m
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package events
+
+import (
+	"encoding/json"
+
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrUnsupportedPayload is returned when a payload has no representation in the requested format.
+const ErrUnsupportedPayload = errors.Error("unsupported_payload: payload can't be serialized in this format")
+
+// Format is an enum providing the supported serialization formats for events.
+type Format string
+
+const (
+	// FormatJSON serializes events as JSON.
+	FormatJSON Format = "json"
+	// FormatProtobuf serializes events using the protobuf definitions in proto/events.
+	FormatProtobuf Format = "protobuf"
+)
+
+const (
+	contentTypeJSON     = "application/json"
+	contentTypeProtobuf = "application/x-protobuf"
+)
+
+// Serializer represents a type that can encode event payloads for delivery to a sink.
+type Serializer interface {
+	ContentType() string
+	Serialize(payload interface{}) ([]byte, error)
+}
+
+// Protoer can be implemented by payloads that have a protobuf representation.
+type Protoer interface {
+	ToProto() proto.Message
+}
+
+// NewSerializer will return the Serializer for the given format, defaulting to JSON.
+func NewSerializer(f Format) (Serializer, error) {
+	switch f {
+	case FormatJSON, "":
+		return JSONSerializer{}, nil
+	case FormatProtobuf:
+		return ProtobufSerializer{}, nil
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// JSONSerializer serializes payloads to JSON.
+type JSONSerializer struct{}
+
+// ContentType returns the media type of the serialized payloads.
+func (JSONSerializer) ContentType() string {
+	return contentTypeJSON
+}
+
+// Serialize will marshal the payload to JSON.
+func (JSONSerializer) Serialize(payload interface{}) ([]byte, error) {
+	return json.Marshal(payload)
+}
+
+// ProtobufSerializer serializes payloads to the protobuf wire format.
+type ProtobufSerializer struct{}
+
+// ContentType returns the media type of the serialized payloads.
+func (ProtobufSerializer) ContentType() string {
+	return contentTypeProtobuf
+}
+
+// Serialize will marshal the payload to the protobuf wire format if it is or can be converted to a proto message.
+func (ProtobufSerializer) Serialize(payload interface{}) ([]byte, error) {
+	switch p := payload.(type) {
+	case proto.Message:
+		return proto.Marshal(p)
+	case Protoer:
+		return proto.Marshal(p.ToProto())
+	default:
+		return nil, ErrUnsupportedPayload
+	}
+}
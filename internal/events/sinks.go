@@ -0,0 +1,61 @@
+package events
+
+import (
+	"context"
+
+	"github.com/speakeasy-api/rest-template-go/internal/core/logging"
+	"go.uber.org/zap"
+)
+
+// LogSink is a Sink that writes events to the application log instead of a broker.
+type LogSink struct{}
+
+// NewLogSink will instantiate a new instance of LogSink.
+func NewLogSink() *LogSink {
+	return &LogSink{}
+}
+
+// Publish will log the message and its payload.
+func (s *LogSink) Publish(ctx context.Context, msg Message) error {
+	logging.From(ctx).Info("event produced",
+		zap.String("event_id", msg.ID),
+		zap.String("topic", string(msg.Topic)),
+		zap.String("key", msg.Key),
+		zap.String("content_type", msg.ContentType),
+		zap.Binary("payload", msg.Payload),
+	)
+
+	return nil
+}
+
+// NotifySink is a Sink that publishes events over postgres NOTIFY so every instance of the service can observe them.
+// Postgres limits notification payloads to 8000 bytes, larger events will fail to publish.
+type NotifySink struct {
+	db DB
+}
+
+// NewNotifySink will instantiate a new instance of NotifySink.
+func NewNotifySink(db DB) *NotifySink {
+	return &NotifySink{
+		db: db,
+	}
+}
+
+// Publish will notify listeners of the channel for the message's topic with the serialized message.
+func (s *NotifySink) Publish(ctx context.Context, msg Message) error {
+	data, err := EncodeEnvelope(msg)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.db.ExecContext(ctx, "SELECT pg_notify($1, $2)", NotifyChannel(msg.Topic), string(data)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// NotifyChannel returns the postgres channel events for the topic are published on by a NotifySink.
+func NotifyChannel(topic Topic) string {
+	return "events_" + string(topic)
+}
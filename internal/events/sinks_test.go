@@ -0,0 +1,56 @@
+package events_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	"github.com/speakeasy-api/rest-template-go/internal/events"
+	"github.com/speakeasy-api/rest-template-go/internal/events/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotifySink_Publish(t *testing.T) {
+	msg := events.Message{
+		ID:          "some-event-id",
+		Topic:       events.TopicUsers,
+		Key:         "some-test-id",
+		ContentType: "application/json",
+		Payload:     []byte(`{}`),
+	}
+	wantPayload, err := json.Marshal(msg)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		execErr error
+		wantErr error
+	}{
+		{
+			name: "success",
+		},
+		{
+			name:    "fails",
+			execErr: errors.ErrUnknown,
+			wantErr: errors.ErrUnknown,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			db := mocks.NewMockDB(ctrl)
+			db.EXPECT().ExecContext(gomock.Any(), "SELECT pg_notify($1, $2)", "events_users", string(wantPayload)).Return(nil, tt.execErr).Times(1)
+
+			err := events.NewNotifySink(db).Publish(context.Background(), msg)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,645 @@
+// Package spool provides a disk backed write-ahead queue used to buffer events
+// while a sink is unavailable so they survive process restarts.
+//
+// Records are appended to segment files, each record is prefixed with its length and a
+// CRC32 checksum so partially written or corrupted records can be detected on replay.
+// Fully replayed segments are deleted and the replay position is persisted in a cursor file.
+package spool
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+)
+
+const (
+	// ErrEmpty is returned when there are no records left to replay.
+	ErrEmpty = errors.Error("spool is empty")
+	// ErrCorrupt is returned when a record fails its checksum, the remainder of its segment is skipped.
+	ErrCorrupt = errors.Error("spool segment is corrupt")
+	// ErrOpen is returned when the spool directory can't be opened.
+	ErrOpen = errors.Error("failed to open spool")
+	// ErrWrite is returned when a record can't be written to the spool.
+	ErrWrite = errors.Error("failed to write to spool")
+	// ErrRead is returned when a record can't be read from the spool.
+	ErrRead = errors.Error("failed to read from spool")
+	// ErrClosed is returned when the spool is used after being closed.
+	ErrClosed = errors.Error("spool is closed")
+)
+
+// FsyncPolicy determines when appended records are flushed to stable storage.
+type FsyncPolicy string
+
+const (
+	// FsyncAlways flushes after every append, no acknowledged record is lost on power failure.
+	FsyncAlways FsyncPolicy = "always"
+	// FsyncInterval flushes periodically, records appended since the last flush may be lost on power failure.
+	FsyncInterval FsyncPolicy = "interval"
+	// FsyncNever leaves flushing to the operating system.
+	FsyncNever FsyncPolicy = "never"
+)
+
+const (
+	headerSize           = 8
+	maxRecordSize        = 256 << 20
+	segmentExt           = ".seg"
+	cursorFile           = "cursor"
+	defaultSegmentSize   = 64 << 20
+	defaultFsyncInterval = time.Second
+	dirPerm              = 0o750
+	filePerm             = 0o600
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// writeFile is replaced in tests to simulate failed writes.
+var writeFile = func(f *os.File, b []byte) (int, error) {
+	return f.Write(b)
+}
+
+// Config represents the configuration of the spool.
+type Config struct {
+	// Dir is the directory spooled events are written to, spooling is disabled when empty.
+	Dir string `yaml:"dir"`
+	// SegmentSize is the size in bytes after which a new segment file is started.
+	SegmentSize int64 `yaml:"segmentSize"`
+	// Fsync is the policy used for flushing appended records to disk.
+	Fsync FsyncPolicy `yaml:"fsync"`
+	// FsyncInterval is how often records are flushed when using the interval policy.
+	FsyncInterval time.Duration `yaml:"fsyncInterval"`
+}
+
+type cursor struct {
+	Segment uint64 `json:"segment"`
+	Offset  int64  `json:"offset"`
+}
+
+// Spool is a segmented append only queue of records stored on disk.
+type Spool struct {
+	mu  sync.Mutex
+	dir string
+	cfg Config
+
+	// segments are the ids of the segments on disk in ascending order, the last is being appended to
+	segments   []uint64
+	active     *os.File
+	activeSize int64
+	dirty      bool
+
+	cursorDirty bool
+
+	read       cursor
+	readFile   *os.File
+	peekedSize int64
+
+	closed bool
+	done   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// Open opens or creates a spool in the directory, recovering any records left by a previous process.
+func Open(dir string, cfg Config) (*Spool, error) {
+	if cfg.SegmentSize <= 0 {
+		cfg.SegmentSize = defaultSegmentSize
+	}
+	if cfg.FsyncInterval <= 0 {
+		cfg.FsyncInterval = defaultFsyncInterval
+	}
+	if cfg.Fsync == "" {
+		cfg.Fsync = FsyncInterval
+	}
+
+	if err := os.MkdirAll(dir, dirPerm); err != nil {
+		return nil, ErrOpen.Wrap(err)
+	}
+
+	s := &Spool{
+		dir:  dir,
+		cfg:  cfg,
+		done: make(chan struct{}),
+	}
+
+	if err := s.recover(); err != nil {
+		return nil, ErrOpen.Wrap(err)
+	}
+
+	if cfg.Fsync == FsyncInterval {
+		s.wg.Add(1)
+		go s.syncLoop()
+	}
+
+	return s, nil
+}
+
+// Append durably adds a record to the end of the spool according to the fsync policy.
+func (s *Spool) Append(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+
+	if s.active == nil || s.activeSize >= s.cfg.SegmentSize {
+		if err := s.rotate(); err != nil {
+			return ErrWrite.Wrap(err)
+		}
+	}
+
+	record := make([]byte, headerSize+len(data))
+	binary.BigEndian.PutUint32(record[0:4], uint32(len(data)))
+	binary.BigEndian.PutUint32(record[4:8], crc32.Checksum(data, crcTable))
+	copy(record[headerSize:], data)
+
+	if _, err := writeFile(s.active, record); err != nil {
+		// Remove any partially written record so later records aren't appended after a corrupt tail
+		if terr := s.truncateActive(); terr != nil {
+			return ErrWrite.Wrap(fmt.Errorf("%w: failed to truncate segment: %s", err, terr))
+		}
+		return ErrWrite.Wrap(err)
+	}
+	s.activeSize += int64(len(record))
+
+	s.dirty = true
+
+	if s.cfg.Fsync == FsyncAlways {
+		if err := s.sync(); err != nil {
+			return ErrWrite.Wrap(err)
+		}
+	}
+
+	return nil
+}
+
+// Peek returns the oldest record that hasn't been acknowledged without removing it.
+// ErrEmpty is returned when there are no records, ErrCorrupt is returned once for each corrupted
+// segment that is skipped and Peek can be called again to continue with the next segment.
+func (s *Spool) Peek() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, ErrClosed
+	}
+
+	for {
+		if len(s.segments) == 0 {
+			return nil, ErrEmpty
+		}
+
+		data, err := s.readRecord()
+		switch {
+		case err == nil:
+			s.peekedSize = int64(headerSize + len(data))
+			return data, nil
+		case errors.Is(err, io.EOF):
+			if s.read.Segment == s.tail() {
+				return nil, ErrEmpty
+			}
+			if err := s.dropReadSegment(); err != nil {
+				return nil, ErrRead.Wrap(err)
+			}
+		case errors.Is(err, ErrCorrupt):
+			if s.read.Segment == s.tail() {
+				// A torn write at the end of the tail is only possible if we crashed, recovery truncates it
+				return nil, ErrEmpty
+			}
+			if derr := s.dropReadSegment(); derr != nil {
+				return nil, ErrRead.Wrap(derr)
+			}
+			return nil, err
+		default:
+			return nil, ErrRead.Wrap(err)
+		}
+	}
+}
+
+// Ack removes the record returned by the last call to Peek.
+func (s *Spool) Ack() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+	if s.peekedSize == 0 {
+		return nil
+	}
+
+	s.read.Offset += s.peekedSize
+	s.peekedSize = 0
+
+	// Once everything has been replayed the segments are no longer needed
+	if s.read.Segment == s.tail() && s.read.Offset >= s.activeSize {
+		return s.reset()
+	}
+
+	// The cursor is persisted with the records when flushing periodically, at worst acknowledged records are replayed
+	// again after a crash
+	if s.cfg.Fsync == FsyncInterval {
+		s.cursorDirty = true
+		return nil
+	}
+
+	return s.writeCursor()
+}
+
+// Empty reports whether all records have been acknowledged.
+func (s *Spool) Empty() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.segments) == 0 {
+		return true
+	}
+
+	return s.read.Segment == s.tail() && s.read.Offset >= s.activeSize
+}
+
+// Close flushes and closes the spool, records not yet acknowledged are replayed when it is next opened.
+func (s *Spool) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	close(s.done)
+	s.mu.Unlock()
+
+	s.wg.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.sync()
+	if s.active != nil {
+		if cerr := s.active.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	if s.readFile != nil {
+		_ = s.readFile.Close()
+	}
+
+	return err
+}
+
+func (s *Spool) syncLoop() {
+	defer s.wg.Done()
+
+	t := time.NewTicker(s.cfg.FsyncInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-t.C:
+			s.mu.Lock()
+			_ = s.sync()
+			s.mu.Unlock()
+		}
+	}
+}
+
+func (s *Spool) sync() error {
+	if s.dirty && s.active != nil {
+		s.dirty = false
+		if err := s.active.Sync(); err != nil {
+			return err
+		}
+	}
+
+	if s.cursorDirty {
+		return s.writeCursor()
+	}
+
+	return nil
+}
+
+// truncateActive removes anything written to the active segment after the last complete record. If the segment
+// can't be truncated it is closed so the next record starts a new segment and replay skips the corrupt tail.
+func (s *Spool) truncateActive() error {
+	err := s.active.Truncate(s.activeSize)
+	if err == nil {
+		_, err = s.active.Seek(s.activeSize, io.SeekStart)
+	}
+	if err != nil {
+		_ = s.active.Close()
+		s.active = nil
+		s.activeSize = 0
+		s.dirty = false
+		return err
+	}
+
+	return nil
+}
+
+func (s *Spool) tail() uint64 {
+	return s.segments[len(s.segments)-1]
+}
+
+func (s *Spool) segmentPath(id uint64) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%016d%s", id, segmentExt))
+}
+
+// recover loads the segments and cursor from disk and truncates any torn write at the end of the tail segment.
+func (s *Spool) recover() error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), segmentExt) {
+			continue
+		}
+
+		id, err := strconv.ParseUint(strings.TrimSuffix(e.Name(), segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		s.segments = append(s.segments, id)
+	}
+	sort.Slice(s.segments, func(i, j int) bool { return s.segments[i] < s.segments[j] })
+
+	if len(s.segments) == 0 {
+		return nil
+	}
+
+	if err := s.readCursor(); err != nil {
+		return err
+	}
+
+	validSize, err := s.validSize(s.tail())
+	if err != nil {
+		return err
+	}
+
+	//nolint:gosec
+	f, err := os.OpenFile(s.segmentPath(s.tail()), os.O_WRONLY, filePerm)
+	if err != nil {
+		return err
+	}
+	if err := f.Truncate(validSize); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if _, err := f.Seek(validSize, io.SeekStart); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	s.active = f
+	s.activeSize = validSize
+
+	return nil
+}
+
+// validSize returns the offset after the last complete record with a valid checksum in the segment.
+func (s *Spool) validSize(id uint64) (int64, error) {
+	//nolint:gosec
+	f, err := os.Open(s.segmentPath(id))
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var offset int64
+	for {
+		data, err := readRecordAt(f, offset)
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, ErrCorrupt) {
+				return offset, nil
+			}
+			return 0, err
+		}
+		offset += int64(headerSize + len(data))
+	}
+}
+
+func (s *Spool) readCursor() error {
+	s.read = cursor{Segment: s.segments[0]}
+
+	data, err := os.ReadFile(filepath.Join(s.dir, cursorFile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	c := cursor{}
+	if err := json.Unmarshal(data, &c); err != nil {
+		// Replaying from the start of the oldest segment is always safe, if not optimal
+		return nil
+	}
+
+	if c.Segment >= s.segments[0] && c.Segment <= s.tail() {
+		s.read = c
+	}
+
+	return nil
+}
+
+// writeCursor persists the replay position, flushing it to disk unless the fsync policy leaves that to the operating
+// system.
+func (s *Spool) writeCursor() error {
+	data, err := json.Marshal(s.read)
+	if err != nil {
+		return ErrWrite.Wrap(err)
+	}
+
+	// Write then rename so a crash never leaves a partially written cursor behind
+	tmp := filepath.Join(s.dir, cursorFile+".tmp")
+	//nolint:gosec
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, filePerm)
+	if err != nil {
+		return ErrWrite.Wrap(err)
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return ErrWrite.Wrap(err)
+	}
+	if s.cfg.Fsync != FsyncNever {
+		if err := f.Sync(); err != nil {
+			_ = f.Close()
+			return ErrWrite.Wrap(err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		return ErrWrite.Wrap(err)
+	}
+	if err := os.Rename(tmp, filepath.Join(s.dir, cursorFile)); err != nil {
+		return ErrWrite.Wrap(err)
+	}
+
+	s.cursorDirty = false
+
+	if s.cfg.Fsync != FsyncNever {
+		if err := s.syncDir(); err != nil {
+			return ErrWrite.Wrap(err)
+		}
+	}
+
+	return nil
+}
+
+// syncDir flushes the spool directory so renames and removed files survive a power failure.
+func (s *Spool) syncDir() error {
+	//nolint:gosec
+	d, err := os.Open(s.dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
+
+func (s *Spool) rotate() error {
+	next := uint64(1)
+	if len(s.segments) > 0 {
+		next = s.tail() + 1
+	}
+
+	//nolint:gosec
+	f, err := os.OpenFile(s.segmentPath(next), os.O_CREATE|os.O_WRONLY|os.O_APPEND, filePerm)
+	if err != nil {
+		return err
+	}
+
+	if s.active != nil {
+		if err := s.active.Sync(); err != nil {
+			_ = f.Close()
+			return err
+		}
+		if err := s.active.Close(); err != nil {
+			_ = f.Close()
+			return err
+		}
+	}
+
+	if len(s.segments) == 0 {
+		s.read = cursor{Segment: next}
+	}
+
+	s.segments = append(s.segments, next)
+	s.active = f
+	s.activeSize = 0
+	s.dirty = false
+
+	return nil
+}
+
+// reset removes all segments once every record has been acknowledged.
+func (s *Spool) reset() error {
+	if s.active != nil {
+		if err := s.active.Close(); err != nil {
+			return err
+		}
+		s.active = nil
+	}
+	if s.readFile != nil {
+		_ = s.readFile.Close()
+		s.readFile = nil
+	}
+
+	for _, id := range s.segments {
+		if err := os.Remove(s.segmentPath(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	next := s.tail() + 1
+
+	s.segments = nil
+	s.activeSize = 0
+	s.dirty = false
+	s.cursorDirty = false
+	s.read = cursor{Segment: next}
+
+	if err := os.Remove(filepath.Join(s.dir, cursorFile)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Spool) dropReadSegment() error {
+	if s.readFile != nil {
+		_ = s.readFile.Close()
+		s.readFile = nil
+	}
+
+	if err := os.Remove(s.segmentPath(s.read.Segment)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	s.segments = s.segments[1:]
+	s.read = cursor{Segment: s.segments[0]}
+
+	return s.writeCursor()
+}
+
+func (s *Spool) readRecord() ([]byte, error) {
+	if s.readFile == nil || filepath.Base(s.readFile.Name()) != filepath.Base(s.segmentPath(s.read.Segment)) {
+		if s.readFile != nil {
+			_ = s.readFile.Close()
+		}
+
+		//nolint:gosec
+		f, err := os.Open(s.segmentPath(s.read.Segment))
+		if err != nil {
+			return nil, err
+		}
+		s.readFile = f
+	}
+
+	return readRecordAt(s.readFile, s.read.Offset)
+}
+
+func readRecordAt(r io.ReaderAt, offset int64) ([]byte, error) {
+	header := make([]byte, headerSize)
+	if n, err := r.ReadAt(header, offset); err != nil {
+		if errors.Is(err, io.EOF) {
+			if n == 0 {
+				return nil, io.EOF
+			}
+			return nil, ErrCorrupt
+		}
+		return nil, err
+	}
+
+	size := binary.BigEndian.Uint32(header[0:4])
+	sum := binary.BigEndian.Uint32(header[4:8])
+
+	if size > maxRecordSize {
+		return nil, ErrCorrupt
+	}
+
+	data := make([]byte, size)
+	if _, err := r.ReadAt(data, offset+headerSize); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, ErrCorrupt
+		}
+		return nil, err
+	}
+
+	if crc32.Checksum(data, crcTable) != sum {
+		return nil, ErrCorrupt
+	}
+
+	return data, nil
+}
@@ -0,0 +1,9 @@
+package spool
+
+import "os"
+
+func ExportSetWriteFile(fn func(f *os.File, b []byte) (int, error)) func() {
+	prev := writeFile
+	writeFile = fn
+	return func() { writeFile = prev }
+}
@@ -0,0 +1,251 @@
+package spool_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/speakeasy-api/rest-template-go/internal/events/spool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpool_ReplayInOrder(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         spool.Config
+		records     int
+		wantSegment int
+	}{
+		{
+			name:        "single segment",
+			cfg:         spool.Config{Fsync: spool.FsyncAlways},
+			records:     10,
+			wantSegment: 1,
+		},
+		{
+			name:        "rotates segments",
+			cfg:         spool.Config{Fsync: spool.FsyncNever, SegmentSize: 32},
+			records:     10,
+			wantSegment: 5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			s, err := spool.Open(dir, tt.cfg)
+			require.NoError(t, err)
+			defer s.Close()
+
+			assert.True(t, s.Empty())
+
+			for i := 0; i < tt.records; i++ {
+				require.NoError(t, s.Append([]byte(fmt.Sprintf("record-%02d", i))))
+			}
+
+			assert.False(t, s.Empty())
+			assert.Len(t, segments(t, dir), tt.wantSegment)
+
+			for i := 0; i < tt.records; i++ {
+				data, err := s.Peek()
+				require.NoError(t, err)
+				assert.Equal(t, fmt.Sprintf("record-%02d", i), string(data))
+				require.NoError(t, s.Ack())
+			}
+
+			_, err = s.Peek()
+			assert.ErrorIs(t, err, spool.ErrEmpty)
+			assert.True(t, s.Empty())
+			assert.Empty(t, segments(t, dir))
+		})
+	}
+}
+
+func TestSpool_SurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	cfg := spool.Config{Fsync: spool.FsyncInterval, SegmentSize: 32}
+
+	s, err := spool.Open(dir, cfg)
+	require.NoError(t, err)
+
+	for i := 0; i < 6; i++ {
+		require.NoError(t, s.Append([]byte(fmt.Sprintf("record-%02d", i))))
+	}
+
+	// Replay some records before "crashing"
+	for i := 0; i < 3; i++ {
+		_, err := s.Peek()
+		require.NoError(t, err)
+		require.NoError(t, s.Ack())
+	}
+	require.NoError(t, s.Close())
+
+	s, err = spool.Open(dir, cfg)
+	require.NoError(t, err)
+	defer s.Close()
+
+	require.NoError(t, s.Append([]byte("record-06")))
+
+	for i := 3; i < 7; i++ {
+		data, err := s.Peek()
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("record-%02d", i), string(data))
+		require.NoError(t, s.Ack())
+	}
+
+	assert.True(t, s.Empty())
+}
+
+func TestSpool_TornWrite(t *testing.T) {
+	dir := t.TempDir()
+	cfg := spool.Config{Fsync: spool.FsyncAlways}
+
+	s, err := spool.Open(dir, cfg)
+	require.NoError(t, err)
+	require.NoError(t, s.Append([]byte("record-00")))
+	require.NoError(t, s.Append([]byte("record-01")))
+	require.NoError(t, s.Close())
+
+	// Simulate a crash part way through writing the last record
+	segs := segments(t, dir)
+	require.Len(t, segs, 1)
+	info, err := os.Stat(segs[0])
+	require.NoError(t, err)
+	require.NoError(t, os.Truncate(segs[0], info.Size()-3))
+
+	s, err = spool.Open(dir, cfg)
+	require.NoError(t, err)
+	defer s.Close()
+
+	require.NoError(t, s.Append([]byte("record-02")))
+
+	for _, want := range []string{"record-00", "record-02"} {
+		data, err := s.Peek()
+		require.NoError(t, err)
+		assert.Equal(t, want, string(data))
+		require.NoError(t, s.Ack())
+	}
+
+	assert.True(t, s.Empty())
+}
+
+func TestSpool_ShortWrite(t *testing.T) {
+	dir := t.TempDir()
+	cfg := spool.Config{Fsync: spool.FsyncAlways}
+
+	s, err := spool.Open(dir, cfg)
+	require.NoError(t, err)
+
+	require.NoError(t, s.Append([]byte("record-00")))
+
+	// Simulate the disk filling up part way through writing a record
+	restore := spool.ExportSetWriteFile(func(f *os.File, b []byte) (int, error) {
+		n, _ := f.Write(b[:len(b)/2])
+		return n, errors.New("no space left on device")
+	})
+	err = s.Append([]byte("record-01"))
+	restore()
+	assert.ErrorIs(t, err, spool.ErrWrite)
+
+	require.NoError(t, s.Append([]byte("record-02")))
+
+	data, err := s.Peek()
+	require.NoError(t, err)
+	assert.Equal(t, "record-00", string(data))
+	require.NoError(t, s.Ack())
+	require.NoError(t, s.Close())
+
+	// Records appended after the failed write must also survive recovery
+	s, err = spool.Open(dir, cfg)
+	require.NoError(t, err)
+	defer s.Close()
+
+	require.NoError(t, s.Append([]byte("record-03")))
+
+	for _, want := range []string{"record-02", "record-03"} {
+		data, err := s.Peek()
+		require.NoError(t, err)
+		assert.Equal(t, want, string(data))
+		require.NoError(t, s.Ack())
+	}
+
+	assert.True(t, s.Empty())
+}
+
+func TestSpool_CursorPersistedOnClose(t *testing.T) {
+	dir := t.TempDir()
+	cfg := spool.Config{Fsync: spool.FsyncInterval, FsyncInterval: time.Hour}
+
+	s, err := spool.Open(dir, cfg)
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, s.Append([]byte(fmt.Sprintf("record-%02d", i))))
+	}
+
+	_, err = s.Peek()
+	require.NoError(t, err)
+	require.NoError(t, s.Ack())
+
+	// The cursor is only flushed with the records when using the interval policy
+	_, err = os.Stat(filepath.Join(dir, "cursor"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	require.NoError(t, s.Close())
+
+	s, err = spool.Open(dir, cfg)
+	require.NoError(t, err)
+	defer s.Close()
+
+	data, err := s.Peek()
+	require.NoError(t, err)
+	assert.Equal(t, "record-01", string(data))
+}
+
+func TestSpool_CorruptSegment(t *testing.T) {
+	dir := t.TempDir()
+	cfg := spool.Config{Fsync: spool.FsyncAlways, SegmentSize: 32}
+
+	s, err := spool.Open(dir, cfg)
+	require.NoError(t, err)
+	for i := 0; i < 4; i++ {
+		require.NoError(t, s.Append([]byte(fmt.Sprintf("record-%02d", i))))
+	}
+	require.NoError(t, s.Close())
+
+	// Flip a byte in the payload of the first record of the first segment
+	segs := segments(t, dir)
+	require.Len(t, segs, 2)
+	data, err := os.ReadFile(segs[0])
+	require.NoError(t, err)
+	data[10] ^= 0xff
+	require.NoError(t, os.WriteFile(segs[0], data, 0o600))
+
+	s, err = spool.Open(dir, cfg)
+	require.NoError(t, err)
+	defer s.Close()
+
+	_, err = s.Peek()
+	assert.ErrorIs(t, err, spool.ErrCorrupt)
+
+	for _, want := range []string{"record-02", "record-03"} {
+		data, err := s.Peek()
+		require.NoError(t, err)
+		assert.Equal(t, want, string(data))
+		require.NoError(t, s.Ack())
+	}
+
+	assert.True(t, s.Empty())
+}
+
+func segments(t *testing.T, dir string) []string {
+	t.Helper()
+
+	segs, err := filepath.Glob(filepath.Join(dir, "*.seg"))
+	require.NoError(t, err)
+
+	return segs
+}
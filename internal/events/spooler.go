@@ -0,0 +1,142 @@
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	"github.com/speakeasy-api/rest-template-go/internal/core/logging"
+	"github.com/speakeasy-api/rest-template-go/internal/events/spool"
+	"go.uber.org/zap"
+)
+
+var replayInterval = time.Second
+
+// spooler buffers events for a destination in its spool while the sink is unhealthy
+// and replays them in order once the sink recovers.
+type spooler struct {
+	d Destination
+
+	mu       sync.Mutex
+	spooling bool
+	wake     chan struct{}
+}
+
+func newSpooler(d Destination) *spooler {
+	return &spooler{
+		d: d,
+		// Events left over from a previous run need replaying before new events can be delivered
+		spooling: !d.Spool.Empty(),
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// appendIfSpooling will spool the message if the sink is currently unhealthy.
+func (s *spooler) appendIfSpooling(ctx context.Context, msg Message) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.spooling {
+		return false
+	}
+
+	return s.appendLocked(ctx, msg)
+}
+
+// append will mark the sink as unhealthy and spool the message.
+func (s *spooler) append(ctx context.Context, msg Message) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.spooling {
+		logging.From(ctx).Warn("sink unhealthy, spooling events to disk")
+	}
+	s.spooling = true
+
+	ok := s.appendLocked(ctx, msg)
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+
+	return ok
+}
+
+func (s *spooler) appendLocked(ctx context.Context, msg Message) bool {
+	data, err := EncodeEnvelope(msg)
+	if err != nil {
+		logging.From(ctx).Error("failed to encode event for spool", zap.Error(err))
+		return false
+	}
+
+	if err := s.d.Spool.Append(data); err != nil {
+		logging.From(ctx).Error("failed to spool event", zap.Error(err))
+		return false
+	}
+
+	return true
+}
+
+func (s *spooler) run(ctx context.Context) {
+	ctx = logging.WithFields(ctx, zap.String("sink", s.d.Name))
+
+	t := time.NewTicker(replayInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-t.C:
+		}
+
+		s.replay(ctx)
+	}
+}
+
+// replay publishes spooled events in order until the spool is drained or the sink fails again.
+func (s *spooler) replay(ctx context.Context) {
+	for ctx.Err() == nil {
+		data, err := s.d.Spool.Peek()
+		switch {
+		case errors.Is(err, spool.ErrEmpty):
+			s.mu.Lock()
+			// Checked under the lock so no event can be appended after we decide the spool is drained
+			if s.spooling && s.d.Spool.Empty() {
+				s.spooling = false
+				logging.From(ctx).Info("sink recovered, spooled events replayed")
+			}
+			s.mu.Unlock()
+			return
+		case errors.Is(err, spool.ErrCorrupt):
+			logging.From(ctx).Error("skipped corrupt spool segment, events have been lost", zap.Error(err))
+			continue
+		case err != nil:
+			logging.From(ctx).Error("failed to read from spool", zap.Error(err))
+			return
+		}
+
+		msg, err := DecodeEnvelope(data)
+		if err != nil {
+			logging.From(ctx).Error("dropping undecodable spooled event", zap.Error(err))
+			_ = s.d.Spool.Ack()
+			continue
+		}
+
+		if err := s.d.Sink.Publish(ctx, msg); err != nil {
+			// The sink is still unhealthy so try again on the next tick
+			logging.From(ctx).Debug("sink still unhealthy", zap.Error(err), zap.String("event_id", msg.ID))
+			return
+		}
+
+		producedTotal.WithLabelValues(string(msg.Topic), s.d.Name).Inc()
+
+		if err := s.d.Spool.Ack(); err != nil {
+			logging.From(ctx).Error("failed to acknowledge spooled event", zap.Error(err), zap.String("event_id", msg.ID))
+			return
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	"github.com/speakeasy-api/rest-template-go/internal/events"
+)
+
+const (
+	// ErrInvalidID is returned when the ID is not a valid UUID or is empty.
+	ErrInvalidID = errors.Error("invalid_id: id is invalid")
+	// ErrDeadLetterNotFound is returned when a dead letter can't be found.
+	ErrDeadLetterNotFound = errors.Error("dead_letter_not_found: dead letter wasn't found")
+)
+
+const (
+	pqErrInvalidTextRepresentation = "invalid_text_representation"
+)
+
+// DB represents a type for interfacing with a postgres database.
+type DB interface {
+	NamedQueryContext(ctx context.Context, query string, arg interface{}) (*sqlx.Rows, error)
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// Store provides functionality for persisting events in a postgres database.
+type Store struct {
+	db DB
+}
+
+// New will instantiate a new instance of Store.
+func New(db DB) *Store {
+	return &Store{
+		db: db,
+	}
+}
+
+// InsertDeadLetter will persist an event that failed to publish.
+func (s *Store) InsertDeadLetter(ctx context.Context, dl *events.DeadLetter) (*events.DeadLetter, error) {
+	res, err := s.db.NamedQueryContext(ctx,
+		`INSERT INTO 
+		dead_letters(event_id, topic, sink, key, content_type, headers, payload, error, attempts, produced_at, first_failed_at, last_failed_at) 
+		VALUES (:event_id, :topic, :sink, :key, :content_type, :headers, :payload, :error, :attempts, :produced_at, :first_failed_at, :last_failed_at) 
+		RETURNING *`, dl)
+	if err != nil {
+		return nil, errors.ErrUnknown.Wrap(err)
+	}
+	defer res.Close()
+
+	if !res.Next() {
+		return nil, errors.ErrUnknown
+	}
+
+	created := &events.DeadLetter{}
+
+	if err := res.StructScan(created); err != nil {
+		return nil, errors.ErrUnknown.Wrap(err)
+	}
+
+	return created, nil
+}
+
+// GetDeadLetter will retrieve a dead letter via its ID.
+func (s *Store) GetDeadLetter(ctx context.Context, id string) (*events.DeadLetter, error) {
+	var dl events.DeadLetter
+
+	if err := s.db.GetContext(ctx, &dl, "SELECT * FROM dead_letters WHERE id = $1", id); err != nil {
+		return nil, checkReadError(err)
+	}
+
+	return &dl, nil
+}
+
+// FindDeadLetters will retrieve the dead letters matching the filter ordered by when they first failed,
+// using pagination if limit is gt 0.
+func (s *Store) FindDeadLetters(ctx context.Context, filter events.DeadLetterFilter, offset, limit int64) ([]*events.DeadLetter, error) {
+	where, values := filterClause(filter)
+
+	limitClause := ""
+
+	if limit > 0 {
+		limitClause = fmt.Sprintf(" LIMIT %d OFFSET %d", limit, offset)
+	}
+
+	dls := []*events.DeadLetter{}
+
+	if err := s.db.SelectContext(ctx, &dls, fmt.Sprintf("SELECT * FROM dead_letters%s ORDER BY first_failed_at ASC, id ASC%s", where, limitClause), values...); err != nil {
+		return nil, errors.ErrUnknown.Wrap(err)
+	}
+
+	if len(dls) == 0 {
+		return nil, errors.ErrNotFound
+	}
+
+	return dls, nil
+}
+
+// UpdateDeadLetter will record another failed attempt to publish a dead letter.
+func (s *Store) UpdateDeadLetter(ctx context.Context, dl *events.DeadLetter) (*events.DeadLetter, error) {
+	if dl.ID == nil || *dl.ID == "" {
+		return nil, ErrInvalidID.Wrap(errors.ErrValidation)
+	}
+
+	res, err := s.db.NamedQueryContext(ctx,
+		`UPDATE dead_letters 
+		SET 
+		error = :error, 
+		attempts = :attempts, 
+		last_failed_at = :last_failed_at 
+		WHERE id = :id
+		RETURNING *`, dl)
+	if err != nil {
+		return nil, checkReadError(err)
+	}
+	defer res.Close()
+
+	if !res.Next() {
+		return nil, ErrDeadLetterNotFound.Wrap(errors.ErrNotFound)
+	}
+
+	updated := &events.DeadLetter{}
+
+	if err := res.StructScan(updated); err != nil {
+		return nil, errors.ErrUnknown.Wrap(err)
+	}
+
+	return updated, nil
+}
+
+// DeleteDeadLetter will remove a dead letter via its ID.
+func (s *Store) DeleteDeadLetter(ctx context.Context, id string) error {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM dead_letters WHERE id = $1", id)
+	if err != nil {
+		return checkReadError(err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return errors.ErrUnknown.Wrap(err)
+	}
+	if rows != 1 {
+		return ErrDeadLetterNotFound.Wrap(errors.ErrNotFound)
+	}
+
+	return nil
+}
+
+// DeleteDeadLetters will remove all dead letters matching the filter returning the number removed.
+func (s *Store) DeleteDeadLetters(ctx context.Context, filter events.DeadLetterFilter) (int64, error) {
+	where, values := filterClause(filter)
+
+	res, err := s.db.ExecContext(ctx, fmt.Sprintf("DELETE FROM dead_letters%s", where), values...)
+	if err != nil {
+		return 0, errors.ErrUnknown.Wrap(err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.ErrUnknown.Wrap(err)
+	}
+
+	return rows, nil
+}
+
+// CountDeadLetters will return the number of dead letters for each topic and sink.
+func (s *Store) CountDeadLetters(ctx context.Context) ([]events.DeadLetterCount, error) {
+	counts := []events.DeadLetterCount{}
+
+	if err := s.db.SelectContext(ctx, &counts, "SELECT topic, sink, COUNT(*) AS count FROM dead_letters GROUP BY topic, sink"); err != nil {
+		return nil, errors.ErrUnknown.Wrap(err)
+	}
+
+	return counts, nil
+}
+
+// ClaimProcessing will record that the consumer is handling the event until the claim expires, returning false if
+// the event has already been processed or is claimed by another instance. Claiming is a single statement so
+// instances sharing a consumer name can't both claim an event.
+func (s *Store) ClaimProcessing(ctx context.Context, consumer, eventID string, ttl time.Duration) (bool, error) {
+	var claimed bool
+	err := s.db.GetContext(ctx, &claimed,
+		`INSERT INTO 
+		processed_events(consumer, event_id, claimed_until) 
+		VALUES ($1, $2, now() + make_interval(secs => $3)) 
+		ON CONFLICT (consumer, event_id) DO UPDATE 
+		SET claimed_until = EXCLUDED.claimed_until 
+		WHERE processed_events.processed_at IS NULL AND processed_events.claimed_until < now() 
+		RETURNING true`, consumer, eventID, ttl.Seconds())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, errors.ErrUnknown.Wrap(err)
+	}
+
+	return claimed, nil
+}
+
+// MarkProcessed will record that the consumer has processed the event, marking an event already processed is a noop.
+func (s *Store) MarkProcessed(ctx context.Context, consumer, eventID string) error {
+	if _, err := s.db.ExecContext(ctx,
+		`INSERT INTO 
+		processed_events(consumer, event_id, claimed_until, processed_at) 
+		VALUES ($1, $2, now(), now()) 
+		ON CONFLICT (consumer, event_id) DO UPDATE 
+		SET processed_at = COALESCE(processed_events.processed_at, EXCLUDED.processed_at)`, consumer, eventID); err != nil {
+		return errors.ErrUnknown.Wrap(err)
+	}
+
+	return nil
+}
+
+// ReleaseProcessing will remove the consumer's claim on an event it failed to process so it can be handled again
+// when redelivered, releasing a processed event is a noop.
+func (s *Store) ReleaseProcessing(ctx context.Context, consumer, eventID string) error {
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM processed_events WHERE consumer = $1 AND event_id = $2 AND processed_at IS NULL", consumer, eventID); err != nil {
+		return errors.ErrUnknown.Wrap(err)
+	}
+
+	return nil
+}
+
+func filterClause(filter events.DeadLetterFilter) (string, []interface{}) {
+	whereClauses := []string{}
+	values := []interface{}{}
+
+	if filter.Topic != "" {
+		values = append(values, filter.Topic)
+		whereClauses = append(whereClauses, fmt.Sprintf("topic = $%d", len(values)))
+	}
+	if filter.Sink != "" {
+		values = append(values, filter.Sink)
+		whereClauses = append(whereClauses, fmt.Sprintf("sink = $%d", len(values)))
+	}
+	if filter.FailedBefore != nil {
+		values = append(values, *filter.FailedBefore)
+		whereClauses = append(whereClauses, fmt.Sprintf("first_failed_at < $%d", len(values)))
+	}
+
+	if len(whereClauses) == 0 {
+		return "", values
+	}
+
+	return " WHERE " + strings.Join(whereClauses, " AND "), values
+}
+
+func checkReadError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrDeadLetterNotFound.Wrap(errors.ErrNotFound.Wrap(err))
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		if pqErr.Code.Name() == pqErrInvalidTextRepresentation && strings.Contains(pqErr.Error(), "uuid") {
+			return ErrInvalidID.Wrap(errors.ErrValidation.Wrap(err))
+		}
+	}
+
+	return errors.ErrUnknown.Wrap(err)
+}
@@ -0,0 +1,229 @@
+// Package stream fans out user events published over postgres NOTIFY to live subscribers,
+// keeping a bounded buffer of recent events so subscribers can resume after reconnecting.
+package stream
+
+import (
+	"context"
+	"sync"
+
+	"github.com/lib/pq"
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	"github.com/speakeasy-api/rest-template-go/internal/core/logging"
+	"github.com/speakeasy-api/rest-template-go/internal/events"
+	"go.uber.org/zap"
+)
+
+const (
+	// ErrListen is returned when we cannot start listening for event notifications.
+	ErrListen = errors.Error("listen_failed: failed to listen for event notifications")
+)
+
+const (
+	defaultBufferSize    = 1000
+	subscriberBufferSize = 100
+)
+
+// Config represents the configuration of the event stream.
+type Config struct {
+	// BufferSize is the number of recent events kept for subscribers resuming from a previous event.
+	BufferSize int `yaml:"bufferSize"`
+}
+
+// Listener represents a type that can receive postgres notifications such as a pq.Listener.
+type Listener interface {
+	Listen(channel string) error
+	NotificationChannel() <-chan *pq.Notification
+	Close() error
+}
+
+// Event represents a user event delivered to subscribers of the stream.
+type Event struct {
+	ID        string
+	UserEvent events.UserEvent
+}
+
+// Filter is used to select the events a subscriber receives, empty fields match all events.
+type Filter struct {
+	EventTypes []events.EventType
+	IDs        []string
+}
+
+// Match returns true if the event matches the filter.
+func (f Filter) Match(e events.UserEvent) bool {
+	return matchAny(f.EventTypes, e.EventType) && matchAny(f.IDs, e.ID)
+}
+
+func matchAny[T comparable](values []T, v T) bool {
+	if len(values) == 0 {
+		return true
+	}
+
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+type subscriber struct {
+	filter Filter
+	events chan Event
+}
+
+// Hub receives user events from postgres and broadcasts them to subscribers.
+type Hub struct {
+	listener    Listener
+	bufferSize  int
+	mu          sync.Mutex
+	buffer      []Event
+	subscribers map[*subscriber]struct{}
+}
+
+// New will instantiate a new instance of Hub receiving notifications from the listener.
+func New(cfg Config, l Listener) *Hub {
+	bufferSize := cfg.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = defaultBufferSize
+	}
+
+	return &Hub{
+		listener:    l,
+		bufferSize:  bufferSize,
+		subscribers: map[*subscriber]struct{}{},
+	}
+}
+
+// Listen will receive user events from postgres and broadcast them to subscribers until the context is done.
+func (h *Hub) Listen(ctx context.Context) error {
+	channel := events.NotifyChannel(events.TopicUsers)
+
+	if err := h.listener.Listen(channel); err != nil {
+		return ErrListen.Wrap(err)
+	}
+
+	logging.From(ctx).Info("listening for events", zap.String("channel", channel))
+
+	for {
+		select {
+		case <-ctx.Done():
+			return h.listener.Close()
+		case n, ok := <-h.listener.NotificationChannel():
+			if !ok {
+				return nil
+			}
+
+			// A nil notification is sent after the connection is re-established, anything sent in between is lost
+			if n == nil {
+				logging.From(ctx).Warn("reconnected to postgres, events may have been missed")
+				continue
+			}
+
+			e, err := decode(n.Extra)
+			if err != nil {
+				logging.From(ctx).Error("failed to decode event notification", zap.Error(err))
+				continue
+			}
+
+			h.broadcast(e)
+		}
+	}
+}
+
+// Subscribe will return a channel receiving the events matching the filter. If lastEventID is still in the
+// buffer the events following it are delivered first, otherwise only new events are delivered.
+// The channel is closed when the context is done or if the subscriber falls too far behind,
+// in which case it should subscribe again from the last event it received.
+func (h *Hub) Subscribe(ctx context.Context, lastEventID string, filter Filter) <-chan Event {
+	h.mu.Lock()
+
+	replay := []Event{}
+	for _, e := range h.since(lastEventID) {
+		if filter.Match(e.UserEvent) {
+			replay = append(replay, e)
+		}
+	}
+
+	s := &subscriber{
+		filter: filter,
+		events: make(chan Event, len(replay)+subscriberBufferSize),
+	}
+	for _, e := range replay {
+		s.events <- e
+	}
+
+	h.subscribers[s] = struct{}{}
+
+	h.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		h.unsubscribe(s)
+	}()
+
+	return s.events
+}
+
+func (h *Hub) broadcast(e Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.buffer = append(h.buffer, e)
+	if len(h.buffer) > h.bufferSize {
+		h.buffer = h.buffer[len(h.buffer)-h.bufferSize:]
+	}
+
+	for s := range h.subscribers {
+		if !s.filter.Match(e.UserEvent) {
+			continue
+		}
+
+		select {
+		case s.events <- e:
+		default:
+			// Don't let a slow subscriber hold up everyone else, it can resume from the buffer
+			delete(h.subscribers, s)
+			close(s.events)
+		}
+	}
+}
+
+func (h *Hub) unsubscribe(s *subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.subscribers[s]; ok {
+		delete(h.subscribers, s)
+		close(s.events)
+	}
+}
+
+// since returns the buffered events following the event with the given ID, h.mu must be held.
+func (h *Hub) since(id string) []Event {
+	if id == "" {
+		return nil
+	}
+
+	for i := len(h.buffer) - 1; i >= 0; i-- {
+		if h.buffer[i].ID == id {
+			return h.buffer[i+1:]
+		}
+	}
+
+	return nil
+}
+
+func decode(payload string) (Event, error) {
+	msg, err := events.DecodeEnvelope([]byte(payload))
+	if err != nil {
+		return Event{}, err
+	}
+
+	ue, err := events.DecodeUserEvent(msg)
+	if err != nil {
+		return Event{}, err
+	}
+
+	return Event{ID: msg.ID, UserEvent: ue}, nil
+}
@@ -0,0 +1,145 @@
+package stream_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/speakeasy-api/rest-template-go/internal/events"
+	"github.com/speakeasy-api/rest-template-go/internal/events/stream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testListener struct {
+	channel       string
+	notifications chan *pq.Notification
+}
+
+func (l *testListener) Listen(channel string) error {
+	l.channel = channel
+	return nil
+}
+
+func (l *testListener) NotificationChannel() <-chan *pq.Notification {
+	return l.notifications
+}
+
+func (l *testListener) Close() error {
+	return nil
+}
+
+func (l *testListener) notify(t *testing.T, id string, eventType events.EventType, userID string) {
+	t.Helper()
+
+	payload, err := json.Marshal(events.UserEvent{EventType: eventType, ID: userID})
+	require.NoError(t, err)
+
+	data, err := json.Marshal(events.Message{ID: id, Topic: events.TopicUsers, ContentType: "application/json", Payload: payload})
+	require.NoError(t, err)
+
+	l.notifications <- &pq.Notification{Channel: "events_users", Extra: string(data)}
+}
+
+func TestHub_Subscribe(t *testing.T) {
+	tests := []struct {
+		name        string
+		bufferSize  int
+		lastEventID string
+		filter      stream.Filter
+		wantIDs     []string
+	}{
+		{
+			name:    "all events",
+			wantIDs: []string{"4", "5"},
+		},
+		{
+			name:        "resumes from last event",
+			lastEventID: "2",
+			wantIDs:     []string{"3", "4", "5"},
+		},
+		{
+			name:        "unknown last event",
+			lastEventID: "unknown",
+			wantIDs:     []string{"4", "5"},
+		},
+		{
+			name:        "last event no longer buffered",
+			bufferSize:  1,
+			lastEventID: "2",
+			wantIDs:     []string{"4", "5"},
+		},
+		{
+			name:        "filters by event type",
+			lastEventID: "1",
+			filter:      stream.Filter{EventTypes: []events.EventType{events.EventTypeUserUpdated}},
+			wantIDs:     []string{"3", "5"},
+		},
+		{
+			name:        "filters by user ID",
+			lastEventID: "1",
+			filter:      stream.Filter{IDs: []string{"some-user-id"}},
+			wantIDs:     []string{"2", "3", "5"},
+		},
+		{
+			name:        "filters by event type and user ID",
+			lastEventID: "1",
+			filter:      stream.Filter{EventTypes: []events.EventType{events.EventTypeUserUpdated}, IDs: []string{"some-user-id"}},
+			wantIDs:     []string{"3", "5"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			l := &testListener{notifications: make(chan *pq.Notification)}
+			h := stream.New(stream.Config{BufferSize: tt.bufferSize}, l)
+
+			go func() {
+				_ = h.Listen(ctx)
+			}()
+
+			// Wait for the events to be buffered before subscribing with the filter under test
+			buffered := h.Subscribe(ctx, "", stream.Filter{})
+			l.notify(t, "1", events.EventTypeUserCreated, "some-user-id")
+			l.notify(t, "2", events.EventTypeUserCreated, "some-user-id")
+			l.notify(t, "3", events.EventTypeUserUpdated, "some-user-id")
+			for i := 0; i < 3; i++ {
+				<-buffered
+			}
+
+			assert.Equal(t, "events_users", l.channel)
+
+			sub := h.Subscribe(ctx, tt.lastEventID, tt.filter)
+
+			l.notify(t, "4", events.EventTypeUserCreated, "some-other-user-id")
+			l.notify(t, "5", events.EventTypeUserUpdated, "some-user-id")
+
+			gotIDs := []string{}
+			for len(gotIDs) < len(tt.wantIDs) {
+				select {
+				case e := <-sub:
+					gotIDs = append(gotIDs, e.ID)
+				case <-time.After(time.Second):
+					require.FailNow(t, "timed out waiting for events", "got %v", gotIDs)
+				}
+			}
+			assert.Equal(t, tt.wantIDs, gotIDs)
+
+			cancel()
+
+			// The subscription is closed once the context is done
+			assert.Eventually(t, func() bool {
+				select {
+				case _, ok := <-sub:
+					return !ok
+				default:
+					return false
+				}
+			}, time.Second, 10*time.Millisecond)
+		})
+	}
+}
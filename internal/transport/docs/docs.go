@@ -0,0 +1,90 @@
+// Package docs serves the OpenAPI document describing the REST API along with interactive documentation for it.
+package docs
+
+import (
+	"embed"
+	"encoding/json"
+	"io/fs"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ghodss/yaml"
+	"github.com/gorilla/mux"
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	"github.com/speakeasy-api/rest-template-go/internal/core/logging"
+	"github.com/speakeasy-api/rest-template-go/openapi"
+	"go.uber.org/zap"
+)
+
+// ErrLoadSpec is returned when the embedded OpenAPI document can't be loaded.
+const ErrLoadSpec = errors.Error("failed to load openapi spec")
+
+//go:embed assets/index.html assets/swagger-ui-bundle.js assets/swagger-ui.css assets/favicon-32x32.png
+var assets embed.FS
+
+// Config represents the configuration of the API docs.
+type Config struct {
+	// ServerURL is the URL clients reach the API on, it replaces the servers listed in the document when set.
+	ServerURL string `yaml:"serverURL"`
+}
+
+// Server represents a HTTP server that serves the API docs.
+type Server struct {
+	specJSON []byte
+	specYAML []byte
+	assets   fs.FS
+}
+
+// New will instantiate a new instance of Server, rendering the embedded OpenAPI document with the configured server URL.
+func New(cfg Config) (*Server, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(openapi.Spec)
+	if err != nil {
+		return nil, ErrLoadSpec.Wrap(err)
+	}
+
+	if cfg.ServerURL != "" {
+		doc.Servers = openapi3.Servers{{URL: cfg.ServerURL}}
+	}
+
+	specJSON, err := json.Marshal(doc)
+	if err != nil {
+		return nil, ErrLoadSpec.Wrap(err)
+	}
+
+	specYAML, err := yaml.JSONToYAML(specJSON)
+	if err != nil {
+		return nil, ErrLoadSpec.Wrap(err)
+	}
+
+	a, err := fs.Sub(assets, "assets")
+	if err != nil {
+		return nil, ErrLoadSpec.Wrap(err)
+	}
+
+	return &Server{
+		specJSON: specJSON,
+		specYAML: specYAML,
+		assets:   a,
+	}, nil
+}
+
+// AddRoutes will add the routes this server supports to the router.
+func (s *Server) AddRoutes(r *mux.Router) error {
+	r.HandleFunc("/openapi.json", s.serveSpec("application/json", s.specJSON)).Methods(http.MethodGet)
+	r.HandleFunc("/openapi.yaml", s.serveSpec("application/yaml", s.specYAML)).Methods(http.MethodGet)
+
+	r.Handle("/docs", http.RedirectHandler("/docs/", http.StatusMovedPermanently)).Methods(http.MethodGet)
+	r.PathPrefix("/docs/").Handler(http.StripPrefix("/docs/", http.FileServer(http.FS(s.assets)))).Methods(http.MethodGet)
+
+	return nil
+}
+
+func (s *Server) serveSpec(contentType string, data []byte) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+
+		if _, err := w.Write(data); err != nil {
+			logging.From(r.Context()).Error("failed to write openapi spec", zap.Error(err))
+		}
+	}
+}
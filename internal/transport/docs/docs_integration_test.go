@@ -0,0 +1,144 @@
+package docs_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/gorilla/mux"
+	"github.com/speakeasy-api/rest-template-go/internal/transport/docs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_Spec(t *testing.T) {
+	tests := []struct {
+		name            string
+		cfg             docs.Config
+		url             string
+		wantContentType string
+		wantServerURL   string
+	}{
+		{
+			name:            "json with configured server",
+			cfg:             docs.Config{ServerURL: "https://api.example.com"},
+			url:             "/openapi.json",
+			wantContentType: "application/json",
+			wantServerURL:   "https://api.example.com",
+		},
+		{
+			name:            "yaml with configured server",
+			cfg:             docs.Config{ServerURL: "https://api.example.com"},
+			url:             "/openapi.yaml",
+			wantContentType: "application/yaml",
+			wantServerURL:   "https://api.example.com",
+		},
+		{
+			name:            "keeps document servers when none configured",
+			url:             "/openapi.json",
+			wantContentType: "application/json",
+			wantServerURL:   "http://localhost:8080",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := docs.New(tt.cfg)
+			require.NoError(t, err)
+
+			r := mux.NewRouter()
+			require.NoError(t, s.AddRoutes(r))
+
+			req, err := http.NewRequest(http.MethodGet, tt.url, nil)
+			require.NoError(t, err)
+
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.wantContentType, w.Header().Get("Content-Type"))
+
+			// YAML is a superset of JSON so both formats can be read the same way
+			data, err := yaml.YAMLToJSON(w.Body.Bytes())
+			require.NoError(t, err)
+
+			var spec struct {
+				OpenAPI string `json:"openapi"`
+				Servers []struct {
+					URL string `json:"url"`
+				} `json:"servers"`
+			}
+			require.NoError(t, json.Unmarshal(data, &spec))
+
+			assert.Equal(t, "3.0.3", spec.OpenAPI)
+			require.Len(t, spec.Servers, 1)
+			assert.Equal(t, tt.wantServerURL, spec.Servers[0].URL)
+		})
+	}
+}
+
+func TestServer_Docs(t *testing.T) {
+	tests := []struct {
+		name            string
+		url             string
+		wantCode        int
+		wantContentType string
+		wantLocation    string
+	}{
+		{
+			name:         "redirects to index",
+			url:          "/docs",
+			wantCode:     http.StatusMovedPermanently,
+			wantLocation: "/docs/",
+		},
+		{
+			name:            "index",
+			url:             "/docs/",
+			wantCode:        http.StatusOK,
+			wantContentType: "text/html; charset=utf-8",
+		},
+		{
+			name:            "bundled script",
+			url:             "/docs/swagger-ui-bundle.js",
+			wantCode:        http.StatusOK,
+			wantContentType: "text/javascript; charset=utf-8",
+		},
+		{
+			name:            "bundled stylesheet",
+			url:             "/docs/swagger-ui.css",
+			wantCode:        http.StatusOK,
+			wantContentType: "text/css; charset=utf-8",
+		},
+		{
+			name:     "unknown asset",
+			url:      "/docs/unknown.js",
+			wantCode: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := docs.New(docs.Config{})
+			require.NoError(t, err)
+
+			r := mux.NewRouter()
+			require.NoError(t, s.AddRoutes(r))
+
+			req, err := http.NewRequest(http.MethodGet, tt.url, nil)
+			require.NoError(t, err)
+
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			if tt.wantContentType != "" {
+				assert.Equal(t, tt.wantContentType, w.Header().Get("Content-Type"))
+			}
+			if tt.wantLocation != "" {
+				assert.Equal(t, tt.wantLocation, w.Header().Get("Location"))
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// ErrTooComplex is returned when a query exceeds the maximum complexity.
+const ErrTooComplex = errors.Error("query_too_complex: query exceeds the maximum complexity")
+
+// paginatedFields are the fields returning a page of items, the cost of their selections is multiplied by the page size.
+var paginatedFields = map[string]bool{
+	"users": true,
+}
+
+// checkComplexity will return an error if the cost of the operation exceeds the maximum. Each field costs 1 and
+// the cost of the selections of a paginated field is multiplied by the number of items requested.
+func checkComplexity(query, operationName string, variables map[string]interface{}, maxComplexity int) error {
+	doc, err := parser.ParseQuery(&ast.Source{Input: query})
+	if err != nil {
+		// Invalid queries are reported by the executor with more detail
+		return nil
+	}
+
+	c := complexity{doc: doc, variables: variables}
+
+	for _, op := range doc.Operations {
+		if operationName != "" && op.Name != operationName {
+			continue
+		}
+
+		if cost := c.selectionSet(op.SelectionSet, map[string]bool{}); cost > maxComplexity {
+			return ErrTooComplex.Wrap(errors.New(fmt.Sprintf("complexity %d exceeds %d", cost, maxComplexity)))
+		}
+	}
+
+	return nil
+}
+
+type complexity struct {
+	doc       *ast.QueryDocument
+	variables map[string]interface{}
+}
+
+func (c complexity) selectionSet(set ast.SelectionSet, fragments map[string]bool) int {
+	cost := 0
+
+	for _, s := range set {
+		switch s := s.(type) {
+		case *ast.Field:
+			cost += 1 + c.pageSize(s)*c.selectionSet(s.SelectionSet, fragments)
+		case *ast.InlineFragment:
+			cost += c.selectionSet(s.SelectionSet, fragments)
+		case *ast.FragmentSpread:
+			f := c.doc.Fragments.ForName(s.Name)
+			// Cyclic fragments are rejected by validation so only need to be guarded against here
+			if f == nil || fragments[s.Name] {
+				continue
+			}
+
+			fragments[s.Name] = true
+			cost += c.selectionSet(f.SelectionSet, fragments)
+			delete(fragments, s.Name)
+		}
+	}
+
+	return cost
+}
+
+func (c complexity) pageSize(f *ast.Field) int {
+	if !paginatedFields[f.Name] {
+		return 1
+	}
+
+	arg := f.Arguments.ForName("first")
+	if arg == nil {
+		return defaultPageSize
+	}
+
+	switch arg.Value.Kind {
+	case ast.IntValue:
+		if n, err := strconv.Atoi(arg.Value.Raw); err == nil && n > 0 {
+			return n
+		}
+	case ast.Variable:
+		if n, ok := c.variables[arg.Value.Raw].(float64); ok && n > 0 {
+			return int(n)
+		}
+		if _, ok := c.variables[arg.Value.Raw]; !ok {
+			return defaultPageSize
+		}
+	}
+
+	return 1
+}
@@ -0,0 +1,68 @@
+package graphql
+
+import (
+	"context"
+
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	"github.com/speakeasy-api/rest-template-go/internal/core/logging"
+	"go.uber.org/zap"
+)
+
+const (
+	codeBadUserInput    = "BAD_USER_INPUT"
+	codeNotFound        = "NOT_FOUND"
+	codeConflict        = "CONFLICT"
+	codeUnauthenticated = "UNAUTHENTICATED"
+	codeForbidden       = "FORBIDDEN"
+	codeUnavailable     = "SERVICE_UNAVAILABLE"
+	codeRateLimited     = "RATE_LIMITED"
+	codeInternal        = "INTERNAL_SERVER_ERROR"
+)
+
+// resolverError is returned from resolvers so the error code is included in the extensions of the response.
+type resolverError struct {
+	message string
+	code    string
+}
+
+func (e *resolverError) Error() string {
+	return e.message
+}
+
+func (e *resolverError) Extensions() map[string]interface{} {
+	return map[string]interface{}{
+		"code": e.code,
+	}
+}
+
+func handleError(ctx context.Context, err error) error {
+	logging.From(ctx).Error("error occurred in resolver", zap.Error(err))
+
+	var code string
+
+	switch errors.CategoryOf(err) {
+	case errors.CategoryInvalidRequest, errors.CategoryValidation:
+		code = codeBadUserInput
+	case errors.CategoryNotFound:
+		code = codeNotFound
+	case errors.CategoryConflict:
+		code = codeConflict
+	case errors.CategoryUnauthenticated:
+		code = codeUnauthenticated
+	case errors.CategoryForbidden:
+		code = codeForbidden
+	case errors.CategoryUnavailable:
+		code = codeUnavailable
+	case errors.CategoryRateLimited:
+		code = codeRateLimited
+	case errors.CategoryUnknown:
+		fallthrough
+	default:
+		code = codeInternal
+	}
+
+	return &resolverError{
+		message: errors.PublicMessage(err),
+		code:    code,
+	}
+}
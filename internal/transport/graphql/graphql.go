@@ -0,0 +1,160 @@
+//go:generate mockgen -destination=./mocks/graphql_mock.go -package mocks github.com/speakeasy-api/rest-template-go/internal/transport/graphql Users
+
+// Package graphql provides a GraphQL endpoint over users allowing clients to fetch only the fields they need.
+package graphql
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	httplistener "github.com/speakeasy-api/rest-template-go/internal/core/listeners/http"
+	"github.com/speakeasy-api/rest-template-go/internal/core/logging"
+	"github.com/speakeasy-api/rest-template-go/internal/users/model"
+	"go.uber.org/zap"
+)
+
+const (
+	// ErrSchema is returned when the schema can't be parsed or doesn't match the resolvers.
+	ErrSchema = errors.Error("invalid graphql schema")
+	// ErrUnsupportedMediaType is returned when the request body isn't JSON.
+	ErrUnsupportedMediaType = errors.Error("unsupported_media_type: content type of request must be application/json")
+)
+
+const (
+	defaultMaxDepth      = 10
+	defaultMaxComplexity = 1000
+	mediaTypeJSON        = "application/json"
+)
+
+//go:embed schema.graphql
+var schema string
+
+// Config represents the configuration of the GraphQL endpoint.
+type Config struct {
+	// MaxDepth is the maximum depth of nested selections a query can have.
+	MaxDepth int `yaml:"maxDepth"`
+	// MaxComplexity is the maximum cost of a query, each field costs 1 multiplied by the page size of the lists it is in.
+	MaxComplexity int `yaml:"maxComplexity"`
+}
+
+// Users represents a type that can provide CRUD operations on users.
+type Users interface {
+	CreateUser(ctx context.Context, user *model.User) (*model.User, error)
+	GetUsers(ctx context.Context, ids []string) ([]*model.User, error)
+	FindUsers(ctx context.Context, filters []model.Filter, sort []model.Sort, offset, limit int64) ([]*model.User, error)
+	UpdateUser(ctx context.Context, user *model.User) (*model.User, error)
+	DeleteUser(ctx context.Context, id string) error
+}
+
+// Server represents a HTTP server that can handle GraphQL requests for this microservice.
+type Server struct {
+	schema        *graphql.Schema
+	users         Users
+	maxComplexity int
+}
+
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// New will instantiate a new instance of Server.
+func New(cfg Config, u Users) (*Server, error) {
+	if cfg.MaxDepth <= 0 {
+		cfg.MaxDepth = defaultMaxDepth
+	}
+	if cfg.MaxComplexity <= 0 {
+		cfg.MaxComplexity = defaultMaxComplexity
+	}
+
+	s, err := graphql.ParseSchema(schema, &resolver{users: u}, graphql.MaxDepth(cfg.MaxDepth))
+	if err != nil {
+		return nil, ErrSchema.Wrap(err)
+	}
+
+	return &Server{
+		schema:        s,
+		users:         u,
+		maxComplexity: cfg.MaxComplexity,
+	}, nil
+}
+
+// AddRoutes will add the routes this server supports to the router.
+func (s *Server) AddRoutes(r *mux.Router) error {
+	r.HandleFunc("/graphql", s.query).Methods(http.MethodPost)
+
+	return nil
+}
+
+func (s *Server) query(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	w.Header().Add("Content-Type", mediaTypeJSON)
+
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != mediaTypeJSON {
+		handleResponse(ctx, w, http.StatusUnsupportedMediaType, errorResponse(ErrUnsupportedMediaType))
+		return
+	}
+
+	// The body is limited to the maximum size of the listener while it is read
+	data, err := io.ReadAll(r.Body)
+	if errors.Is(err, httplistener.ErrBodyTooLarge) {
+		handleResponse(ctx, w, http.StatusRequestEntityTooLarge, errorResponse(httplistener.ErrBodyTooLarge))
+		return
+	}
+	if err != nil {
+		logging.From(ctx).Error("failed to read request body", zap.Error(err))
+		handleResponse(ctx, w, http.StatusInternalServerError, errorResponse(errors.ErrUnknown))
+		return
+	}
+
+	req := request{}
+
+	if err := json.Unmarshal(data, &req); err != nil {
+		logging.From(ctx).Error("failed to unmarshal json body", zap.Error(err))
+		handleResponse(ctx, w, http.StatusBadRequest, errorResponse(errors.ErrInvalidRequest))
+		return
+	}
+
+	ctx = logging.WithFields(ctx, zap.String("operation", req.OperationName))
+
+	// Rejected before executing so expensive queries never reach the database
+	if err := checkComplexity(req.Query, req.OperationName, req.Variables, s.maxComplexity); err != nil {
+		logging.From(ctx).Warn("rejected graphql query", zap.Error(err))
+		handleResponse(ctx, w, http.StatusOK, errorResponse(err))
+		return
+	}
+
+	// Loaders are scoped to the request so users are only cached for its lifetime
+	ctx = withUserLoader(ctx, newUserLoader(s.users))
+
+	handleResponse(ctx, w, http.StatusOK, s.schema.Exec(ctx, req.Query, req.OperationName, req.Variables))
+}
+
+func errorResponse(err error) *graphql.Response {
+	return &graphql.Response{Errors: []*gqlerrors.QueryError{{Message: err.Error()}}}
+}
+
+func handleResponse(ctx context.Context, w http.ResponseWriter, code int, res *graphql.Response) {
+	data, err := json.Marshal(res)
+	if err != nil {
+		logging.From(ctx).Error("failed to serialize graphql response", zap.Error(err))
+		code = http.StatusInternalServerError
+		data = []byte(`{"errors":[{"message":"internal server error"}]}`)
+	}
+
+	w.WriteHeader(code)
+
+	if _, err := w.Write(data); err != nil {
+		logging.From(ctx).Error("failed to write graphql response", zap.Error(err))
+	}
+}
@@ -0,0 +1,10 @@
+package graphql
+
+import "time"
+
+// ExportSetBatchWait sets how long batches wait for more ids, returning a func restoring the default.
+func ExportSetBatchWait(d time.Duration) func() {
+	prev := batchWait
+	batchWait = d
+	return func() { batchWait = prev }
+}
@@ -0,0 +1,403 @@
+package graphql_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AlekSi/pointer"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	httplistener "github.com/speakeasy-api/rest-template-go/internal/core/listeners/http"
+	graphqltransport "github.com/speakeasy-api/rest-template-go/internal/transport/graphql"
+	"github.com/speakeasy-api/rest-template-go/internal/transport/graphql/mocks"
+	"github.com/speakeasy-api/rest-template-go/internal/users/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	graphqlURL = "/graphql"
+	testID1    = "9cdd8ae2-15ab-40df-9c46-50f391e16f60"
+	testID2    = "3a9f3e8c-5b3b-4c1e-8f51-6b7e0a0f2d11"
+)
+
+func testUser(id, firstName string) *model.User {
+	return &model.User{
+		ID:        pointer.ToString(id),
+		FirstName: pointer.ToString(firstName),
+		Password:  pointer.ToString("test"),
+		Email:     pointer.ToString(firstName + "@test.com"),
+		CreatedAt: pointer.ToTime(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)),
+	}
+}
+
+func execute(t *testing.T, cfg graphqltransport.Config, u *mocks.MockUsers, query string, variables map[string]interface{}) (int, string) {
+	t.Helper()
+
+	s, err := graphqltransport.New(cfg, u)
+	require.NoError(t, err)
+
+	r := mux.NewRouter()
+	require.NoError(t, s.AddRoutes(r))
+
+	body, err := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPost, graphqlURL, bytes.NewBuffer(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	return w.Code, w.Body.String()
+}
+
+func TestServer_User(t *testing.T) {
+	defer graphqltransport.ExportSetBatchWait(10 * time.Millisecond)()
+
+	tests := []struct {
+		name     string
+		query    string
+		setup    func(u *mocks.MockUsers)
+		wantBody string
+	}{
+		{
+			name:  "success only returning requested fields",
+			query: `{ user(id: "` + testID1 + `") { id firstName createdAt } }`,
+			setup: func(u *mocks.MockUsers) {
+				u.EXPECT().GetUsers(gomock.Any(), []string{testID1}).Return([]*model.User{testUser(testID1, "first")}, nil).Times(1)
+			},
+			wantBody: `{"data":{"user":{"id":"` + testID1 + `","firstName":"first","createdAt":"2020-01-01T00:00:00Z"}}}`,
+		},
+		{
+			name: "batches lookups into a single call",
+			query: `{
+				a: user(id: "` + testID1 + `") { firstName }
+				b: user(id: "` + testID2 + `") { firstName }
+				c: user(id: "` + testID1 + `") { email }
+			}`,
+			setup: func(u *mocks.MockUsers) {
+				u.EXPECT().GetUsers(gomock.Any(), gomock.InAnyOrder([]string{testID1, testID2})).
+					Return([]*model.User{testUser(testID2, "second"), testUser(testID1, "first")}, nil).Times(1)
+			},
+			wantBody: `{"data":{"a":{"firstName":"first"},"b":{"firstName":"second"},"c":{"email":"first@test.com"}}}`,
+		},
+		{
+			name:  "returns null for missing user",
+			query: `{ user(id: "` + testID1 + `") { id } }`,
+			setup: func(u *mocks.MockUsers) {
+				u.EXPECT().GetUsers(gomock.Any(), []string{testID1}).Return([]*model.User{}, nil).Times(1)
+			},
+			wantBody: `{"data":{"user":null}}`,
+		},
+		{
+			name:     "returns null for invalid id without looking it up",
+			query:    `{ user(id: "invalid") { id } }`,
+			wantBody: `{"data":{"user":null}}`,
+		},
+		{
+			name:  "fails with unknown error",
+			query: `{ user(id: "` + testID1 + `") { id } }`,
+			setup: func(u *mocks.MockUsers) {
+				u.EXPECT().GetUsers(gomock.Any(), []string{testID1}).Return(nil, errors.ErrUnknown.Wrap(errors.New("connection refused"))).Times(1)
+			},
+			wantBody: `{"errors":[{"message":"err_unknown: unknown error occurred","path":["user"],"extensions":{"code":"INTERNAL_SERVER_ERROR"}}],"data":{"user":null}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			u := mocks.NewMockUsers(ctrl)
+			if tt.setup != nil {
+				tt.setup(u)
+			}
+
+			code, body := execute(t, graphqltransport.Config{}, u, tt.query, nil)
+			assert.Equal(t, http.StatusOK, code)
+			assert.JSONEq(t, tt.wantBody, body)
+		})
+	}
+}
+
+func TestServer_Users(t *testing.T) {
+	query := `query($after: String) {
+		users(filter: [{field: COUNTRY, matchType: EQUAL, value: "UK"}], sort: [{field: CREATED_AT, direction: DESC}], first: 2, after: $after) {
+			edges { cursor node { id } }
+			pageInfo { hasNextPage endCursor }
+		}
+	}`
+	filters := []model.Filter{{Field: model.FieldCountry, MatchType: model.MatchTypeEqual, Value: "UK"}}
+	sort := []model.Sort{{Field: model.FieldCreatedAt, Direction: model.SortDirectionDesc}}
+
+	tests := []struct {
+		name      string
+		variables map[string]interface{}
+		setup     func(u *mocks.MockUsers)
+		wantBody  string
+	}{
+		{
+			name: "first page with next page",
+			setup: func(u *mocks.MockUsers) {
+				u.EXPECT().FindUsers(gomock.Any(), filters, sort, int64(0), int64(3)).
+					Return([]*model.User{testUser(testID1, "a"), testUser(testID2, "b"), testUser(testID1, "c")}, nil).Times(1)
+			},
+			wantBody: `{"data":{"users":{"edges":[{"cursor":"Y3Vyc29yOjA=","node":{"id":"` + testID1 + `"}},{"cursor":"Y3Vyc29yOjE=","node":{"id":"` + testID2 + `"}}],"pageInfo":{"hasNextPage":true,"endCursor":"Y3Vyc29yOjE="}}}}`,
+		},
+		{
+			name:      "last page after cursor",
+			variables: map[string]interface{}{"after": "Y3Vyc29yOjE="},
+			setup: func(u *mocks.MockUsers) {
+				u.EXPECT().FindUsers(gomock.Any(), filters, sort, int64(2), int64(3)).
+					Return([]*model.User{testUser(testID1, "c")}, nil).Times(1)
+			},
+			wantBody: `{"data":{"users":{"edges":[{"cursor":"Y3Vyc29yOjI=","node":{"id":"` + testID1 + `"}}],"pageInfo":{"hasNextPage":false,"endCursor":"Y3Vyc29yOjI="}}}}`,
+		},
+		{
+			name: "empty page when no users match",
+			setup: func(u *mocks.MockUsers) {
+				u.EXPECT().FindUsers(gomock.Any(), filters, sort, int64(0), int64(3)).Return(nil, errors.ErrNotFound).Times(1)
+			},
+			wantBody: `{"data":{"users":{"edges":[],"pageInfo":{"hasNextPage":false,"endCursor":null}}}}`,
+		},
+		{
+			name:      "fails with invalid cursor",
+			variables: map[string]interface{}{"after": "not-a-cursor"},
+			wantBody:  `{"errors":[{"message":"invalid_cursor: cursor is invalid","path":["users"],"extensions":{"code":"BAD_USER_INPUT"}}],"data":null}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			u := mocks.NewMockUsers(ctrl)
+			if tt.setup != nil {
+				tt.setup(u)
+			}
+
+			code, body := execute(t, graphqltransport.Config{}, u, query, tt.variables)
+			assert.Equal(t, http.StatusOK, code)
+			assert.JSONEq(t, tt.wantBody, body)
+		})
+	}
+}
+
+func TestServer_Users_MissingFilter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Users are never listed without a filter so the search isn't reached
+	u := mocks.NewMockUsers(ctrl)
+
+	code, body := execute(t, graphqltransport.Config{}, u, `{ users(filter: []) { edges { node { id } } } }`, nil)
+	assert.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `{"errors":[{"message":"missing_filter: at least one filter is required","path":["users"],"extensions":{"code":"BAD_USER_INPUT"}}],"data":null}`, body)
+}
+
+func TestServer_Mutations(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		setup    func(u *mocks.MockUsers)
+		wantBody string
+	}{
+		{
+			name:  "create user",
+			query: `mutation { createUser(input: {firstName: "first", password: "test", email: "first@test.com"}) { id email } }`,
+			setup: func(u *mocks.MockUsers) {
+				u.EXPECT().CreateUser(gomock.Any(), &model.User{
+					FirstName: pointer.ToString("first"),
+					Password:  pointer.ToString("test"),
+					Email:     pointer.ToString("first@test.com"),
+				}).Return(testUser(testID1, "first"), nil).Times(1)
+			},
+			wantBody: `{"data":{"createUser":{"id":"` + testID1 + `","email":"first@test.com"}}}`,
+		},
+		{
+			name:  "create user fails validation",
+			query: `mutation { createUser(input: {firstName: "first"}) { id } }`,
+			setup: func(u *mocks.MockUsers) {
+				u.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Return(nil, errors.ErrValidation).Times(1)
+			},
+			wantBody: `{"errors":[{"message":"err_validation: failed validation","path":["createUser"],"extensions":{"code":"BAD_USER_INPUT"}}],"data":null}`,
+		},
+		{
+			name:  "update user",
+			query: `mutation { updateUser(id: "` + testID1 + `", input: {nickname: "nick"}) { id } }`,
+			setup: func(u *mocks.MockUsers) {
+				u.EXPECT().UpdateUser(gomock.Any(), &model.User{
+					ID:       pointer.ToString(testID1),
+					Nickname: pointer.ToString("nick"),
+				}).Return(testUser(testID1, "first"), nil).Times(1)
+			},
+			wantBody: `{"data":{"updateUser":{"id":"` + testID1 + `"}}}`,
+		},
+		{
+			name:  "delete user",
+			query: `mutation { deleteUser(id: "` + testID1 + `") }`,
+			setup: func(u *mocks.MockUsers) {
+				u.EXPECT().DeleteUser(gomock.Any(), testID1).Return(nil).Times(1)
+			},
+			wantBody: `{"data":{"deleteUser":true}}`,
+		},
+		{
+			name:  "delete user fails with not found",
+			query: `mutation { deleteUser(id: "` + testID1 + `") }`,
+			setup: func(u *mocks.MockUsers) {
+				u.EXPECT().DeleteUser(gomock.Any(), testID1).Return(errors.ErrNotFound).Times(1)
+			},
+			wantBody: `{"errors":[{"message":"err_not_found: not found","path":["deleteUser"],"extensions":{"code":"NOT_FOUND"}}],"data":null}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			u := mocks.NewMockUsers(ctrl)
+			tt.setup(u)
+
+			code, body := execute(t, graphqltransport.Config{}, u, tt.query, nil)
+			assert.Equal(t, http.StatusOK, code)
+			assert.JSONEq(t, tt.wantBody, body)
+		})
+	}
+}
+
+func TestServer_Limits(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       graphqltransport.Config
+		query     string
+		variables map[string]interface{}
+		wantErr   string
+	}{
+		{
+			name:    "rejects queries deeper than the max depth",
+			cfg:     graphqltransport.Config{MaxDepth: 2},
+			query:   `{ users(filter: []) { edges { node { id } } } }`,
+			wantErr: "exceeds max depth",
+		},
+		{
+			name:    "rejects queries more complex than the max complexity",
+			cfg:     graphqltransport.Config{MaxComplexity: 100},
+			query:   `{ users(filter: [], first: 50) { edges { node { id email } } } }`,
+			wantErr: "query_too_complex: query exceeds the maximum complexity -- complexity 201 exceeds 100",
+		},
+		{
+			name:      "uses page size from variables for complexity",
+			cfg:       graphqltransport.Config{MaxComplexity: 100},
+			query:     `query($first: Int) { users(filter: [], first: $first) { edges { node { id email } } } }`,
+			variables: map[string]interface{}{"first": 50},
+			wantErr:   "query_too_complex",
+		},
+		{
+			name: "counts fragments towards complexity",
+			cfg:  graphqltransport.Config{MaxComplexity: 100},
+			query: `{ users(filter: []) { ...page } }
+				fragment page on UserConnection { edges { node { id email firstName lastName } } }`,
+			wantErr: "query_too_complex",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// No users are looked up for rejected queries
+			u := mocks.NewMockUsers(ctrl)
+
+			code, body := execute(t, tt.cfg, u, tt.query, tt.variables)
+			assert.Equal(t, http.StatusOK, code)
+
+			var res struct {
+				Errors []struct {
+					Message string `json:"message"`
+				} `json:"errors"`
+				Data json.RawMessage `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal([]byte(body), &res))
+			require.Len(t, res.Errors, 1)
+			assert.Contains(t, res.Errors[0].Message, tt.wantErr)
+			assert.Empty(t, res.Data)
+		})
+	}
+}
+
+func TestServer_InvalidRequest(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantCode    int
+		wantBody    string
+	}{
+		{
+			name:        "invalid json",
+			contentType: "application/json",
+			body:        "not json",
+			wantCode:    http.StatusBadRequest,
+			wantBody:    `{"errors":[{"message":"err_invalid_request: invalid request received"}]}`,
+		},
+		{
+			name:     "missing content type",
+			body:     `{"query":"{ user(id: \"1\") { id } }"}`,
+			wantCode: http.StatusUnsupportedMediaType,
+			wantBody: `{"errors":[{"message":"unsupported_media_type: content type of request must be application/json"}]}`,
+		},
+		{
+			name:        "unsupported content type",
+			contentType: "application/graphql",
+			body:        `{ user(id: "1") { id } }`,
+			wantCode:    http.StatusUnsupportedMediaType,
+			wantBody:    `{"errors":[{"message":"unsupported_media_type: content type of request must be application/json"}]}`,
+		},
+		{
+			name:        "body too large",
+			contentType: "application/json; charset=utf-8",
+			body:        `{"query":"` + strings.Repeat(" ", 256) + `{ user(id: \"1\") { id } }"}`,
+			wantCode:    http.StatusRequestEntityTooLarge,
+			wantBody:    `{"errors":[{"message":"body_too_large: request body is too large"}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s, err := graphqltransport.New(graphqltransport.Config{}, mocks.NewMockUsers(ctrl))
+			require.NoError(t, err)
+
+			r := mux.NewRouter()
+			require.NoError(t, s.AddRoutes(r))
+
+			// Hides the length of the body so it is only found to be too large once read
+			req, err := http.NewRequest(http.MethodPost, graphqlURL, io.MultiReader(bytes.NewBufferString(tt.body)))
+			require.NoError(t, err)
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+
+			w := httptest.NewRecorder()
+
+			// The listener limits the size of bodies before they reach the router
+			httplistener.NewBodyLimiter(256).Middleware(r).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.JSONEq(t, tt.wantBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package graphql
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/speakeasy-api/rest-template-go/internal/users/model"
+)
+
+type contextKey int
+
+const userLoaderKey contextKey = iota
+
+var (
+	// batchWait is how long a batch waits for more ids after the first before users are fetched.
+	batchWait = time.Millisecond
+	// maxBatchSize is the maximum number of users fetched at once.
+	maxBatchSize = 100
+)
+
+// userBatch represents the ids requested within the same batch and the users fetched for them.
+type userBatch struct {
+	ids   []string
+	done  chan struct{}
+	users map[string]*model.User
+	err   error
+}
+
+// userLoader batches the lookups of users by id made while resolving a query so they are fetched in a single
+// call instead of one per lookup. Users are cached so each id is only fetched once per request.
+type userLoader struct {
+	users Users
+
+	mu    sync.Mutex
+	batch *userBatch
+	cache map[string]*userBatch
+}
+
+func newUserLoader(u Users) *userLoader {
+	return &userLoader{
+		users: u,
+		cache: map[string]*userBatch{},
+	}
+}
+
+func withUserLoader(ctx context.Context, l *userLoader) context.Context {
+	return context.WithValue(ctx, userLoaderKey, l)
+}
+
+func userLoaderFrom(ctx context.Context) *userLoader {
+	l, _ := ctx.Value(userLoaderKey).(*userLoader)
+	return l
+}
+
+// Load will return the user with the id, or nil if they don't exist, once the batch it was added to has been fetched.
+func (l *userLoader) Load(ctx context.Context, id string) (*model.User, error) {
+	b := l.add(ctx, id)
+
+	select {
+	case <-b.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	return b.users[id], nil
+}
+
+func (l *userLoader) add(ctx context.Context, id string) *userBatch {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if b, ok := l.cache[id]; ok {
+		return b
+	}
+
+	b := l.batch
+	if b == nil {
+		b = &userBatch{done: make(chan struct{})}
+		l.batch = b
+
+		time.AfterFunc(batchWait, func() {
+			l.dispatch(ctx, b)
+		})
+	}
+
+	b.ids = append(b.ids, id)
+	l.cache[id] = b
+
+	// Full batches are fetched straight away and any more ids start a new batch
+	if len(b.ids) >= maxBatchSize {
+		l.batch = nil
+		go l.fetch(ctx, b)
+	}
+
+	return b
+}
+
+// dispatch will fetch the users in the batch if it wasn't already fetched for being full.
+func (l *userLoader) dispatch(ctx context.Context, b *userBatch) {
+	l.mu.Lock()
+	if l.batch != b {
+		l.mu.Unlock()
+		return
+	}
+	l.batch = nil
+	l.mu.Unlock()
+
+	l.fetch(ctx, b)
+}
+
+func (l *userLoader) fetch(ctx context.Context, b *userBatch) {
+	defer close(b.done)
+
+	users, err := l.users.GetUsers(ctx, b.ids)
+	if err != nil {
+		b.err = err
+		return
+	}
+
+	b.users = make(map[string]*model.User, len(users))
+	for _, u := range users {
+		if u.ID != nil {
+			b.users[*u.ID] = u
+		}
+	}
+}
@@ -0,0 +1,331 @@
+package graphql
+
+import (
+	"context"
+	"encoding/base64"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	"github.com/speakeasy-api/rest-template-go/internal/core/logging"
+	"github.com/speakeasy-api/rest-template-go/internal/users/model"
+	"go.uber.org/zap"
+)
+
+const (
+	// ErrInvalidCursor is returned when the after cursor wasn't returned by a previous page.
+	ErrInvalidCursor = errors.Error("invalid_cursor: cursor is invalid")
+	// ErrInvalidPageSize is returned when more users are requested than can be returned in a page.
+	ErrInvalidPageSize = errors.Error("invalid_page_size: first must be between 0 and 100")
+	// ErrMissingFilter is returned when users are queried without any filters, as listing every user isn't supported.
+	ErrMissingFilter = errors.Error("missing_filter: at least one filter is required")
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+	cursorPrefix    = "cursor:"
+)
+
+var filterFields = map[string]model.Field{
+	"FIRST_NAME": model.FieldFirstName,
+	"LAST_NAME":  model.FieldLastName,
+	"NICKNAME":   model.FieldNickname,
+	"EMAIL":      model.FieldEmail,
+	"COUNTRY":    model.FieldCountry,
+}
+
+var sortFields = map[string]model.Field{
+	"FIRST_NAME": model.FieldFirstName,
+	"LAST_NAME":  model.FieldLastName,
+	"NICKNAME":   model.FieldNickname,
+	"EMAIL":      model.FieldEmail,
+	"COUNTRY":    model.FieldCountry,
+	"CREATED_AT": model.FieldCreatedAt,
+	"UPDATED_AT": model.FieldUpdatedAt,
+}
+
+var matchTypes = map[string]model.MatchType{
+	"EQUAL": model.MatchTypeEqual,
+	"LIKE":  model.MatchTypeLike,
+}
+
+var sortDirections = map[string]model.SortDirection{
+	"ASC":  model.SortDirectionAsc,
+	"DESC": model.SortDirectionDesc,
+}
+
+type userFilterInput struct {
+	Field     string
+	MatchType string
+	Value     string
+}
+
+type userSortInput struct {
+	Field     string
+	Direction string
+}
+
+type userInput struct {
+	FirstName *string
+	LastName  *string
+	Nickname  *string
+	Password  *string
+	Email     *string
+	Country   *string
+}
+
+func (i userInput) toModel() *model.User {
+	return &model.User{
+		FirstName: i.FirstName,
+		LastName:  i.LastName,
+		Nickname:  i.Nickname,
+		Password:  i.Password,
+		Email:     i.Email,
+		Country:   i.Country,
+	}
+}
+
+// resolver is the root resolver for queries and mutations.
+type resolver struct {
+	users Users
+}
+
+func (r *resolver) User(ctx context.Context, args struct{ ID graphql.ID }) (*userResolver, error) {
+	id := string(args.ID)
+
+	// Invalid ids can't exist so are not looked up to avoid failing the rest of the batch
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, nil
+	}
+
+	l := userLoaderFrom(ctx)
+	if l == nil {
+		l = newUserLoader(r.users)
+	}
+
+	u, err := l.Load(ctx, id)
+	if err != nil {
+		logging.From(ctx).Error("failed to get user", zap.Error(err))
+		return nil, handleError(ctx, err)
+	}
+	if u == nil {
+		return nil, nil
+	}
+
+	return &userResolver{u: u}, nil
+}
+
+func (r *resolver) Users(ctx context.Context, args struct {
+	Filter []userFilterInput
+	Sort   *[]userSortInput
+	First  *int32
+	After  *string
+}) (*userConnectionResolver, error) {
+	if len(args.Filter) == 0 {
+		return nil, handleError(ctx, ErrMissingFilter.Wrap(errors.ErrValidation))
+	}
+
+	first := int64(defaultPageSize)
+	if args.First != nil {
+		first = int64(*args.First)
+	}
+	if first < 0 || first > maxPageSize {
+		return nil, handleError(ctx, ErrInvalidPageSize.Wrap(errors.ErrValidation))
+	}
+
+	offset := int64(0)
+	if args.After != nil {
+		position, err := decodeCursor(*args.After)
+		if err != nil {
+			return nil, handleError(ctx, ErrInvalidCursor.Wrap(errors.ErrValidation))
+		}
+		offset = position + 1
+	}
+
+	filters := make([]model.Filter, 0, len(args.Filter))
+	for _, f := range args.Filter {
+		filters = append(filters, model.Filter{
+			Field:     filterFields[f.Field],
+			MatchType: matchTypes[f.MatchType],
+			Value:     f.Value,
+		})
+	}
+
+	var sort []model.Sort
+	if args.Sort != nil {
+		for _, o := range *args.Sort {
+			sort = append(sort, model.Sort{Field: sortFields[o.Field], Direction: sortDirections[o.Direction]})
+		}
+	}
+
+	// One more user than requested is fetched to determine if there is a next page
+	users, err := r.users.FindUsers(ctx, filters, sort, offset, first+1)
+	if err != nil && !errors.Is(err, errors.ErrNotFound) {
+		logging.From(ctx).Error("failed to find users", zap.Error(err))
+		return nil, handleError(ctx, err)
+	}
+
+	c := &userConnectionResolver{edges: []*userEdgeResolver{}}
+
+	if int64(len(users)) > first {
+		users = users[:first]
+		c.hasNextPage = true
+	}
+
+	for i, u := range users {
+		c.edges = append(c.edges, &userEdgeResolver{
+			cursor: encodeCursor(offset + int64(i)),
+			node:   &userResolver{u: u},
+		})
+	}
+
+	return c, nil
+}
+
+func (r *resolver) CreateUser(ctx context.Context, args struct{ Input userInput }) (*userResolver, error) {
+	u, err := r.users.CreateUser(ctx, args.Input.toModel())
+	if err != nil {
+		logging.From(ctx).Error("failed to create user", zap.Error(err))
+		return nil, handleError(ctx, err)
+	}
+
+	return &userResolver{u: u}, nil
+}
+
+func (r *resolver) UpdateUser(ctx context.Context, args struct {
+	ID    graphql.ID
+	Input userInput
+}) (*userResolver, error) {
+	id := string(args.ID)
+
+	u := args.Input.toModel()
+	u.ID = &id
+
+	updatedUser, err := r.users.UpdateUser(ctx, u)
+	if err != nil {
+		logging.From(ctx).Error("failed to update user", zap.Error(err))
+		return nil, handleError(ctx, err)
+	}
+
+	return &userResolver{u: updatedUser}, nil
+}
+
+func (r *resolver) DeleteUser(ctx context.Context, args struct{ ID graphql.ID }) (bool, error) {
+	if err := r.users.DeleteUser(ctx, string(args.ID)); err != nil {
+		logging.From(ctx).Error("failed to delete user", zap.Error(err))
+		return false, handleError(ctx, err)
+	}
+
+	return true, nil
+}
+
+type userResolver struct {
+	u *model.User
+}
+
+func (r *userResolver) ID() graphql.ID {
+	if r.u.ID == nil {
+		return ""
+	}
+	return graphql.ID(*r.u.ID)
+}
+
+func (r *userResolver) FirstName() *string {
+	return r.u.FirstName
+}
+
+func (r *userResolver) LastName() *string {
+	return r.u.LastName
+}
+
+func (r *userResolver) Nickname() *string {
+	return r.u.Nickname
+}
+
+func (r *userResolver) Email() *string {
+	return r.u.Email
+}
+
+func (r *userResolver) Country() *string {
+	return r.u.Country
+}
+
+func (r *userResolver) CreatedAt() *graphql.Time {
+	if r.u.CreatedAt == nil {
+		return nil
+	}
+	return &graphql.Time{Time: *r.u.CreatedAt}
+}
+
+func (r *userResolver) UpdatedAt() *graphql.Time {
+	if r.u.UpdatedAt == nil {
+		return nil
+	}
+	return &graphql.Time{Time: *r.u.UpdatedAt}
+}
+
+type userConnectionResolver struct {
+	edges       []*userEdgeResolver
+	hasNextPage bool
+}
+
+func (r *userConnectionResolver) Edges() []*userEdgeResolver {
+	return r.edges
+}
+
+func (r *userConnectionResolver) PageInfo() *pageInfoResolver {
+	p := &pageInfoResolver{hasNextPage: r.hasNextPage}
+	if len(r.edges) > 0 {
+		p.endCursor = &r.edges[len(r.edges)-1].cursor
+	}
+
+	return p
+}
+
+type userEdgeResolver struct {
+	cursor string
+	node   *userResolver
+}
+
+func (r *userEdgeResolver) Cursor() string {
+	return r.cursor
+}
+
+func (r *userEdgeResolver) Node() *userResolver {
+	return r.node
+}
+
+type pageInfoResolver struct {
+	hasNextPage bool
+	endCursor   *string
+}
+
+func (r *pageInfoResolver) HasNextPage() bool {
+	return r.hasNextPage
+}
+
+func (r *pageInfoResolver) EndCursor() *string {
+	return r.endCursor
+}
+
+// encodeCursor returns an opaque cursor for the position of a user within the results.
+func encodeCursor(position int64) string {
+	return base64.StdEncoding.EncodeToString([]byte(cursorPrefix + strconv.FormatInt(position, 10)))
+}
+
+func decodeCursor(cursor string) (int64, error) {
+	data, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+
+	position, err := strconv.ParseInt(strings.TrimPrefix(string(data), cursorPrefix), 10, 64)
+	if err != nil || !strings.HasPrefix(string(data), cursorPrefix) || position < 0 {
+		return 0, ErrInvalidCursor
+	}
+
+	return position, nil
+}
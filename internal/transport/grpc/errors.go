@@ -0,0 +1,40 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	"github.com/speakeasy-api/rest-template-go/internal/core/logging"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func handleError(ctx context.Context, err error) error {
+	logging.From(ctx).Error("error occurred in request", zap.Error(err))
+
+	var code codes.Code
+
+	switch errors.CategoryOf(err) {
+	case errors.CategoryInvalidRequest, errors.CategoryValidation:
+		code = codes.InvalidArgument
+	case errors.CategoryNotFound:
+		code = codes.NotFound
+	case errors.CategoryConflict:
+		code = codes.AlreadyExists
+	case errors.CategoryUnauthenticated:
+		code = codes.Unauthenticated
+	case errors.CategoryForbidden:
+		code = codes.PermissionDenied
+	case errors.CategoryUnavailable:
+		code = codes.Unavailable
+	case errors.CategoryRateLimited:
+		code = codes.ResourceExhausted
+	case errors.CategoryUnknown:
+		fallthrough
+	default:
+		code = codes.Internal
+	}
+
+	return status.Error(code, errors.PublicMessage(err))
+}
@@ -0,0 +1,39 @@
+//go:generate buf generate --template ../../../buf.gen.grpc.yaml --output ../../.. --path ../../../proto/users ../../../proto
+//go:generate mockgen -destination=./mocks/grpc_mock.go -package mocks github.com/speakeasy-api/rest-template-go/internal/transport/grpc Users
+
+package grpc
+
+import (
+	"context"
+
+	usersv1 "github.com/speakeasy-api/rest-template-go/internal/transport/grpc/pb/users/v1"
+	"github.com/speakeasy-api/rest-template-go/internal/users/model"
+	"google.golang.org/grpc"
+)
+
+// Users represents a type that can provide CRUD operations on users.
+type Users interface {
+	CreateUser(ctx context.Context, user *model.User) (*model.User, error)
+	GetUser(ctx context.Context, id string) (*model.User, error)
+	FindUsers(ctx context.Context, filters []model.Filter, sort []model.Sort, offset, limit int64) ([]*model.User, error)
+	UpdateUser(ctx context.Context, user *model.User) (*model.User, error)
+	DeleteUser(ctx context.Context, id string) error
+}
+
+// Server represents a gRPC server that can handle requests for this microservice.
+type Server struct {
+	usersv1.UnimplementedUsersServiceServer
+	users Users
+}
+
+// New will instantiate a new instance of Server.
+func New(u Users) *Server {
+	return &Server{
+		users: u,
+	}
+}
+
+// Register will register the services this server provides with the gRPC server.
+func (s *Server) Register(gs *grpc.Server) {
+	usersv1.RegisterUsersServiceServer(gs, s)
+}
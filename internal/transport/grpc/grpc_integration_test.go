@@ -0,0 +1,321 @@
+package grpc_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/AlekSi/pointer"
+	"github.com/golang/mock/gomock"
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	grpclistener "github.com/speakeasy-api/rest-template-go/internal/core/listeners/grpc"
+	grpctransport "github.com/speakeasy-api/rest-template-go/internal/transport/grpc"
+	"github.com/speakeasy-api/rest-template-go/internal/transport/grpc/mocks"
+	usersv1 "github.com/speakeasy-api/rest-template-go/internal/transport/grpc/pb/users/v1"
+	"github.com/speakeasy-api/rest-template-go/internal/users/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func dial(t *testing.T, u grpctransport.Users) *grpc.ClientConn {
+	t.Helper()
+
+	lis := bufconn.Listen(1024 * 1024)
+
+	s := grpclistener.New(grpclistener.Config{}, grpctransport.New(u))
+
+	go func() {
+		_ = s.Serve(context.Background(), lis)
+	}()
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return conn
+}
+
+var (
+	testTime = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	testUser = &model.User{
+		ID:        pointer.ToString("some-test-id"),
+		FirstName: pointer.ToString("testFirst"),
+		LastName:  pointer.ToString("testLast"),
+		Password:  pointer.ToString("test"),
+		Email:     pointer.ToString("test@test.com"),
+		CreatedAt: pointer.ToTime(testTime),
+		UpdatedAt: pointer.ToTime(testTime),
+	}
+	testProtoUser = &usersv1.User{
+		Id:        "some-test-id",
+		FirstName: proto.String("testFirst"),
+		LastName:  proto.String("testLast"),
+		Email:     proto.String("test@test.com"),
+		CreatedAt: timestamppb.New(testTime),
+		UpdatedAt: timestamppb.New(testTime),
+	}
+)
+
+func TestServer_CreateUser(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      *usersv1.CreateUserRequest
+		setup    func(u *mocks.MockUsers)
+		want     *usersv1.CreateUserResponse
+		wantCode codes.Code
+	}{
+		{
+			name: "success",
+			req: &usersv1.CreateUserRequest{
+				User: &usersv1.User{
+					FirstName: proto.String("testFirst"),
+					LastName:  proto.String("testLast"),
+					Email:     proto.String("test@test.com"),
+				},
+				Password: proto.String("test"),
+			},
+			setup: func(u *mocks.MockUsers) {
+				u.EXPECT().CreateUser(gomock.Any(), &model.User{
+					FirstName: pointer.ToString("testFirst"),
+					LastName:  pointer.ToString("testLast"),
+					Password:  pointer.ToString("test"),
+					Email:     pointer.ToString("test@test.com"),
+				}).Return(testUser, nil).Times(1)
+			},
+			want:     &usersv1.CreateUserResponse{User: testProtoUser},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "fails with missing user",
+			req:      &usersv1.CreateUserRequest{},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name: "fails with validation error",
+			req:  &usersv1.CreateUserRequest{User: &usersv1.User{}},
+			setup: func(u *mocks.MockUsers) {
+				u.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Return(nil, errors.ErrValidation).Times(1)
+			},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name: "fails with unknown error",
+			req:  &usersv1.CreateUserRequest{User: &usersv1.User{}},
+			setup: func(u *mocks.MockUsers) {
+				u.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Return(nil, errors.ErrUnknown.Wrap(errors.New("some error"))).Times(1)
+			},
+			wantCode: codes.Internal,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			u := mocks.NewMockUsers(ctrl)
+			if tt.setup != nil {
+				tt.setup(u)
+			}
+
+			c := usersv1.NewUsersServiceClient(dial(t, u))
+
+			res, err := c.CreateUser(context.Background(), tt.req)
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			if tt.want != nil {
+				assert.True(t, proto.Equal(tt.want, res), "got %v", res)
+			}
+		})
+	}
+}
+
+func TestServer_GetUser(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		want     *usersv1.GetUserResponse
+		wantCode codes.Code
+		wantMsg  string
+	}{
+		{
+			name:     "success",
+			want:     &usersv1.GetUserResponse{User: testProtoUser},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "fails with not found",
+			err:      errors.ErrNotFound.Wrap(errors.New("no rows in result set")),
+			wantCode: codes.NotFound,
+			wantMsg:  "err_not_found: not found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			u := mocks.NewMockUsers(ctrl)
+			if tt.err != nil {
+				u.EXPECT().GetUser(gomock.Any(), "some-test-id").Return(nil, tt.err).Times(1)
+			} else {
+				u.EXPECT().GetUser(gomock.Any(), "some-test-id").Return(testUser, nil).Times(1)
+			}
+
+			c := usersv1.NewUsersServiceClient(dial(t, u))
+
+			res, err := c.GetUser(context.Background(), &usersv1.GetUserRequest{Id: "some-test-id"})
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			if tt.wantMsg != "" {
+				// Causes are not leaked to clients
+				assert.Equal(t, tt.wantMsg, status.Convert(err).Message())
+			}
+			if tt.want != nil {
+				assert.True(t, proto.Equal(tt.want, res), "got %v", res)
+			}
+		})
+	}
+}
+
+func TestServer_FindUsers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	u := mocks.NewMockUsers(ctrl)
+	u.EXPECT().FindUsers(gomock.Any(), []model.Filter{
+		{Field: model.FieldEmail, MatchType: model.MatchTypeEqual, Value: "test@test.com"},
+		{Field: "", MatchType: model.MatchTypeLike, Value: "test%"},
+	}, nil, int64(10), int64(5)).Return([]*model.User{testUser}, nil).Times(1)
+
+	c := usersv1.NewUsersServiceClient(dial(t, u))
+
+	res, err := c.FindUsers(context.Background(), &usersv1.FindUsersRequest{
+		Filters: []*usersv1.Filter{
+			{Field: usersv1.Field_FIELD_EMAIL, MatchType: usersv1.MatchType_MATCH_TYPE_EQUAL, Value: "test@test.com"},
+			{Field: usersv1.Field_FIELD_UNSPECIFIED, MatchType: usersv1.MatchType_MATCH_TYPE_LIKE, Value: "test%"},
+		},
+		Offset: 10,
+		Limit:  5,
+	})
+	require.NoError(t, err)
+	assert.True(t, proto.Equal(&usersv1.FindUsersResponse{Users: []*usersv1.User{testProtoUser}}, res), "got %v", res)
+}
+
+func TestServer_UpdateUser(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      *usersv1.UpdateUserRequest
+		setup    func(u *mocks.MockUsers)
+		wantCode codes.Code
+	}{
+		{
+			name: "success",
+			req:  &usersv1.UpdateUserRequest{User: &usersv1.User{Id: "some-test-id", Nickname: proto.String("test")}},
+			setup: func(u *mocks.MockUsers) {
+				u.EXPECT().UpdateUser(gomock.Any(), &model.User{ID: pointer.ToString("some-test-id"), Nickname: pointer.ToString("test")}).Return(testUser, nil).Times(1)
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name: "success with password",
+			req:  &usersv1.UpdateUserRequest{User: &usersv1.User{Id: "some-test-id"}, Password: proto.String("new-password")},
+			setup: func(u *mocks.MockUsers) {
+				u.EXPECT().UpdateUser(gomock.Any(), &model.User{ID: pointer.ToString("some-test-id"), Password: pointer.ToString("new-password")}).Return(testUser, nil).Times(1)
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "fails with missing id",
+			req:      &usersv1.UpdateUserRequest{User: &usersv1.User{Nickname: proto.String("test")}},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name: "fails with not found",
+			req:  &usersv1.UpdateUserRequest{User: &usersv1.User{Id: "some-test-id"}},
+			setup: func(u *mocks.MockUsers) {
+				u.EXPECT().UpdateUser(gomock.Any(), gomock.Any()).Return(nil, errors.ErrNotFound).Times(1)
+			},
+			wantCode: codes.NotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			u := mocks.NewMockUsers(ctrl)
+			if tt.setup != nil {
+				tt.setup(u)
+			}
+
+			c := usersv1.NewUsersServiceClient(dial(t, u))
+
+			_, err := c.UpdateUser(context.Background(), tt.req)
+			assert.Equal(t, tt.wantCode, status.Code(err))
+		})
+	}
+}
+
+func TestServer_DeleteUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	u := mocks.NewMockUsers(ctrl)
+	u.EXPECT().DeleteUser(gomock.Any(), "some-test-id").Return(nil).Times(1)
+
+	c := usersv1.NewUsersServiceClient(dial(t, u))
+
+	_, err := c.DeleteUser(context.Background(), &usersv1.DeleteUserRequest{Id: "some-test-id"})
+	assert.NoError(t, err)
+}
+
+func TestServer_Panic(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	u := mocks.NewMockUsers(ctrl)
+	gomock.InOrder(
+		u.EXPECT().GetUser(gomock.Any(), "some-test-id").DoAndReturn(func(context.Context, string) (*model.User, error) {
+			panic("nil map")
+		}).Times(1),
+		u.EXPECT().GetUser(gomock.Any(), "some-test-id").Return(testUser, nil).Times(1),
+	)
+
+	c := usersv1.NewUsersServiceClient(dial(t, u))
+
+	_, err := c.GetUser(context.Background(), &usersv1.GetUserRequest{Id: "some-test-id"})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Equal(t, errors.ErrUnknown.Error(), status.Convert(err).Message())
+
+	// The server is still serving after the panic
+	res, err := c.GetUser(context.Background(), &usersv1.GetUserRequest{Id: "some-test-id"})
+	require.NoError(t, err)
+	assert.True(t, proto.Equal(testProtoUser, res.User), "got %v", res.User)
+}
+
+func TestServer_Health(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	c := healthpb.NewHealthClient(dial(t, mocks.NewMockUsers(ctrl)))
+
+	for _, service := range []string{"", usersv1.UsersService_ServiceDesc.ServiceName} {
+		assert.Eventually(t, func() bool {
+			res, err := c.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+			return err == nil && res.Status == healthpb.HealthCheckResponse_SERVING
+		}, time.Second, 10*time.Millisecond, "service %q", service)
+	}
+}
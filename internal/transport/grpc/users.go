@@ -0,0 +1,163 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	"github.com/speakeasy-api/rest-template-go/internal/core/logging"
+	usersv1 "github.com/speakeasy-api/rest-template-go/internal/transport/grpc/pb/users/v1"
+	"github.com/speakeasy-api/rest-template-go/internal/users/model"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// ErrMissingUser is returned when a request doesn't include the user to create or update.
+	ErrMissingUser = errors.Error("user is required")
+	// ErrMissingID is returned when a request doesn't include the id of the user to update.
+	ErrMissingID = errors.Error("user id is required")
+)
+
+var fields = map[usersv1.Field]model.Field{
+	usersv1.Field_FIELD_FIRST_NAME: model.FieldFirstName,
+	usersv1.Field_FIELD_LAST_NAME:  model.FieldLastName,
+	usersv1.Field_FIELD_NICKNAME:   model.FieldNickname,
+	usersv1.Field_FIELD_EMAIL:      model.FieldEmail,
+	usersv1.Field_FIELD_COUNTRY:    model.FieldCountry,
+}
+
+var matchTypes = map[usersv1.MatchType]model.MatchType{
+	usersv1.MatchType_MATCH_TYPE_EQUAL: model.MatchTypeEqual,
+	usersv1.MatchType_MATCH_TYPE_LIKE:  model.MatchTypeLike,
+}
+
+// CreateUser will create a new user.
+func (s *Server) CreateUser(ctx context.Context, req *usersv1.CreateUserRequest) (*usersv1.CreateUserResponse, error) {
+	if req.User == nil {
+		return nil, handleError(ctx, errors.ErrInvalidRequest.Wrap(ErrMissingUser))
+	}
+
+	createdUser, err := s.users.CreateUser(ctx, userFromProto(req.User, req.Password))
+	if err != nil {
+		logging.From(ctx).Error("failed to create user", zap.Error(err))
+		return nil, handleError(ctx, err)
+	}
+
+	return &usersv1.CreateUserResponse{User: userToProto(createdUser)}, nil
+}
+
+// GetUser will get the user with the given id.
+func (s *Server) GetUser(ctx context.Context, req *usersv1.GetUserRequest) (*usersv1.GetUserResponse, error) {
+	u, err := s.users.GetUser(ctx, req.Id)
+	if err != nil {
+		logging.From(ctx).Error("failed to get user", zap.Error(err))
+		return nil, handleError(ctx, err)
+	}
+
+	return &usersv1.GetUserResponse{User: userToProto(u)}, nil
+}
+
+// FindUsers will find the users matching the filters.
+func (s *Server) FindUsers(ctx context.Context, req *usersv1.FindUsersRequest) (*usersv1.FindUsersResponse, error) {
+	filters := make([]model.Filter, 0, len(req.Filters))
+	for _, f := range req.Filters {
+		// Unspecified fields and match types are left empty to fail validation
+		filters = append(filters, model.Filter{
+			Field:     fields[f.Field],
+			MatchType: matchTypes[f.MatchType],
+			Value:     f.Value,
+		})
+	}
+
+	users, err := s.users.FindUsers(ctx, filters, nil, req.Offset, req.Limit)
+	if err != nil {
+		logging.From(ctx).Error("failed to find users", zap.Error(err))
+		return nil, handleError(ctx, err)
+	}
+
+	res := &usersv1.FindUsersResponse{Users: make([]*usersv1.User, 0, len(users))}
+	for _, u := range users {
+		res.Users = append(res.Users, userToProto(u))
+	}
+
+	return res, nil
+}
+
+// UpdateUser will update the user with the id of the given user.
+func (s *Server) UpdateUser(ctx context.Context, req *usersv1.UpdateUserRequest) (*usersv1.UpdateUserResponse, error) {
+	if req.User == nil {
+		return nil, handleError(ctx, errors.ErrInvalidRequest.Wrap(ErrMissingUser))
+	}
+	if req.User.Id == "" {
+		return nil, handleError(ctx, errors.ErrInvalidRequest.Wrap(ErrMissingID))
+	}
+
+	updatedUser, err := s.users.UpdateUser(ctx, userFromProto(req.User, req.Password))
+	if err != nil {
+		logging.From(ctx).Error("failed to update user", zap.Error(err))
+		return nil, handleError(ctx, err)
+	}
+
+	return &usersv1.UpdateUserResponse{User: userToProto(updatedUser)}, nil
+}
+
+// DeleteUser will delete the user with the given id.
+func (s *Server) DeleteUser(ctx context.Context, req *usersv1.DeleteUserRequest) (*usersv1.DeleteUserResponse, error) {
+	if err := s.users.DeleteUser(ctx, req.Id); err != nil {
+		logging.From(ctx).Error("failed to delete user", zap.Error(err))
+		return nil, handleError(ctx, err)
+	}
+
+	return &usersv1.DeleteUserResponse{}, nil
+}
+
+// userToProto converts a user for a response, leaving out the password so it is never returned.
+func userToProto(u *model.User) *usersv1.User {
+	if u == nil {
+		return nil
+	}
+
+	pu := &usersv1.User{
+		FirstName: u.FirstName,
+		LastName:  u.LastName,
+		Nickname:  u.Nickname,
+		Email:     u.Email,
+		Country:   u.Country,
+	}
+	if u.ID != nil {
+		pu.Id = *u.ID
+	}
+	if u.CreatedAt != nil {
+		pu.CreatedAt = timestamppb.New(*u.CreatedAt)
+	}
+	if u.UpdatedAt != nil {
+		pu.UpdatedAt = timestamppb.New(*u.UpdatedAt)
+	}
+
+	return pu
+}
+
+// userFromProto converts the user of a request along with the password sent alongside it.
+func userFromProto(pu *usersv1.User, password *string) *model.User {
+	u := &model.User{
+		FirstName: pu.FirstName,
+		LastName:  pu.LastName,
+		Nickname:  pu.Nickname,
+		Password:  password,
+		Email:     pu.Email,
+		Country:   pu.Country,
+	}
+	if pu.Id != "" {
+		u.ID = &pu.Id
+	}
+	if pu.CreatedAt != nil {
+		t := pu.CreatedAt.AsTime()
+		u.CreatedAt = &t
+	}
+	if pu.UpdatedAt != nil {
+		t := pu.UpdatedAt.AsTime()
+		u.UpdatedAt = &t
+	}
+
+	return u
+}
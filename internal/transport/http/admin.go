@@ -0,0 +1,207 @@
+package http
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	"github.com/speakeasy-api/rest-template-go/internal/core/logging"
+	"github.com/speakeasy-api/rest-template-go/internal/events"
+	"go.uber.org/zap"
+)
+
+// DeadLetters represents a type that can inspect and recover events that failed to publish.
+type DeadLetters interface {
+	GetDeadLetter(ctx context.Context, id string) (*events.DeadLetter, error)
+	FindDeadLetters(ctx context.Context, filter events.DeadLetterFilter, offset, limit int64) ([]*events.DeadLetter, error)
+	RedriveDeadLetter(ctx context.Context, id string) error
+	RedriveDeadLetters(ctx context.Context, filter events.DeadLetterFilter) (int64, error)
+	DiscardDeadLetter(ctx context.Context, id string) error
+	DiscardDeadLetters(ctx context.Context, filter events.DeadLetterFilter) (int64, error)
+}
+
+type searchDeadLettersRequest struct {
+	Filter events.DeadLetterFilter `json:"filter"`
+	Offset int64                   `json:"offset"`
+	Limit  int64                   `json:"limit"`
+}
+
+type filterDeadLettersRequest struct {
+	Filter events.DeadLetterFilter `json:"filter"`
+}
+
+type successResponse struct {
+	Success bool `json:"success"`
+}
+
+type countResponse struct {
+	Count int64 `json:"count"`
+}
+
+// AdminConfig represents the configuration of the admin API.
+type AdminConfig struct {
+	// Token is the bearer token callers of the admin API must present, every request is rejected if it isn't set.
+	Token string `env:"ADMIN_TOKEN"`
+}
+
+// Admin represents a HTTP server that can handle operational requests for this microservice.
+type Admin struct {
+	token       string
+	deadLetters DeadLetters
+}
+
+// NewAdmin will instantiate a new instance of Admin.
+func NewAdmin(cfg AdminConfig, dl DeadLetters) *Admin {
+	return &Admin{
+		token:       cfg.Token,
+		deadLetters: dl,
+	}
+}
+
+// AddRoutes will add the routes this server supports to the router, requests to them must be authenticated with the
+// configured token.
+func (a *Admin) AddRoutes(r *mux.Router) error {
+	r = r.PathPrefix("/v1/admin").Subrouter()
+	r.Use(a.authenticate)
+
+	r.HandleFunc("/deadletters/search", a.searchDeadLetters).Methods(http.MethodPost)
+	r.HandleFunc("/deadletters/redrive", a.redriveDeadLetters).Methods(http.MethodPost)
+	r.HandleFunc("/deadletters/discard", a.discardDeadLetters).Methods(http.MethodPost)
+	r.HandleFunc("/deadletters/{id}", a.getDeadLetter).Methods(http.MethodGet)
+	r.HandleFunc("/deadletters/{id}", a.discardDeadLetter).Methods(http.MethodDelete)
+	r.HandleFunc("/deadletters/{id}/redrive", a.redriveDeadLetter).Methods(http.MethodPost)
+
+	return nil
+}
+
+// authenticate will reject requests that don't present the admin token as a bearer token.
+func (a *Admin) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+		// Compared in constant time so the token can't be guessed from how long requests take to be rejected
+		if a.token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) != 1 {
+			w.Header().Add("Content-Type", "application/json")
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			handleError(r.Context(), w, errors.ErrUnauthenticated)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (a *Admin) searchDeadLetters(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	w.Header().Add("Content-Type", "application/json")
+
+	req := searchDeadLettersRequest{}
+	if err := decodeBody(r, &req); err != nil {
+		handleError(ctx, w, err)
+		return
+	}
+
+	dls, err := a.deadLetters.FindDeadLetters(ctx, req.Filter, req.Offset, req.Limit)
+	if err != nil {
+		logging.From(ctx).Error("failed to find dead letters", zap.Error(err))
+		handleError(ctx, w, err)
+		return
+	}
+
+	handleResponse(ctx, w, dls)
+}
+
+func (a *Admin) getDeadLetter(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	w.Header().Add("Content-Type", "application/json")
+
+	id := mux.Vars(r)["id"]
+
+	dl, err := a.deadLetters.GetDeadLetter(ctx, id)
+	if err != nil {
+		logging.From(ctx).Error("failed to get dead letter", zap.Error(err))
+		handleError(ctx, w, err)
+		return
+	}
+
+	handleResponse(ctx, w, dl)
+}
+
+func (a *Admin) redriveDeadLetter(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	w.Header().Add("Content-Type", "application/json")
+
+	id := mux.Vars(r)["id"]
+
+	if err := a.deadLetters.RedriveDeadLetter(ctx, id); err != nil {
+		logging.From(ctx).Error("failed to redrive dead letter", zap.Error(err))
+		handleError(ctx, w, err)
+		return
+	}
+
+	handleResponse(ctx, w, successResponse{Success: true})
+}
+
+func (a *Admin) discardDeadLetter(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	w.Header().Add("Content-Type", "application/json")
+
+	id := mux.Vars(r)["id"]
+
+	if err := a.deadLetters.DiscardDeadLetter(ctx, id); err != nil {
+		logging.From(ctx).Error("failed to discard dead letter", zap.Error(err))
+		handleError(ctx, w, err)
+		return
+	}
+
+	handleResponse(ctx, w, successResponse{Success: true})
+}
+
+func (a *Admin) redriveDeadLetters(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	w.Header().Add("Content-Type", "application/json")
+
+	req := filterDeadLettersRequest{}
+	if err := decodeBody(r, &req); err != nil {
+		handleError(ctx, w, err)
+		return
+	}
+
+	count, err := a.deadLetters.RedriveDeadLetters(ctx, req.Filter)
+	if err != nil {
+		logging.From(ctx).Error("failed to redrive dead letters", zap.Error(err))
+		handleError(ctx, w, err)
+		return
+	}
+
+	handleResponse(ctx, w, countResponse{Count: count})
+}
+
+func (a *Admin) discardDeadLetters(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	w.Header().Add("Content-Type", "application/json")
+
+	req := filterDeadLettersRequest{}
+	if err := decodeBody(r, &req); err != nil {
+		handleError(ctx, w, err)
+		return
+	}
+
+	count, err := a.deadLetters.DiscardDeadLetters(ctx, req.Filter)
+	if err != nil {
+		logging.From(ctx).Error("failed to discard dead letters", zap.Error(err))
+		handleError(ctx, w, err)
+		return
+	}
+
+	handleResponse(ctx, w, countResponse{Count: count})
+}
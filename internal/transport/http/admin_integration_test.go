@@ -0,0 +1,296 @@
+package http_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/AlekSi/pointer"
+	"github.com/golang/mock/gomock"
+	coreerrors "github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	httplistener "github.com/speakeasy-api/rest-template-go/internal/core/listeners/http"
+	"github.com/speakeasy-api/rest-template-go/internal/core/problem"
+	"github.com/speakeasy-api/rest-template-go/internal/events"
+	httptransport "github.com/speakeasy-api/rest-template-go/internal/transport/http"
+	"github.com/speakeasy-api/rest-template-go/internal/transport/http/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	deadLetterURL        = "/v1/admin/deadletters/%s"
+	deadLetterRedriveURL = deadLetterURL + "/redrive"
+	redriveURL           = "/v1/admin/deadletters/redrive"
+	discardURL           = "/v1/admin/deadletters/discard"
+
+	adminToken = "some-admin-token"
+)
+
+func TestAdmin_GetDeadLetter(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       string
+		err      error
+		wantCode int
+	}{
+		{
+			name:     "success",
+			id:       "some-dead-letter-id",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "not found",
+			id:       "some-dead-letter-id",
+			err:      coreerrors.ErrNotFound,
+			wantCode: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dl := mocks.NewMockDeadLetters(ctrl)
+
+			r := newRouter(t)
+			require.NoError(t, httptransport.NewAdmin(httptransport.AdminConfig{Token: adminToken}, dl).AddRoutes(r))
+
+			var deadLetter *events.DeadLetter
+			if tt.err == nil {
+				deadLetter = &events.DeadLetter{ID: pointer.ToString(tt.id), Topic: events.TopicUsers}
+			}
+			dl.EXPECT().GetDeadLetter(gomock.Any(), tt.id).Return(deadLetter, tt.err).Times(1)
+
+			req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(deadLetterURL, tt.id), nil)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer "+adminToken)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			if tt.err == nil {
+				var res struct {
+					Data events.DeadLetter `json:"data"`
+				}
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+				assert.Equal(t, *deadLetter, res.Data)
+			}
+		})
+	}
+}
+
+func TestAdmin_RedriveDeadLetter(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+	}{
+		{
+			name:     "success",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "fails",
+			err:      errors.New("test fail"),
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dl := mocks.NewMockDeadLetters(ctrl)
+
+			r := newRouter(t)
+			require.NoError(t, httptransport.NewAdmin(httptransport.AdminConfig{Token: adminToken}, dl).AddRoutes(r))
+
+			dl.EXPECT().RedriveDeadLetter(gomock.Any(), "some-dead-letter-id").Return(tt.err).Times(1)
+
+			req, err := http.NewRequest(http.MethodPost, fmt.Sprintf(deadLetterRedriveURL, "some-dead-letter-id"), nil)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer "+adminToken)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+		})
+	}
+}
+
+func TestAdmin_FilterDeadLetters(t *testing.T) {
+	tests := []struct {
+		name      string
+		url       string
+		filter    events.DeadLetterFilter
+		wantCount int64
+	}{
+		{
+			name:      "redrive by topic",
+			url:       redriveURL,
+			filter:    events.DeadLetterFilter{Topic: events.TopicUsers},
+			wantCount: 2,
+		},
+		{
+			name:      "discard by sink",
+			url:       discardURL,
+			filter:    events.DeadLetterFilter{Sink: "log"},
+			wantCount: 5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dl := mocks.NewMockDeadLetters(ctrl)
+
+			r := newRouter(t)
+			require.NoError(t, httptransport.NewAdmin(httptransport.AdminConfig{Token: adminToken}, dl).AddRoutes(r))
+
+			if tt.url == redriveURL {
+				dl.EXPECT().RedriveDeadLetters(gomock.Any(), tt.filter).Return(tt.wantCount, nil).Times(1)
+			} else {
+				dl.EXPECT().DiscardDeadLetters(gomock.Any(), tt.filter).Return(tt.wantCount, nil).Times(1)
+			}
+
+			body, err := json.Marshal(map[string]interface{}{"filter": tt.filter})
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPost, tt.url, bytes.NewBuffer(body))
+			require.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer "+adminToken)
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+
+			var res struct {
+				Data struct {
+					Count int64 `json:"count"`
+				} `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+			assert.Equal(t, tt.wantCount, res.Data.Count)
+		})
+	}
+}
+
+func TestAdmin_FilterDeadLetters_Error(t *testing.T) {
+	tests := []struct {
+		name       string
+		url        string
+		body       string
+		wantCode   int
+		wantDetail string
+		wantErrors []coreerrors.FieldError
+	}{
+		{
+			name:       "unknown filter field",
+			url:        redriveURL,
+			body:       `{"filter":{"topc":"users"}}`,
+			wantCode:   http.StatusBadRequest,
+			wantDetail: "unknown_field: field is not supported",
+			wantErrors: []coreerrors.FieldError{
+				httptransport.ErrUnknownField.Field("filter.topc"),
+			},
+		},
+		{
+			name:       "body too large",
+			url:        discardURL,
+			body:       `{"filter":{"topic":"` + strings.Repeat("a", 256) + `"}}`,
+			wantCode:   http.StatusRequestEntityTooLarge,
+			wantDetail: "body_too_large: request body is too large",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// No calls are expected as requests are rejected before reaching the dead letters
+			dl := mocks.NewMockDeadLetters(ctrl)
+
+			r := newRouter(t)
+			require.NoError(t, httptransport.NewAdmin(httptransport.AdminConfig{Token: adminToken}, dl).AddRoutes(r))
+
+			req, err := http.NewRequest(http.MethodPost, tt.url, bytes.NewBufferString(tt.body))
+			require.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer "+adminToken)
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			// The listener limits the size of bodies before they reach the router
+			httplistener.NewBodyLimiter(256).Middleware(r).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+
+			var res problem.Problem
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+			assert.Equal(t, tt.wantDetail, res.Detail)
+			assert.Equal(t, tt.wantErrors, res.Errors)
+		})
+	}
+}
+
+func TestAdmin_Unauthenticated(t *testing.T) {
+	tests := []struct {
+		name          string
+		token         string
+		authorization string
+	}{
+		{
+			name:  "missing token",
+			token: adminToken,
+		},
+		{
+			name:          "wrong token",
+			token:         adminToken,
+			authorization: "Bearer some-other-token",
+		},
+		{
+			name:          "token not bearer",
+			token:         adminToken,
+			authorization: adminToken + "-suffix",
+		},
+		{
+			name:          "token not configured",
+			authorization: "Bearer ",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// No calls are expected as requests are rejected before reaching the handlers
+			dl := mocks.NewMockDeadLetters(ctrl)
+
+			r := newRouter(t)
+			require.NoError(t, httptransport.NewAdmin(httptransport.AdminConfig{Token: tt.token}, dl).AddRoutes(r))
+
+			req, err := http.NewRequest(http.MethodPost, discardURL, bytes.NewBufferString(`{"filter":{}}`))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+			assert.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+		})
+	}
+}
@@ -0,0 +1,268 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+const (
+	// ErrUnsupportedMediaType is returned when the request body is encoded with a media type no codec supports.
+	ErrUnsupportedMediaType = errors.Error("unsupported_media_type: content type of request is not supported")
+	// ErrNotAcceptable is returned when none of the media types the client accepts are supported.
+	ErrNotAcceptable = errors.Error("not_acceptable: none of the accepted media types are supported")
+)
+
+const (
+	mediaTypeJSON    = "application/json"
+	mediaTypeMsgPack = "application/msgpack"
+	mediaTypeCBOR    = "application/cbor"
+)
+
+// Codec encodes and decodes request and response bodies of a media type.
+type Codec interface {
+	MediaType() string
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// Codecs is a registry of codecs that selects the codec for a request's body from its Content-Type and the codec
+// for its response from its Accept header.
+type Codecs struct {
+	defaultCodec Codec
+	byMediaType  map[string]Codec
+}
+
+// NewCodecs will instantiate a new registry of the codecs, the first codec is used when the client has no preference.
+func NewCodecs(defaultCodec Codec, codecs ...Codec) *Codecs {
+	c := &Codecs{
+		defaultCodec: defaultCodec,
+		byMediaType:  map[string]Codec{},
+	}
+
+	c.Register(defaultCodec)
+	for _, codec := range codecs {
+		c.Register(codec)
+	}
+
+	return c
+}
+
+// DefaultCodecs returns a registry of the JSON, MessagePack and CBOR codecs preferring JSON.
+func DefaultCodecs() *Codecs {
+	c := NewCodecs(JSONCodec{}, MsgPackCodec{}, CBORCodec{})
+	// Media types used for MessagePack before application/msgpack was registered
+	c.Register(MsgPackCodec{}, "application/x-msgpack", "application/vnd.msgpack")
+
+	return c
+}
+
+// Register will add the codec to the registry for its media type and any aliases of it.
+func (c *Codecs) Register(codec Codec, aliases ...string) {
+	c.byMediaType[codec.MediaType()] = codec
+	for _, a := range aliases {
+		c.byMediaType[a] = codec
+	}
+}
+
+// ForContentType returns the codec for decoding a body with the Content-Type, bodies without one are assumed to
+// be encoded with the default codec.
+func (c *Codecs) ForContentType(contentType string) (Codec, error) {
+	if contentType == "" {
+		return c.defaultCodec, nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, ErrUnsupportedMediaType.Wrap(errors.ErrInvalidRequest.Wrap(err))
+	}
+
+	codec, ok := c.byMediaType[mediaType]
+	if !ok {
+		return nil, ErrUnsupportedMediaType.Wrap(errors.ErrInvalidRequest)
+	}
+
+	return codec, nil
+}
+
+// ForAccept returns the codec for encoding a response the client accepts, preferring media types with higher
+// quality values then the order the client listed them in.
+func (c *Codecs) ForAccept(accept string) (Codec, error) {
+	if strings.TrimSpace(accept) == "" {
+		return c.defaultCodec, nil
+	}
+
+	type accepted struct {
+		mediaType string
+		q         float64
+	}
+
+	var ranges []accepted
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+
+		if q > 0 {
+			ranges = append(ranges, accepted{mediaType: mediaType, q: q})
+		}
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].q > ranges[j].q
+	})
+
+	for _, r := range ranges {
+		switch r.mediaType {
+		case "*/*", "application/*":
+			return c.defaultCodec, nil
+		default:
+			if codec, ok := c.byMediaType[r.mediaType]; ok {
+				return codec, nil
+			}
+		}
+	}
+
+	return nil, ErrNotAcceptable.Wrap(errors.ErrInvalidRequest)
+}
+
+type negotiatedKey struct{}
+
+type negotiated struct {
+	decoder Codec
+	encoder Codec
+}
+
+// negotiate will select the codecs for the request and response before calling the handler, rejecting requests
+// whose body or response can't be encoded with any of the codecs.
+func (c *Codecs) negotiate(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		// The response is encoded for the Accept header so caches must key on it, otherwise a cached response could be
+		// served to a client that can't decode it
+		w.Header().Add("Vary", "Accept")
+
+		encoder, err := c.ForAccept(r.Header.Get("Accept"))
+		if err != nil {
+			handleError(ctx, w, err)
+			return
+		}
+
+		// Set before checking the decoder so a request body that can't be decoded gets an error the client accepts
+		decoder, derr := c.ForContentType(r.Header.Get("Content-Type"))
+		ctx = context.WithValue(ctx, negotiatedKey{}, negotiated{decoder: decoder, encoder: encoder})
+		if derr != nil {
+			handleError(ctx, w, derr)
+			return
+		}
+
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// decoderFrom returns the codec negotiated for the request body, defaulting to JSON for routes without negotiation.
+func decoderFrom(ctx context.Context) Codec {
+	if n, ok := ctx.Value(negotiatedKey{}).(negotiated); ok && n.decoder != nil {
+		return n.decoder
+	}
+	return JSONCodec{}
+}
+
+// encoderFrom returns the codec negotiated for the response body, defaulting to JSON for routes without negotiation.
+func encoderFrom(ctx context.Context) Codec {
+	if n, ok := ctx.Value(negotiatedKey{}).(negotiated); ok && n.encoder != nil {
+		return n.encoder
+	}
+	return JSONCodec{}
+}
+
+// JSONCodec encodes bodies as JSON.
+type JSONCodec struct{}
+
+// MediaType returns the media type of JSON.
+func (JSONCodec) MediaType() string {
+	return mediaTypeJSON
+}
+
+// Marshal will encode v as JSON.
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal will decode the JSON into v.
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// MsgPackCodec encodes bodies as MessagePack using the json tags of structs to name their fields.
+type MsgPackCodec struct{}
+
+// MediaType returns the media type of MessagePack.
+func (MsgPackCodec) MediaType() string {
+	return mediaTypeMsgPack
+}
+
+// Marshal will encode v as MessagePack.
+func (MsgPackCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Unmarshal will decode the MessagePack into v.
+func (MsgPackCodec) Unmarshal(data []byte, v interface{}) error {
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.SetCustomStructTag("json")
+
+	return dec.Decode(v)
+}
+
+var (
+	cborEncMode, _ = cbor.EncOptions{Time: cbor.TimeRFC3339Nano}.EncMode()
+	cborDecMode, _ = cbor.DecOptions{DefaultMapType: reflect.TypeOf(map[string]interface{}(nil))}.DecMode()
+)
+
+// CBORCodec encodes bodies as CBOR using the json tags of structs to name their fields.
+type CBORCodec struct{}
+
+// MediaType returns the media type of CBOR.
+func (CBORCodec) MediaType() string {
+	return mediaTypeCBOR
+}
+
+// Marshal will encode v as CBOR.
+func (CBORCodec) Marshal(v interface{}) ([]byte, error) {
+	return cborEncMode.Marshal(v)
+}
+
+// Unmarshal will decode the CBOR into v.
+func (CBORCodec) Unmarshal(data []byte, v interface{}) error {
+	return cborDecMode.Unmarshal(data, v)
+}
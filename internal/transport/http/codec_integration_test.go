@@ -0,0 +1,226 @@
+package http_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/AlekSi/pointer"
+	"github.com/golang/mock/gomock"
+	"github.com/speakeasy-api/rest-template-go/internal/core/problem"
+	httptransport "github.com/speakeasy-api/rest-template-go/internal/transport/http"
+	"github.com/speakeasy-api/rest-template-go/internal/transport/http/mocks"
+	"github.com/speakeasy-api/rest-template-go/internal/users/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_Codecs_Success(t *testing.T) {
+	user := model.User{
+		FirstName: pointer.ToString("testFirst"),
+		Password:  pointer.ToString("test"),
+		Email:     pointer.ToString("test@test.com"),
+	}
+	createdUser := model.User{
+		ID:        pointer.ToString("some-test-id"),
+		FirstName: pointer.ToString("testFirst"),
+		Password:  pointer.ToString("test"),
+		Email:     pointer.ToString("test@test.com"),
+		CreatedAt: pointer.ToTime(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)),
+		UpdatedAt: pointer.ToTime(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)),
+	}
+
+	tests := []struct {
+		name            string
+		requestCodec    httptransport.Codec
+		contentType     string
+		accept          string
+		wantCodec       httptransport.Codec
+		wantContentType string
+	}{
+		{
+			name:            "json by default",
+			requestCodec:    httptransport.JSONCodec{},
+			contentType:     "application/json",
+			wantCodec:       httptransport.JSONCodec{},
+			wantContentType: "application/json",
+		},
+		{
+			name:            "msgpack",
+			requestCodec:    httptransport.MsgPackCodec{},
+			contentType:     "application/msgpack",
+			accept:          "application/msgpack",
+			wantCodec:       httptransport.MsgPackCodec{},
+			wantContentType: "application/msgpack",
+		},
+		{
+			name:            "cbor request with json response",
+			requestCodec:    httptransport.CBORCodec{},
+			contentType:     "application/cbor",
+			accept:          "application/json",
+			wantCodec:       httptransport.JSONCodec{},
+			wantContentType: "application/json",
+		},
+		{
+			name:            "highest quality accepted",
+			requestCodec:    httptransport.JSONCodec{},
+			contentType:     "application/json; charset=utf-8",
+			accept:          "application/msgpack;q=0.5, text/html, application/cbor;q=0.9",
+			wantCodec:       httptransport.CBORCodec{},
+			wantContentType: "application/cbor",
+		},
+		{
+			name:            "wildcard accepted",
+			requestCodec:    httptransport.JSONCodec{},
+			contentType:     "application/json",
+			accept:          "text/html, */*;q=0.1",
+			wantCodec:       httptransport.JSONCodec{},
+			wantContentType: "application/json",
+		},
+		{
+			name:            "msgpack alias",
+			requestCodec:    httptransport.MsgPackCodec{},
+			contentType:     "application/msgpack",
+			accept:          "application/x-msgpack",
+			wantCodec:       httptransport.MsgPackCodec{},
+			wantContentType: "application/msgpack",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			u := mocks.NewMockUsers(ctrl)
+
+			ht := httptransport.New(u, mocks.NewMockDB(ctrl), mocks.NewMockStream(ctrl))
+
+			r := newRouter(t)
+			require.NoError(t, ht.AddRoutes(r))
+
+			u.EXPECT().CreateUser(gomock.Any(), &user).Return(&createdUser, nil).Times(1)
+
+			data, err := tt.requestCodec.Marshal(user)
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPost, baseUserURL, bytes.NewBuffer(data))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", tt.contentType)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.wantContentType, w.Header().Get("Content-Type"))
+			assert.Contains(t, w.Header().Values("Vary"), "Accept")
+
+			var res struct {
+				Data model.User `json:"data"`
+			}
+			require.NoError(t, tt.wantCodec.Unmarshal(w.Body.Bytes(), &res))
+
+			// Binary encodings don't preserve the time zone of times
+			res.Data.CreatedAt = pointer.ToTime(res.Data.CreatedAt.UTC())
+			res.Data.UpdatedAt = pointer.ToTime(res.Data.UpdatedAt.UTC())
+			assert.Equal(t, createdUser, res.Data)
+		})
+	}
+}
+
+func TestServer_Codecs_Error(t *testing.T) {
+	tests := []struct {
+		name            string
+		contentType     string
+		accept          string
+		body            []byte
+		createErr       error
+		wantCode        int
+		wantCodec       httptransport.Codec
+		wantContentType string
+		wantDetail      string
+	}{
+		{
+			name:            "unsupported content type",
+			contentType:     "application/xml",
+			body:            []byte(`<user></user>`),
+			wantCode:        http.StatusUnsupportedMediaType,
+			wantCodec:       httptransport.JSONCodec{},
+			wantContentType: problem.ContentType,
+			wantDetail:      "err_invalid_request: invalid request received",
+		},
+		{
+			name:            "unacceptable response",
+			contentType:     "application/json",
+			accept:          "text/html, application/xml",
+			body:            []byte(`{}`),
+			wantCode:        http.StatusNotAcceptable,
+			wantCodec:       httptransport.JSONCodec{},
+			wantContentType: problem.ContentType,
+			wantDetail:      "not_acceptable: none of the accepted media types are supported",
+		},
+		{
+			name:            "invalid body",
+			contentType:     "application/msgpack",
+			accept:          "application/msgpack",
+			body:            []byte{0xc1},
+			wantCode:        http.StatusBadRequest,
+			wantCodec:       httptransport.JSONCodec{},
+			wantContentType: problem.ContentType,
+			wantDetail:      "err_invalid_request: invalid request received",
+		},
+		{
+			name:            "error encoded with negotiated codec",
+			contentType:     "application/json",
+			accept:          "application/cbor",
+			body:            []byte(`{}`),
+			createErr:       errors.New("test fail"),
+			wantCode:        http.StatusInternalServerError,
+			wantCodec:       httptransport.CBORCodec{},
+			wantContentType: "application/cbor",
+			wantDetail:      "err_unknown: unknown error occurred",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			u := mocks.NewMockUsers(ctrl)
+
+			ht := httptransport.New(u, mocks.NewMockDB(ctrl), mocks.NewMockStream(ctrl))
+
+			r := newRouter(t)
+			require.NoError(t, ht.AddRoutes(r))
+
+			if tt.createErr != nil {
+				u.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Return(nil, tt.createErr).Times(1)
+			}
+
+			req, err := http.NewRequest(http.MethodPost, baseUserURL, bytes.NewBuffer(tt.body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", tt.contentType)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Equal(t, tt.wantContentType, w.Header().Get("Content-Type"))
+
+			var res problem.Problem
+			require.NoError(t, tt.wantCodec.Unmarshal(w.Body.Bytes(), &res))
+			assert.Equal(t, tt.wantCode, res.Status)
+			assert.Equal(t, tt.wantDetail, res.Detail)
+		})
+	}
+}
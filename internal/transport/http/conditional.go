@@ -0,0 +1,68 @@
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/speakeasy-api/rest-template-go/internal/users/model"
+)
+
+// userETag returns a strong ETag for the representation of the user encoded as the media type, users have no version
+// so it is derived from when the user was last updated.
+func userETag(u *model.User, mediaType string) string {
+	h := sha256.New()
+
+	if u.ID != nil {
+		h.Write([]byte(*u.ID))
+	}
+	h.Write([]byte{0})
+	h.Write([]byte(strconv.FormatInt(u.UpdatedAt.UnixNano(), 10)))
+	h.Write([]byte{0})
+	// Each encoding of the user is a different representation so needs its own ETag
+	h.Write([]byte(mediaType))
+
+	return `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// setValidators will set the ETag and Last-Modified headers of the response.
+func setValidators(w http.ResponseWriter, etag string, lastModified time.Time) {
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+}
+
+// notModified returns whether the client already has the current representation according to the conditional
+// headers of the request. If-Modified-Since is ignored when If-None-Match is sent as ETags are more precise.
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return etagMatches(inm, etag)
+	}
+
+	ims := r.Header.Get("If-Modified-Since")
+	if ims == "" {
+		return false
+	}
+
+	since, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+
+	// Last-Modified only has second precision
+	return !lastModified.Truncate(time.Second).After(since)
+}
+
+// etagMatches compares the ETags of an If-None-Match header with the weak comparison it requires, so a response
+// whose ETag was weakened when it was compressed still matches.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,148 @@
+package http
+
+import (
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	httplistener "github.com/speakeasy-api/rest-template-go/internal/core/listeners/http"
+)
+
+const (
+	// ErrMissingContentType is returned when a request has a body but no Content-Type describing it.
+	ErrMissingContentType = errors.Error("missing_content_type: request body must have a content type")
+	// ErrUnknownField is returned when the request body has a field the resource doesn't have.
+	ErrUnknownField = errors.Error("unknown_field: field is not supported")
+	// ErrReadOnlyField is returned when the request body sets a field only the server can set.
+	ErrReadOnlyField = errors.Error("read_only_field: field is read only")
+)
+
+// userReadOnlyFields are the fields of a user that are set by the server.
+var userReadOnlyFields = []string{"id", "created_at", "updated_at"}
+
+// decodeBody will read the request body and strictly decode it into v with the codec negotiated for the request,
+// rejecting bodies without a content type, with fields v doesn't have or that set any of the read only fields.
+// The size of the body is limited by the listener, which makes reading an oversized body fail.
+func decodeBody(r *http.Request, v interface{}, readOnly ...string) error {
+	if r.Header.Get("Content-Type") == "" {
+		return ErrMissingContentType.Wrap(ErrUnsupportedMediaType.Wrap(errors.ErrInvalidRequest))
+	}
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		if errors.Is(err, httplistener.ErrBodyTooLarge) {
+			return err
+		}
+		return errors.ErrUnknown.Wrap(err)
+	}
+
+	codec := decoderFrom(r.Context())
+
+	// Decoded generically first so fields can be checked against v by name for every codec
+	var body interface{}
+	if err := codec.Unmarshal(data, &body); err != nil {
+		return errors.ErrInvalidRequest.Wrap(err)
+	}
+
+	first := ErrUnknownField
+	fields := checkFields(body, reflect.TypeOf(v), "")
+
+	if m, ok := body.(map[string]interface{}); ok {
+		for _, f := range readOnly {
+			// Null is accepted as clients often encode the whole resource leaving read only fields empty
+			if value, ok := m[f]; ok && value != nil {
+				if len(fields) == 0 {
+					first = ErrReadOnlyField
+				}
+				fields = append(fields, ErrReadOnlyField.Field(f))
+			}
+		}
+	}
+
+	if len(fields) > 0 {
+		// Sorted as maps are decoded in a random order
+		sort.SliceStable(fields, func(i, j int) bool {
+			return fields[i].Field < fields[j].Field
+		})
+
+		return errors.WithFieldErrors(first.Wrap(errors.ErrInvalidRequest), fields...)
+	}
+
+	if err := codec.Unmarshal(data, v); err != nil {
+		return errors.ErrInvalidRequest.Wrap(err)
+	}
+
+	return nil
+}
+
+// checkFields returns an error for every field of the decoded body that the type doesn't have, recursing into
+// nested objects and arrays so the path of each field is reported.
+func checkFields(body interface{}, t reflect.Type, path string) []errors.FieldError {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var fields []errors.FieldError
+
+	switch b := body.(type) {
+	case map[string]interface{}:
+		if t.Kind() != reflect.Struct {
+			return nil
+		}
+
+		known := structFields(t)
+
+		for name, value := range b {
+			fieldPath := name
+			if path != "" {
+				fieldPath = path + "." + name
+			}
+
+			ft, ok := known[name]
+			if !ok {
+				fields = append(fields, ErrUnknownField.Field(fieldPath))
+				continue
+			}
+
+			fields = append(fields, checkFields(value, ft, fieldPath)...)
+		}
+	case []interface{}:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return nil
+		}
+
+		for i, value := range b {
+			fields = append(fields, checkFields(value, t.Elem(), path+"["+strconv.Itoa(i)+"]")...)
+		}
+	}
+
+	return fields
+}
+
+// structFields returns the types of the fields of the struct by the name they are encoded with.
+func structFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = f.Name
+		}
+
+		fields[name] = f.Type
+	}
+
+	return fields
+}
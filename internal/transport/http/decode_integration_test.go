@@ -0,0 +1,168 @@
+package http_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	httplistener "github.com/speakeasy-api/rest-template-go/internal/core/listeners/http"
+	"github.com/speakeasy-api/rest-template-go/internal/core/problem"
+	httptransport "github.com/speakeasy-api/rest-template-go/internal/transport/http"
+	"github.com/speakeasy-api/rest-template-go/internal/transport/http/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_DecodeBody_Error(t *testing.T) {
+	msgpackBody, err := httptransport.MsgPackCodec{}.Marshal(map[string]interface{}{"email": "test@test.com", "firstname": "test"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		method      string
+		url         string
+		contentType string
+		body        []byte
+		wantCode    int
+		wantDetail  string
+		wantErrors  []errors.FieldError
+		// The validator rejects some requests before the handler when the API is served with an OpenAPI document
+		withoutValidation bool
+	}{
+		{
+			name:        "unknown field",
+			method:      http.MethodPost,
+			url:         baseUserURL,
+			contentType: "application/json",
+			body:        []byte(`{"email":"test@test.com","firstname":"test","lastName":"test"}`),
+			wantCode:    http.StatusBadRequest,
+			wantDetail:  "unknown_field: field is not supported",
+			wantErrors: []errors.FieldError{
+				httptransport.ErrUnknownField.Field("firstname"),
+				httptransport.ErrUnknownField.Field("lastName"),
+			},
+		},
+		{
+			name:        "unknown nested field",
+			method:      http.MethodPost,
+			url:         searchURL,
+			contentType: "application/json",
+			body:        []byte(`{"filters":[{"field":"email","match_type":"=","value":"test"},{"field":"email","match_type":"=","matchType":"=","value":"test"}],"offset":0,"limit":10}`),
+			wantCode:    http.StatusBadRequest,
+			wantDetail:  "unknown_field: field is not supported",
+			wantErrors: []errors.FieldError{
+				httptransport.ErrUnknownField.Field("filters[1].matchType"),
+			},
+		},
+		{
+			name:        "unknown field in msgpack",
+			method:      http.MethodPost,
+			url:         baseUserURL,
+			contentType: "application/msgpack",
+			body:        msgpackBody,
+			wantCode:    http.StatusBadRequest,
+			wantDetail:  "unknown_field: field is not supported",
+			wantErrors: []errors.FieldError{
+				httptransport.ErrUnknownField.Field("firstname"),
+			},
+		},
+		{
+			name:        "read only fields on create",
+			method:      http.MethodPost,
+			url:         baseUserURL,
+			contentType: "application/json",
+			body:        []byte(`{"id":"some-test-id","email":"test@test.com","created_at":"2020-01-01T00:00:00Z"}`),
+			wantCode:    http.StatusBadRequest,
+			wantDetail:  "read_only_field: field is read only",
+			wantErrors: []errors.FieldError{
+				httptransport.ErrReadOnlyField.Field("created_at"),
+				httptransport.ErrReadOnlyField.Field("id"),
+			},
+		},
+		{
+			name:        "read only field on update",
+			method:      http.MethodPut,
+			url:         fmt.Sprintf(userURL, "some-test-id"),
+			contentType: "application/json",
+			body:        []byte(`{"email":"test@test.com","updated_at":"2020-01-01T00:00:00Z"}`),
+			wantCode:    http.StatusBadRequest,
+			wantDetail:  "read_only_field: field is read only",
+			wantErrors: []errors.FieldError{
+				httptransport.ErrReadOnlyField.Field("updated_at"),
+			},
+		},
+		{
+			name:        "unknown and read only fields",
+			method:      http.MethodPut,
+			url:         fmt.Sprintf(userURL, "some-test-id"),
+			contentType: "application/json",
+			body:        []byte(`{"id":"some-test-id","nick_name":"test"}`),
+			wantCode:    http.StatusBadRequest,
+			wantDetail:  "unknown_field: field is not supported",
+			wantErrors: []errors.FieldError{
+				httptransport.ErrReadOnlyField.Field("id"),
+				httptransport.ErrUnknownField.Field("nick_name"),
+			},
+		},
+		{
+			name:       "missing content type",
+			method:     http.MethodPost,
+			url:        baseUserURL,
+			body:       []byte(`{"email":"test@test.com"}`),
+			wantCode:   http.StatusUnsupportedMediaType,
+			wantDetail: "missing_content_type: request body must have a content type",
+
+			withoutValidation: true,
+		},
+		{
+			name:        "body too large",
+			method:      http.MethodPost,
+			url:         baseUserURL,
+			contentType: "application/json",
+			body:        []byte(`{"email":"test@test.com","first_name":"` + strings.Repeat("a", 256) + `"}`),
+			wantCode:    http.StatusRequestEntityTooLarge,
+			wantDetail:  "body_too_large: request body is too large",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ht := httptransport.New(mocks.NewMockUsers(ctrl), mocks.NewMockDB(ctrl), mocks.NewMockStream(ctrl))
+
+			r := mux.NewRouter()
+			if !tt.withoutValidation {
+				r = newRouter(t)
+			}
+			require.NoError(t, ht.AddRoutes(r))
+
+			req, err := http.NewRequest(tt.method, tt.url, bytes.NewBuffer(tt.body))
+			require.NoError(t, err)
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+
+			w := httptest.NewRecorder()
+
+			// The listener limits the size of bodies before they reach the router
+			httplistener.NewBodyLimiter(256).Middleware(r).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+
+			var res problem.Problem
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+			assert.Equal(t, tt.wantCode, res.Status)
+			assert.Equal(t, tt.wantDetail, res.Detail)
+			assert.Equal(t, tt.wantErrors, res.Errors)
+		})
+	}
+}
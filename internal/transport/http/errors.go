@@ -2,45 +2,53 @@ package http
 
 import (
 	"context"
-	"encoding/json"
 	"net/http"
-	"strings"
 
 	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	httplistener "github.com/speakeasy-api/rest-template-go/internal/core/listeners/http"
 	"github.com/speakeasy-api/rest-template-go/internal/core/logging"
+	"github.com/speakeasy-api/rest-template-go/internal/core/problem"
 	"go.uber.org/zap"
 )
 
 func handleError(ctx context.Context, w http.ResponseWriter, err error) {
 	logging.From(ctx).Error("error occurred in request", zap.Error(err))
 
+	p := problem.FromError(ctx, err)
+
+	// Negotiation failures and oversized bodies are invalid requests but have their own status codes
 	switch {
-	case errors.Is(err, errors.ErrInvalidRequest):
-		fallthrough
-	case errors.Is(err, errors.ErrValidation):
-		w.WriteHeader(http.StatusBadRequest)
-	case errors.Is(err, errors.ErrNotFound):
-		w.WriteHeader(http.StatusNotFound)
-	case errors.Is(err, errors.ErrUnknown):
-		fallthrough
-	default:
-		w.WriteHeader(http.StatusInternalServerError)
+	case errors.Is(err, httplistener.ErrBodyTooLarge):
+		p.Status = http.StatusRequestEntityTooLarge
+	case errors.Is(err, ErrUnsupportedMediaType):
+		p.Status = http.StatusUnsupportedMediaType
+	case errors.Is(err, ErrNotAcceptable):
+		p.Status = http.StatusNotAcceptable
 	}
 
-	errJSON := struct {
-		Error string `json:"error"`
-	}{
-		Error: strings.Split(err.Error(), errors.ErrSeperator)[0], // TODO we may need to strip additional error information
-	}
+	encoder := encoderFrom(ctx)
 
-	data, err := json.Marshal(errJSON)
+	data, err := encoder.Marshal(p)
 	if err != nil {
 		logging.From(ctx).Error("failed to serialize error response", zap.Error(err))
-		data = []byte(`{"error": "internal server error"}`)
+		problem.Write(ctx, w, p)
+		return
 	}
 
-	_, err = w.Write(data)
-	if err != nil {
+	contentType := encoder.MediaType()
+	if contentType == mediaTypeJSON {
+		contentType = problem.ContentType
+	}
+
+	// Errors aren't the representation the cache policy and validators of the route describe
+	for _, h := range []string{"Cache-Control", "ETag", "Last-Modified"} {
+		w.Header().Del(h)
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(p.Status)
+
+	if _, err := w.Write(data); err != nil {
 		logging.From(ctx).Error("failed to write error response", zap.Error(err))
 	}
 }
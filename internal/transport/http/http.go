@@ -1,21 +1,23 @@
-//go:generate mockgen -destination=./mocks/http_mock.go -package mocks github.com/speakeasy-api/rest-template-go/internal/transport/http Users,DB
+//go:generate mockgen -destination=./mocks/http_mock.go -package mocks github.com/speakeasy-api/rest-template-go/internal/transport/http Users,DB,Stream,DeadLetters
 
 package http
 
 import (
 	"context"
-	"encoding/json"
 	"net/http"
 
 	"github.com/gorilla/mux"
+	"github.com/speakeasy-api/rest-template-go/internal/core/logging"
+	"github.com/speakeasy-api/rest-template-go/internal/events/stream"
 	"github.com/speakeasy-api/rest-template-go/internal/users/model"
+	"go.uber.org/zap"
 )
 
 // Users represents a type that can provide CRUD operations on users.
 type Users interface {
 	CreateUser(ctx context.Context, user *model.User) (*model.User, error)
 	GetUser(ctx context.Context, id string) (*model.User, error)
-	FindUsers(ctx context.Context, filters []model.Filter, offset, limit int64) ([]*model.User, error)
+	FindUsers(ctx context.Context, filters []model.Filter, sort []model.Sort, offset, limit int64) ([]*model.User, error)
 	UpdateUser(ctx context.Context, user *model.User) (*model.User, error)
 	DeleteUser(ctx context.Context, id string) error
 }
@@ -25,33 +27,43 @@ type DB interface {
 	PingContext(ctx context.Context) error
 }
 
+// Stream represents a type that can subscribe to a live stream of user events.
+type Stream interface {
+	Subscribe(ctx context.Context, lastEventID string, filter stream.Filter) <-chan stream.Event
+}
+
 // Server represents a HTTP server that can handle requests for this microservice.
 type Server struct {
-	users Users
-	db    DB
+	users  Users
+	db     DB
+	stream Stream
+	codecs *Codecs
 }
 
 // New will instantiate a new instance of Server.
-func New(u Users, db DB) *Server {
+func New(u Users, db DB, s Stream) *Server {
 	return &Server{
-		users: u,
-		db:    db,
+		users:  u,
+		db:     db,
+		stream: s,
+		codecs: DefaultCodecs(),
 	}
 }
 
 // AddRoutes will add the routes this server supports to the router.
 func (s *Server) AddRoutes(r *mux.Router) error {
-	r.HandleFunc("/health", s.healthCheck).Methods(http.MethodGet)
+	r.HandleFunc("/health", s.healthCheck).Methods(http.MethodGet).Name("getHealth")
 
 	r = r.PathPrefix("/v1").Subrouter()
 
-	r.HandleFunc("/user", s.createUser).Methods(http.MethodPost)
-	r.HandleFunc("/user/{id}", s.getUser).Methods(http.MethodGet)
-	r.HandleFunc("/user/{id}", s.updateUser).Methods(http.MethodPut)
-	r.HandleFunc("/user/{id}", s.deleteUser).Methods(http.MethodDelete)
+	r.HandleFunc("/user", s.codecs.negotiate(s.createUser)).Methods(http.MethodPost).Name("createUserv1")
+	r.HandleFunc("/user/{id}", s.codecs.negotiate(s.getUser)).Methods(http.MethodGet).Name("getUserv1")
+	r.HandleFunc("/user/{id}", s.codecs.negotiate(s.updateUser)).Methods(http.MethodPut).Name("updateUserv1")
+	r.HandleFunc("/user/{id}", s.codecs.negotiate(s.deleteUser)).Methods(http.MethodDelete).Name("deleteUserv1")
 
 	// Not the most RESTful way of doing this as it won't really be cachable but provides easier parsing of the inputs for now
-	r.HandleFunc("/users/search", s.searchUsers).Methods(http.MethodPost)
+	r.HandleFunc("/users/search", s.codecs.negotiate(s.searchUsers)).Methods(http.MethodPost).Name("searchUsersv1")
+	r.HandleFunc("/users/stream", s.streamUsers).Methods(http.MethodGet).Name("streamUsersv1")
 
 	return nil
 }
@@ -66,22 +78,24 @@ func (s *Server) healthCheck(w http.ResponseWriter, r *http.Request) {
 }
 
 func handleResponse(ctx context.Context, w http.ResponseWriter, data interface{}) {
-	jsonRes := struct {
+	res := struct {
 		Data interface{} `json:"data"`
 	}{
 		Data: data,
 	}
 
-	dataBytes, err := json.Marshal(jsonRes)
+	encoder := encoderFrom(ctx)
+
+	dataBytes, err := encoder.Marshal(res)
 	if err != nil {
 		handleError(ctx, w, err)
 		return
 	}
 
+	w.Header().Set("Content-Type", encoder.MediaType())
+	w.WriteHeader(http.StatusOK)
+
 	if _, err := w.Write(dataBytes); err != nil {
-		handleError(ctx, w, err)
-		return
+		logging.From(ctx).Error("failed to write response", zap.Error(err))
 	}
-
-	w.WriteHeader(http.StatusOK)
 }
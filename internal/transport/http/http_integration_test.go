@@ -9,12 +9,34 @@ import (
 
 	"github.com/golang/mock/gomock"
 	"github.com/gorilla/mux"
+	httplistener "github.com/speakeasy-api/rest-template-go/internal/core/listeners/http"
+	"github.com/speakeasy-api/rest-template-go/internal/core/problem"
+	"github.com/speakeasy-api/rest-template-go/internal/core/requestid"
 	httptransport "github.com/speakeasy-api/rest-template-go/internal/transport/http"
 	"github.com/speakeasy-api/rest-template-go/internal/transport/http/mocks"
+	"github.com/speakeasy-api/rest-template-go/openapi"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+// newRouter returns a router that fails requests and responses that don't match the OpenAPI document,
+// so tests fail if the handlers drift from it.
+func newRouter(t *testing.T) *mux.Router {
+	t.Helper()
+
+	v, err := httplistener.NewValidator(httplistener.ValidationConfig{
+		Enabled:   true,
+		Responses: httplistener.ResponseValidationFail,
+	}, openapi.Spec)
+	require.NoError(t, err)
+
+	r := mux.NewRouter()
+	r.Use(v.Middleware)
+	r.Use(httplistener.NewCacheController(map[string]string{"getUserv1": "private, no-cache"}).Middleware)
+
+	return r
+}
+
 func TestServer_Health_Success(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -33,10 +55,10 @@ func TestServer_Health_Success(t *testing.T) {
 			u := mocks.NewMockUsers(ctrl)
 			d := mocks.NewMockDB(ctrl)
 
-			ht := httptransport.New(u, d)
+			ht := httptransport.New(u, d, mocks.NewMockStream(ctrl))
 			require.NotNil(t, ht)
 
-			r := mux.NewRouter()
+			r := newRouter(t)
 
 			err := ht.AddRoutes(r)
 			require.NoError(t, err)
@@ -63,7 +85,7 @@ func TestServer_Health_Error(t *testing.T) {
 	}{
 		{
 			name:     "fails",
-			wantErr:  "test fail",
+			wantErr:  "err_unknown: unknown error occurred",
 			wantCode: http.StatusInternalServerError,
 		},
 	}
@@ -75,10 +97,11 @@ func TestServer_Health_Error(t *testing.T) {
 			u := mocks.NewMockUsers(ctrl)
 			d := mocks.NewMockDB(ctrl)
 
-			ht := httptransport.New(u, d)
+			ht := httptransport.New(u, d, mocks.NewMockStream(ctrl))
 			require.NotNil(t, ht)
 
-			r := mux.NewRouter()
+			r := newRouter(t)
+			r.Use(requestid.Middleware)
 
 			err := ht.AddRoutes(r)
 			require.NoError(t, err)
@@ -89,18 +112,21 @@ func TestServer_Health_Error(t *testing.T) {
 
 			req, err := http.NewRequest(http.MethodGet, "/health", nil)
 			require.NoError(t, err)
+			req.Header.Set(requestid.Header, "some-request-id")
 
 			r.ServeHTTP(w, req)
 
 			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+			assert.Equal(t, "some-request-id", w.Header().Get(requestid.Header))
 
-			var res struct {
-				Error string `json:"error"`
-			}
+			var res problem.Problem
 
 			err = json.Unmarshal(w.Body.Bytes(), &res)
 			require.NoError(t, err)
-			assert.Equal(t, tt.wantErr, res.Error)
+			assert.Equal(t, tt.wantCode, res.Status)
+			assert.Equal(t, tt.wantErr, res.Detail)
+			assert.Equal(t, "some-request-id", res.Instance)
 		})
 	}
 }
@@ -1,11 +1,13 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/speakeasy-api/rest-template-go/internal/transport/http (interfaces: Users,DB)
+// Source: github.com/speakeasy-api/rest-template-go/internal/transport/http (interfaces: Users,DB,Stream,DeadLetters)
 
 // Package mocks is a generated GoMock package.
 package mocks
 
 import (
 	context "context"
+	events "github.com/speakeasy-api/rest-template-go/internal/events"
+	stream "github.com/speakeasy-api/rest-template-go/internal/events/stream"
 	model "github.com/speakeasy-api/rest-template-go/internal/users/model"
 	reflect "reflect"
 
@@ -65,18 +67,18 @@ func (mr *MockUsersMockRecorder) DeleteUser(arg0, arg1 interface{}) *gomock.Call
 }
 
 // FindUsers mocks base method.
-func (m *MockUsers) FindUsers(arg0 context.Context, arg1 []model.Filter, arg2, arg3 int64) ([]*model.User, error) {
+func (m *MockUsers) FindUsers(arg0 context.Context, arg1 []model.Filter, arg2 []model.Sort, arg3, arg4 int64) ([]*model.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindUsers", arg0, arg1, arg2, arg3)
+	ret := m.ctrl.Call(m, "FindUsers", arg0, arg1, arg2, arg3, arg4)
 	ret0, _ := ret[0].([]*model.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // FindUsers indicates an expected call of FindUsers.
-func (mr *MockUsersMockRecorder) FindUsers(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+func (mr *MockUsersMockRecorder) FindUsers(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUsers", reflect.TypeOf((*MockUsers)(nil).FindUsers), arg0, arg1, arg2, arg3)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUsers", reflect.TypeOf((*MockUsers)(nil).FindUsers), arg0, arg1, arg2, arg3, arg4)
 }
 
 // GetUser mocks base method.
@@ -145,3 +147,151 @@ func (mr *MockDBMockRecorder) PingContext(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PingContext", reflect.TypeOf((*MockDB)(nil).PingContext), arg0)
 }
+
+// MockStream is a mock of Stream interface.
+type MockStream struct {
+	ctrl     *gomock.Controller
+	recorder *MockStreamMockRecorder
+}
+
+// MockStreamMockRecorder is the mock recorder for MockStream.
+type MockStreamMockRecorder struct {
+	mock *MockStream
+}
+
+// NewMockStream creates a new mock instance.
+func NewMockStream(ctrl *gomock.Controller) *MockStream {
+	mock := &MockStream{ctrl: ctrl}
+	mock.recorder = &MockStreamMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStream) EXPECT() *MockStreamMockRecorder {
+	return m.recorder
+}
+
+// Subscribe mocks base method.
+func (m *MockStream) Subscribe(arg0 context.Context, arg1 string, arg2 stream.Filter) <-chan stream.Event {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Subscribe", arg0, arg1, arg2)
+	ret0, _ := ret[0].(<-chan stream.Event)
+	return ret0
+}
+
+// Subscribe indicates an expected call of Subscribe.
+func (mr *MockStreamMockRecorder) Subscribe(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockStream)(nil).Subscribe), arg0, arg1, arg2)
+}
+
+// MockDeadLetters is a mock of DeadLetters interface.
+type MockDeadLetters struct {
+	ctrl     *gomock.Controller
+	recorder *MockDeadLettersMockRecorder
+}
+
+// MockDeadLettersMockRecorder is the mock recorder for MockDeadLetters.
+type MockDeadLettersMockRecorder struct {
+	mock *MockDeadLetters
+}
+
+// NewMockDeadLetters creates a new mock instance.
+func NewMockDeadLetters(ctrl *gomock.Controller) *MockDeadLetters {
+	mock := &MockDeadLetters{ctrl: ctrl}
+	mock.recorder = &MockDeadLettersMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDeadLetters) EXPECT() *MockDeadLettersMockRecorder {
+	return m.recorder
+}
+
+// DiscardDeadLetter mocks base method.
+func (m *MockDeadLetters) DiscardDeadLetter(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DiscardDeadLetter", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DiscardDeadLetter indicates an expected call of DiscardDeadLetter.
+func (mr *MockDeadLettersMockRecorder) DiscardDeadLetter(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiscardDeadLetter", reflect.TypeOf((*MockDeadLetters)(nil).DiscardDeadLetter), arg0, arg1)
+}
+
+// DiscardDeadLetters mocks base method.
+func (m *MockDeadLetters) DiscardDeadLetters(arg0 context.Context, arg1 events.DeadLetterFilter) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DiscardDeadLetters", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DiscardDeadLetters indicates an expected call of DiscardDeadLetters.
+func (mr *MockDeadLettersMockRecorder) DiscardDeadLetters(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiscardDeadLetters", reflect.TypeOf((*MockDeadLetters)(nil).DiscardDeadLetters), arg0, arg1)
+}
+
+// FindDeadLetters mocks base method.
+func (m *MockDeadLetters) FindDeadLetters(arg0 context.Context, arg1 events.DeadLetterFilter, arg2, arg3 int64) ([]*events.DeadLetter, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindDeadLetters", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]*events.DeadLetter)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindDeadLetters indicates an expected call of FindDeadLetters.
+func (mr *MockDeadLettersMockRecorder) FindDeadLetters(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindDeadLetters", reflect.TypeOf((*MockDeadLetters)(nil).FindDeadLetters), arg0, arg1, arg2, arg3)
+}
+
+// GetDeadLetter mocks base method.
+func (m *MockDeadLetters) GetDeadLetter(arg0 context.Context, arg1 string) (*events.DeadLetter, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeadLetter", arg0, arg1)
+	ret0, _ := ret[0].(*events.DeadLetter)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeadLetter indicates an expected call of GetDeadLetter.
+func (mr *MockDeadLettersMockRecorder) GetDeadLetter(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeadLetter", reflect.TypeOf((*MockDeadLetters)(nil).GetDeadLetter), arg0, arg1)
+}
+
+// RedriveDeadLetter mocks base method.
+func (m *MockDeadLetters) RedriveDeadLetter(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RedriveDeadLetter", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RedriveDeadLetter indicates an expected call of RedriveDeadLetter.
+func (mr *MockDeadLettersMockRecorder) RedriveDeadLetter(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RedriveDeadLetter", reflect.TypeOf((*MockDeadLetters)(nil).RedriveDeadLetter), arg0, arg1)
+}
+
+// RedriveDeadLetters mocks base method.
+func (m *MockDeadLetters) RedriveDeadLetters(arg0 context.Context, arg1 events.DeadLetterFilter) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RedriveDeadLetters", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RedriveDeadLetters indicates an expected call of RedriveDeadLetters.
+func (mr *MockDeadLettersMockRecorder) RedriveDeadLetters(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RedriveDeadLetters", reflect.TypeOf((*MockDeadLetters)(nil).RedriveDeadLetters), arg0, arg1)
+}
@@ -0,0 +1,98 @@
+package http_test
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/speakeasy-api/rest-template-go/internal/core/metrics"
+	docstransport "github.com/speakeasy-api/rest-template-go/internal/transport/docs"
+	graphqltransport "github.com/speakeasy-api/rest-template-go/internal/transport/graphql"
+	graphqlmocks "github.com/speakeasy-api/rest-template-go/internal/transport/graphql/mocks"
+	httptransport "github.com/speakeasy-api/rest-template-go/internal/transport/http"
+	"github.com/speakeasy-api/rest-template-go/internal/transport/http/mocks"
+	"github.com/speakeasy-api/rest-template-go/openapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unvalidatedRoutes are the routes served alongside the API that aren't described by the OpenAPI document, so
+// requests to them are not validated. GraphQL queries are validated against its schema, the docs serve the document
+// itself, metrics are scraped by Prometheus and the admin API is served on its own listener.
+var unvalidatedRoutes = []string{
+	"GET /docs",
+	"GET /docs/",
+	"GET /metrics",
+	"GET /openapi.json",
+	"GET /openapi.yaml",
+	"POST /graphql",
+	"DELETE /v1/admin/deadletters/{id}",
+	"GET /v1/admin/deadletters/{id}",
+	"POST /v1/admin/deadletters/discard",
+	"POST /v1/admin/deadletters/redrive",
+	"POST /v1/admin/deadletters/search",
+	"POST /v1/admin/deadletters/{id}/redrive",
+}
+
+// TestServices_AddRoutes_MatchSpec checks every route of the services the server registers is either described by
+// the OpenAPI document or explicitly listed as not validated, and every operation in the document is served.
+func TestServices_AddRoutes_MatchSpec(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	graphqlServer, err := graphqltransport.New(graphqltransport.Config{}, graphqlmocks.NewMockUsers(ctrl))
+	require.NoError(t, err)
+
+	docsServer, err := docstransport.New(docstransport.Config{})
+	require.NoError(t, err)
+
+	services := []interface{ AddRoutes(r *mux.Router) error }{
+		httptransport.New(mocks.NewMockUsers(ctrl), mocks.NewMockDB(ctrl), mocks.NewMockStream(ctrl)),
+		graphqlServer,
+		docsServer,
+		metrics.Service{},
+		httptransport.NewAdmin(httptransport.AdminConfig{}, mocks.NewMockDeadLetters(ctrl)),
+	}
+
+	r := mux.NewRouter()
+	for _, s := range services {
+		require.NoError(t, s.AddRoutes(r))
+	}
+
+	routes := []string{}
+
+	err = r.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+
+		// Subrouters are registered as routes without methods so are skipped
+		methods, _ := route.GetMethods()
+		for _, m := range methods {
+			routes = append(routes, m+" "+path)
+		}
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	doc, err := openapi3.NewLoader().LoadFromData(openapi.Spec)
+	require.NoError(t, err)
+
+	operations := append([]string{}, unvalidatedRoutes...)
+
+	for path, item := range doc.Paths {
+		for m := range item.Operations() {
+			operations = append(operations, strings.ToUpper(m)+" "+path)
+		}
+	}
+
+	sort.Strings(routes)
+	sort.Strings(operations)
+
+	assert.Equal(t, operations, routes)
+}
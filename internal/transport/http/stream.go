@@ -0,0 +1,101 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	"github.com/speakeasy-api/rest-template-go/internal/core/logging"
+	"github.com/speakeasy-api/rest-template-go/internal/events"
+	"github.com/speakeasy-api/rest-template-go/internal/events/stream"
+	"go.uber.org/zap"
+)
+
+const (
+	// ErrStreamingUnsupported is returned when the response writer can't be flushed to stream events.
+	ErrStreamingUnsupported = errors.Error("streaming_unsupported: response does not support streaming")
+	// ErrInvalidEventType is returned when filtering a stream by an unknown event type.
+	ErrInvalidEventType = errors.Error("invalid_event_type: invalid event type")
+)
+
+// heartbeatInterval is how often a comment is sent on idle streams to stop proxies closing the connection.
+var heartbeatInterval = 15 * time.Second
+
+func (s *Server) streamUsers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		handleError(ctx, w, ErrStreamingUnsupported.Wrap(errors.ErrUnknown))
+		return
+	}
+
+	filter, err := parseStreamFilter(r.URL.Query())
+	if err != nil {
+		handleError(ctx, w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	subscription := s.stream.Subscribe(ctx, r.Header.Get("Last-Event-ID"), filter)
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-subscription:
+			if !ok {
+				// Either the client went away or fell too far behind, in which case it will reconnect and resume
+				return
+			}
+
+			data, err := json.Marshal(e.UserEvent)
+			if err != nil {
+				logging.From(ctx).Error("failed to serialize event", zap.Error(err), zap.String("event_id", e.ID))
+				continue
+			}
+
+			if _, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", e.ID, e.UserEvent.EventType, data); err != nil {
+				logging.From(ctx).Error("failed to write event", zap.Error(err))
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				logging.From(ctx).Error("failed to write heartbeat", zap.Error(err))
+				return
+			}
+		}
+
+		flusher.Flush()
+	}
+}
+
+func parseStreamFilter(query url.Values) (stream.Filter, error) {
+	filter := stream.Filter{
+		IDs: query["id"],
+	}
+
+	for _, t := range query["event_type"] {
+		eventType := events.EventType(t)
+
+		switch eventType {
+		case events.EventTypeUserCreated, events.EventTypeUserUpdated, events.EventTypeUserDeleted:
+			filter.EventTypes = append(filter.EventTypes, eventType)
+		default:
+			return stream.Filter{}, errors.WithFieldErrors(ErrInvalidEventType.Wrap(errors.ErrValidation), ErrInvalidEventType.Field("event_type"))
+		}
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,100 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/speakeasy-api/rest-template-go/internal/events"
+	"github.com/speakeasy-api/rest-template-go/internal/events/stream"
+	httptransport "github.com/speakeasy-api/rest-template-go/internal/transport/http"
+	"github.com/speakeasy-api/rest-template-go/internal/transport/http/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_StreamUsers(t *testing.T) {
+	event := events.UserEvent{
+		EventType:     events.EventTypeUserDeleted,
+		ID:            "some-user-id",
+		ChangedFields: []string{},
+	}
+	eventData, err := json.Marshal(event)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name            string
+		url             string
+		lastEventID     string
+		wantFilter      stream.Filter
+		wantLastEventID string
+		wantCode        int
+		wantBody        string
+	}{
+		{
+			name:     "success",
+			url:      "/v1/users/stream",
+			wantCode: http.StatusOK,
+			wantBody: "id: some-event-id\nevent: user_deleted\ndata: " + string(eventData) + "\n\n",
+		},
+		{
+			name:            "filtered and resumed",
+			url:             "/v1/users/stream?event_type=user_deleted&event_type=user_updated&id=some-user-id",
+			lastEventID:     "some-previous-event-id",
+			wantFilter:      stream.Filter{EventTypes: []events.EventType{events.EventTypeUserDeleted, events.EventTypeUserUpdated}, IDs: []string{"some-user-id"}},
+			wantLastEventID: "some-previous-event-id",
+			wantCode:        http.StatusOK,
+			wantBody:        "id: some-event-id\nevent: user_deleted\ndata: " + string(eventData) + "\n\n",
+		},
+		{
+			// Unknown event types are rejected by the spec before reaching the handler
+			name:     "invalid event type",
+			url:      "/v1/users/stream?event_type=user_exploded",
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"type":"urn:problem-type:err_invalid_request","title":"invalid request received","status":400,"detail":"err_invalid_request: invalid request received",` +
+				`"errors":[{"field":"event_type","code":"invalid_parameter","message":"value is not one of the allowed values"}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			u := mocks.NewMockUsers(ctrl)
+			d := mocks.NewMockDB(ctrl)
+			s := mocks.NewMockStream(ctrl)
+
+			ht := httptransport.New(u, d, s)
+
+			r := newRouter(t)
+			require.NoError(t, ht.AddRoutes(r))
+
+			if tt.wantCode == http.StatusOK {
+				// A closed subscription ends the stream so the response can be inspected
+				subscription := make(chan stream.Event, 1)
+				subscription <- stream.Event{ID: "some-event-id", UserEvent: event}
+				close(subscription)
+
+				s.EXPECT().Subscribe(gomock.Any(), tt.wantLastEventID, tt.wantFilter).Return((<-chan stream.Event)(subscription)).Times(1)
+			}
+
+			req, err := http.NewRequest(http.MethodGet, tt.url, nil)
+			require.NoError(t, err)
+			if tt.lastEventID != "" {
+				req.Header.Set("Last-Event-ID", tt.lastEventID)
+			}
+
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Equal(t, tt.wantBody, w.Body.String())
+			if tt.wantCode == http.StatusOK {
+				assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
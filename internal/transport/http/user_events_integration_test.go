@@ -0,0 +1,116 @@
+package http_test
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/AlekSi/pointer"
+	"github.com/golang/mock/gomock"
+	coreerrors "github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	"github.com/speakeasy-api/rest-template-go/internal/events"
+	"github.com/speakeasy-api/rest-template-go/internal/events/eventstest"
+	httptransport "github.com/speakeasy-api/rest-template-go/internal/transport/http"
+	"github.com/speakeasy-api/rest-template-go/internal/transport/http/mocks"
+	"github.com/speakeasy-api/rest-template-go/internal/users"
+	usersmocks "github.com/speakeasy-api/rest-template-go/internal/users/mocks"
+	"github.com/speakeasy-api/rest-template-go/internal/users/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_UserEvents(t *testing.T) {
+	user := &model.User{
+		ID:        pointer.ToString("some-test-id"),
+		FirstName: pointer.ToString("testFirst"),
+		Password:  pointer.ToString("test"),
+		Email:     pointer.ToString("test@test.com"),
+	}
+
+	tests := []struct {
+		name      string
+		method    string
+		url       string
+		body      string
+		setup     func(s *usersmocks.MockStore)
+		wantCode  int
+		wantEvent events.EventType
+	}{
+		{
+			name:   "create produces event",
+			method: http.MethodPost,
+			url:    baseUserURL,
+			body:   `{"first_name":"testFirst","password":"test","email":"test@test.com"}`,
+			setup: func(s *usersmocks.MockStore) {
+				s.EXPECT().InsertUser(gomock.Any(), gomock.Any()).Return(user, nil).Times(1)
+			},
+			wantCode:  http.StatusOK,
+			wantEvent: events.EventTypeUserCreated,
+		},
+		{
+			name:   "delete produces event",
+			method: http.MethodDelete,
+			url:    fmt.Sprintf(userURL, "some-test-id"),
+			setup: func(s *usersmocks.MockStore) {
+				s.EXPECT().DeleteUser(gomock.Any(), "some-test-id").Return(user, nil).Times(1)
+			},
+			wantCode:  http.StatusOK,
+			wantEvent: events.EventTypeUserDeleted,
+		},
+		{
+			name:     "invalid request produces no events",
+			method:   http.MethodPost,
+			url:      baseUserURL,
+			body:     `not json`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:   "failed delete produces no events",
+			method: http.MethodDelete,
+			url:    fmt.Sprintf(userURL, "some-test-id"),
+			setup: func(s *usersmocks.MockStore) {
+				s.EXPECT().DeleteUser(gomock.Any(), "some-test-id").Return(nil, coreerrors.ErrNotFound).Times(1)
+			},
+			wantCode: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := usersmocks.NewMockStore(ctrl)
+			b := eventstest.New(t)
+
+			if tt.setup != nil {
+				tt.setup(s)
+			}
+
+			ht := httptransport.New(users.New(s, b), mocks.NewMockDB(ctrl), mocks.NewMockStream(ctrl))
+
+			r := newRouter(t)
+			require.NoError(t, ht.AddRoutes(r))
+
+			req, err := http.NewRequest(tt.method, tt.url, bytes.NewBufferString(tt.body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+
+			if tt.wantEvent != "" {
+				e := b.AwaitUserEvent(eventstest.IsUserEvent(tt.wantEvent, "some-test-id"), time.Second)
+				if e.After != nil {
+					assert.Nil(t, e.After.Password)
+				}
+			}
+			b.AssertNoEvents(events.TopicUsers)
+		})
+	}
+}
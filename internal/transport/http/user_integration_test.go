@@ -12,10 +12,12 @@ import (
 
 	"github.com/AlekSi/pointer"
 	"github.com/golang/mock/gomock"
-	"github.com/gorilla/mux"
+	coreerrors "github.com/speakeasy-api/rest-template-go/internal/core/errors"
+	"github.com/speakeasy-api/rest-template-go/internal/core/problem"
 	httptransport "github.com/speakeasy-api/rest-template-go/internal/transport/http"
 	"github.com/speakeasy-api/rest-template-go/internal/transport/http/mocks"
 	"github.com/speakeasy-api/rest-template-go/internal/users/model"
+	"github.com/speakeasy-api/rest-template-go/internal/users/store"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -70,10 +72,10 @@ func TestServer_CreateUser_Success(t *testing.T) {
 			u := mocks.NewMockUsers(ctrl)
 			d := mocks.NewMockDB(ctrl)
 
-			ht := httptransport.New(u, d)
+			ht := httptransport.New(u, d, mocks.NewMockStream(ctrl))
 			require.NotNil(t, ht)
 
-			r := mux.NewRouter()
+			r := newRouter(t)
 
 			err := ht.AddRoutes(r)
 			require.NoError(t, err)
@@ -88,6 +90,7 @@ func TestServer_CreateUser_Success(t *testing.T) {
 
 			req, err := http.NewRequest(http.MethodPost, baseUserURL, bytes.NewBuffer(data))
 			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
 
 			r.ServeHTTP(w, req)
 
@@ -109,10 +112,12 @@ func TestServer_CreateUser_Error(t *testing.T) {
 		user model.User
 	}
 	tests := []struct {
-		name     string
-		args     args
-		wantErr  string
-		wantCode int
+		name       string
+		args       args
+		err        error
+		wantErr    string
+		wantErrors []coreerrors.FieldError
+		wantCode   int
 	}{
 		{
 			name: "fails",
@@ -126,9 +131,22 @@ func TestServer_CreateUser_Error(t *testing.T) {
 					Country:   pointer.ToString("UK"),
 				},
 			},
-			wantErr:  "test fail",
+			err:      errors.New("test fail"),
+			wantErr:  "err_unknown: unknown error occurred",
 			wantCode: http.StatusInternalServerError,
 		},
+		{
+			name: "fails with email already used",
+			args: args{
+				user: model.User{
+					Email: pointer.ToString("test@test.com"),
+				},
+			},
+			err:        coreerrors.WithFieldErrors(store.ErrEmailAlreadyUsed.Structured(coreerrors.CategoryConflict), store.ErrEmailAlreadyUsed.Field("email")),
+			wantErr:    "email_already_used: email is already in use",
+			wantErrors: []coreerrors.FieldError{store.ErrEmailAlreadyUsed.Field("email")},
+			wantCode:   http.StatusConflict,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -138,17 +156,17 @@ func TestServer_CreateUser_Error(t *testing.T) {
 			u := mocks.NewMockUsers(ctrl)
 			d := mocks.NewMockDB(ctrl)
 
-			ht := httptransport.New(u, d)
+			ht := httptransport.New(u, d, mocks.NewMockStream(ctrl))
 			require.NotNil(t, ht)
 
-			r := mux.NewRouter()
+			r := newRouter(t)
 
 			err := ht.AddRoutes(r)
 			require.NoError(t, err)
 
 			w := httptest.NewRecorder()
 
-			u.EXPECT().CreateUser(gomock.Any(), &tt.args.user).Return(nil, errors.New(tt.wantErr)).Times(1)
+			u.EXPECT().CreateUser(gomock.Any(), &tt.args.user).Return(nil, tt.err).Times(1)
 
 			data, err := json.Marshal(tt.args.user)
 			require.NoError(t, err)
@@ -156,18 +174,20 @@ func TestServer_CreateUser_Error(t *testing.T) {
 
 			req, err := http.NewRequest(http.MethodPost, baseUserURL, bytes.NewBuffer(data))
 			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
 
 			r.ServeHTTP(w, req)
 
 			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
 
-			var res struct {
-				Error string `json:"error"`
-			}
+			var res problem.Problem
 
 			err = json.Unmarshal(w.Body.Bytes(), &res)
 			require.NoError(t, err)
-			assert.Equal(t, tt.wantErr, res.Error)
+			assert.Equal(t, tt.wantCode, res.Status)
+			assert.Equal(t, tt.wantErr, res.Detail)
+			assert.Equal(t, tt.wantErrors, res.Errors)
 		})
 	}
 }
@@ -209,10 +229,10 @@ func TestServer_GetUser_Success(t *testing.T) {
 			u := mocks.NewMockUsers(ctrl)
 			d := mocks.NewMockDB(ctrl)
 
-			ht := httptransport.New(u, d)
+			ht := httptransport.New(u, d, mocks.NewMockStream(ctrl))
 			require.NotNil(t, ht)
 
-			r := mux.NewRouter()
+			r := newRouter(t)
 
 			err := ht.AddRoutes(r)
 			require.NoError(t, err)
@@ -254,7 +274,7 @@ func TestServer_GetUser_Error(t *testing.T) {
 			args: args{
 				id: "some-test-id",
 			},
-			wantErr:  "test fail",
+			wantErr:  "err_unknown: unknown error occurred",
 			wantCode: http.StatusInternalServerError,
 		},
 	}
@@ -266,10 +286,10 @@ func TestServer_GetUser_Error(t *testing.T) {
 			u := mocks.NewMockUsers(ctrl)
 			d := mocks.NewMockDB(ctrl)
 
-			ht := httptransport.New(u, d)
+			ht := httptransport.New(u, d, mocks.NewMockStream(ctrl))
 			require.NotNil(t, ht)
 
-			r := mux.NewRouter()
+			r := newRouter(t)
 
 			err := ht.AddRoutes(r)
 			require.NoError(t, err)
@@ -284,14 +304,145 @@ func TestServer_GetUser_Error(t *testing.T) {
 			r.ServeHTTP(w, req)
 
 			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+			assert.Empty(t, w.Header().Get("Cache-Control"))
+			assert.Empty(t, w.Header().Get("ETag"))
 
-			var res struct {
-				Error string `json:"error"`
-			}
+			var res problem.Problem
 
 			err = json.Unmarshal(w.Body.Bytes(), &res)
 			require.NoError(t, err)
-			assert.Equal(t, tt.wantErr, res.Error)
+			assert.Equal(t, tt.wantCode, res.Status)
+			assert.Equal(t, tt.wantErr, res.Detail)
+		})
+	}
+}
+
+func TestServer_GetUser_Conditional(t *testing.T) {
+	user := model.User{
+		ID:        pointer.ToString("some-test-id"),
+		FirstName: pointer.ToString("testFirst"),
+		Email:     pointer.ToString("test@test.com"),
+		CreatedAt: pointer.ToTime(time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)),
+		UpdatedAt: pointer.ToTime(time.Date(2020, time.January, 1, 12, 30, 15, 500, time.UTC)),
+	}
+
+	tests := []struct {
+		name            string
+		ifNoneMatch     func(etag string) string
+		ifModifiedSince string
+		accept          string
+		wantCode        int
+	}{
+		{
+			name:     "unconditional",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:        "current etag",
+			ifNoneMatch: func(etag string) string { return etag },
+			wantCode:    http.StatusNotModified,
+		},
+		{
+			name:        "weak current etag",
+			ifNoneMatch: func(etag string) string { return "W/" + etag },
+			wantCode:    http.StatusNotModified,
+		},
+		{
+			name:        "current etag in list",
+			ifNoneMatch: func(etag string) string { return `"stale", ` + etag },
+			wantCode:    http.StatusNotModified,
+		},
+		{
+			name:        "any etag",
+			ifNoneMatch: func(string) string { return "*" },
+			wantCode:    http.StatusNotModified,
+		},
+		{
+			name:        "stale etag",
+			ifNoneMatch: func(string) string { return `"stale"` },
+			wantCode:    http.StatusOK,
+		},
+		{
+			name:        "etag of another representation",
+			ifNoneMatch: func(etag string) string { return etag },
+			accept:      "application/msgpack",
+			wantCode:    http.StatusOK,
+		},
+		{
+			name:            "not modified since last modified",
+			ifModifiedSince: "Wed, 01 Jan 2020 12:30:15 GMT",
+			wantCode:        http.StatusNotModified,
+		},
+		{
+			name:            "modified since",
+			ifModifiedSince: "Wed, 01 Jan 2020 12:30:14 GMT",
+			wantCode:        http.StatusOK,
+		},
+		{
+			name:            "invalid if modified since",
+			ifModifiedSince: "yesterday",
+			wantCode:        http.StatusOK,
+		},
+		{
+			name:            "etag takes precedence over modified since",
+			ifNoneMatch:     func(string) string { return `"stale"` },
+			ifModifiedSince: "Wed, 01 Jan 2020 12:30:15 GMT",
+			wantCode:        http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			u := mocks.NewMockUsers(ctrl)
+
+			ht := httptransport.New(u, mocks.NewMockDB(ctrl), mocks.NewMockStream(ctrl))
+
+			r := newRouter(t)
+			require.NoError(t, ht.AddRoutes(r))
+
+			u.EXPECT().GetUser(gomock.Any(), *user.ID).Return(&user, nil).Times(2)
+
+			// Fetch the current ETag of the JSON representation as a client would
+			req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(userURL, *user.ID), nil)
+			require.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			require.Equal(t, http.StatusOK, w.Code)
+
+			etag := w.Header().Get("ETag")
+			require.NotEmpty(t, etag)
+
+			req, err = http.NewRequest(http.MethodGet, fmt.Sprintf(userURL, *user.ID), nil)
+			require.NoError(t, err)
+			if tt.ifNoneMatch != nil {
+				req.Header.Set("If-None-Match", tt.ifNoneMatch(etag))
+			}
+			if tt.ifModifiedSince != "" {
+				req.Header.Set("If-Modified-Since", tt.ifModifiedSince)
+			}
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+
+			w = httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Equal(t, "Wed, 01 Jan 2020 12:30:15 GMT", w.Header().Get("Last-Modified"))
+			assert.Equal(t, "private, no-cache", w.Header().Get("Cache-Control"))
+			// Caches revalidating with the ETag must also key on Accept as each encoding has its own ETag
+			assert.Contains(t, w.Header().Values("Vary"), "Accept")
+
+			if tt.wantCode == http.StatusNotModified {
+				assert.Equal(t, etag, w.Header().Get("ETag"))
+				assert.Empty(t, w.Body.Bytes())
+			} else {
+				assert.NotEmpty(t, w.Body.Bytes())
+			}
 		})
 	}
 }
@@ -343,17 +494,17 @@ func TestServer_SearchUsers_Success(t *testing.T) {
 			u := mocks.NewMockUsers(ctrl)
 			d := mocks.NewMockDB(ctrl)
 
-			ht := httptransport.New(u, d)
+			ht := httptransport.New(u, d, mocks.NewMockStream(ctrl))
 			require.NotNil(t, ht)
 
-			r := mux.NewRouter()
+			r := newRouter(t)
 
 			err := ht.AddRoutes(r)
 			require.NoError(t, err)
 
 			w := httptest.NewRecorder()
 
-			u.EXPECT().FindUsers(gomock.Any(), tt.args.filters, tt.args.offset, tt.args.limit).Return(tt.wantUsers, nil).Times(1)
+			u.EXPECT().FindUsers(gomock.Any(), tt.args.filters, nil, tt.args.offset, tt.args.limit).Return(tt.wantUsers, nil).Times(1)
 
 			data, err := json.Marshal(httptransport.SearchUsersRequest{Filters: tt.args.filters, Offset: tt.args.offset, Limit: tt.args.limit})
 			require.NoError(t, err)
@@ -361,6 +512,7 @@ func TestServer_SearchUsers_Success(t *testing.T) {
 
 			req, err := http.NewRequest(http.MethodPost, searchURL, bytes.NewBuffer(data))
 			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
 
 			r.ServeHTTP(w, req)
 
@@ -400,7 +552,7 @@ func TestServer_SearchUsers_Error(t *testing.T) {
 					},
 				},
 			},
-			wantErr:  "test fail",
+			wantErr:  "err_unknown: unknown error occurred",
 			wantCode: http.StatusInternalServerError,
 		},
 	}
@@ -412,17 +564,17 @@ func TestServer_SearchUsers_Error(t *testing.T) {
 			u := mocks.NewMockUsers(ctrl)
 			d := mocks.NewMockDB(ctrl)
 
-			ht := httptransport.New(u, d)
+			ht := httptransport.New(u, d, mocks.NewMockStream(ctrl))
 			require.NotNil(t, ht)
 
-			r := mux.NewRouter()
+			r := newRouter(t)
 
 			err := ht.AddRoutes(r)
 			require.NoError(t, err)
 
 			w := httptest.NewRecorder()
 
-			u.EXPECT().FindUsers(gomock.Any(), tt.args.filters, tt.args.offset, tt.args.limit).Return(nil, errors.New(tt.wantErr)).Times(1)
+			u.EXPECT().FindUsers(gomock.Any(), tt.args.filters, nil, tt.args.offset, tt.args.limit).Return(nil, errors.New(tt.wantErr)).Times(1)
 
 			data, err := json.Marshal(httptransport.SearchUsersRequest{Filters: tt.args.filters, Offset: tt.args.offset, Limit: tt.args.limit})
 			require.NoError(t, err)
@@ -430,18 +582,19 @@ func TestServer_SearchUsers_Error(t *testing.T) {
 
 			req, err := http.NewRequest(http.MethodPost, searchURL, bytes.NewBuffer(data))
 			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
 
 			r.ServeHTTP(w, req)
 
 			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
 
-			var res struct {
-				Error string `json:"error"`
-			}
+			var res problem.Problem
 
 			err = json.Unmarshal(w.Body.Bytes(), &res)
 			require.NoError(t, err)
-			assert.Equal(t, tt.wantErr, res.Error)
+			assert.Equal(t, tt.wantCode, res.Status)
+			assert.Equal(t, tt.wantErr, res.Detail)
 		})
 	}
 }
@@ -491,10 +644,10 @@ func TestServer_UpdateUser_Success(t *testing.T) {
 			u := mocks.NewMockUsers(ctrl)
 			d := mocks.NewMockDB(ctrl)
 
-			ht := httptransport.New(u, d)
+			ht := httptransport.New(u, d, mocks.NewMockStream(ctrl))
 			require.NotNil(t, ht)
 
-			r := mux.NewRouter()
+			r := newRouter(t)
 
 			err := ht.AddRoutes(r)
 			require.NoError(t, err)
@@ -503,12 +656,17 @@ func TestServer_UpdateUser_Success(t *testing.T) {
 
 			u.EXPECT().UpdateUser(gomock.Any(), &tt.args.user).Return(tt.wantUser, nil).Times(1)
 
-			data, err := json.Marshal(tt.args.user)
+			// The id is read only so only sent in the path
+			body := tt.args.user
+			body.ID = nil
+
+			data, err := json.Marshal(body)
 			require.NoError(t, err)
 			require.NotNil(t, data)
 
 			req, err := http.NewRequest(http.MethodPut, fmt.Sprintf(userURL, *tt.args.user.ID), bytes.NewBuffer(data))
 			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
 
 			r.ServeHTTP(w, req)
 
@@ -530,10 +688,12 @@ func TestServer_UpdateUser_Error(t *testing.T) {
 		user model.User
 	}
 	tests := []struct {
-		name     string
-		args     args
-		wantErr  string
-		wantCode int
+		name       string
+		args       args
+		err        error
+		wantErr    string
+		wantErrors []coreerrors.FieldError
+		wantCode   int
 	}{
 		{
 			name: "fails",
@@ -548,9 +708,23 @@ func TestServer_UpdateUser_Error(t *testing.T) {
 					Country:   pointer.ToString("UK"),
 				},
 			},
-			wantErr:  "test fail",
+			err:      errors.New("test fail"),
+			wantErr:  "err_unknown: unknown error occurred",
 			wantCode: http.StatusInternalServerError,
 		},
+		{
+			name: "fails with email already used",
+			args: args{
+				user: model.User{
+					ID:    pointer.ToString("some-test-id"),
+					Email: pointer.ToString("test@test.com"),
+				},
+			},
+			err:        coreerrors.WithFieldErrors(store.ErrEmailAlreadyUsed.Structured(coreerrors.CategoryConflict), store.ErrEmailAlreadyUsed.Field("email")),
+			wantErr:    "email_already_used: email is already in use",
+			wantErrors: []coreerrors.FieldError{store.ErrEmailAlreadyUsed.Field("email")},
+			wantCode:   http.StatusConflict,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -560,36 +734,42 @@ func TestServer_UpdateUser_Error(t *testing.T) {
 			u := mocks.NewMockUsers(ctrl)
 			d := mocks.NewMockDB(ctrl)
 
-			ht := httptransport.New(u, d)
+			ht := httptransport.New(u, d, mocks.NewMockStream(ctrl))
 			require.NotNil(t, ht)
 
-			r := mux.NewRouter()
+			r := newRouter(t)
 
 			err := ht.AddRoutes(r)
 			require.NoError(t, err)
 
 			w := httptest.NewRecorder()
 
-			u.EXPECT().UpdateUser(gomock.Any(), &tt.args.user).Return(nil, errors.New(tt.wantErr)).Times(1)
+			u.EXPECT().UpdateUser(gomock.Any(), &tt.args.user).Return(nil, tt.err).Times(1)
 
-			data, err := json.Marshal(tt.args.user)
+			// The id is read only so only sent in the path
+			body := tt.args.user
+			body.ID = nil
+
+			data, err := json.Marshal(body)
 			require.NoError(t, err)
 			require.NotNil(t, data)
 
 			req, err := http.NewRequest(http.MethodPut, fmt.Sprintf(userURL, *tt.args.user.ID), bytes.NewBuffer(data))
 			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
 
 			r.ServeHTTP(w, req)
 
 			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
 
-			var res struct {
-				Error string `json:"error"`
-			}
+			var res problem.Problem
 
 			err = json.Unmarshal(w.Body.Bytes(), &res)
 			require.NoError(t, err)
-			assert.Equal(t, tt.wantErr, res.Error)
+			assert.Equal(t, tt.wantCode, res.Status)
+			assert.Equal(t, tt.wantErr, res.Detail)
+			assert.Equal(t, tt.wantErrors, res.Errors)
 		})
 	}
 }
@@ -621,10 +801,10 @@ func TestServer_DeleteUser_Success(t *testing.T) {
 			u := mocks.NewMockUsers(ctrl)
 			d := mocks.NewMockDB(ctrl)
 
-			ht := httptransport.New(u, d)
+			ht := httptransport.New(u, d, mocks.NewMockStream(ctrl))
 			require.NotNil(t, ht)
 
-			r := mux.NewRouter()
+			r := newRouter(t)
 
 			err := ht.AddRoutes(r)
 			require.NoError(t, err)
@@ -666,7 +846,7 @@ func TestServer_DeleteUser_Error(t *testing.T) {
 			args: args{
 				id: "some-test-id",
 			},
-			wantErr:  "test fail",
+			wantErr:  "err_unknown: unknown error occurred",
 			wantCode: http.StatusInternalServerError,
 		},
 	}
@@ -678,10 +858,10 @@ func TestServer_DeleteUser_Error(t *testing.T) {
 			u := mocks.NewMockUsers(ctrl)
 			d := mocks.NewMockDB(ctrl)
 
-			ht := httptransport.New(u, d)
+			ht := httptransport.New(u, d, mocks.NewMockStream(ctrl))
 			require.NotNil(t, ht)
 
-			r := mux.NewRouter()
+			r := newRouter(t)
 
 			err := ht.AddRoutes(r)
 			require.NoError(t, err)
@@ -696,14 +876,14 @@ func TestServer_DeleteUser_Error(t *testing.T) {
 			r.ServeHTTP(w, req)
 
 			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
 
-			var res struct {
-				Error string `json:"error"`
-			}
+			var res problem.Problem
 
 			err = json.Unmarshal(w.Body.Bytes(), &res)
 			require.NoError(t, err)
-			assert.Equal(t, tt.wantErr, res.Error)
+			assert.Equal(t, tt.wantCode, res.Status)
+			assert.Equal(t, tt.wantErr, res.Detail)
 		})
 	}
 }
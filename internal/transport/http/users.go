@@ -1,12 +1,9 @@
 package http
 
 import (
-	"encoding/json"
-	"io"
 	"net/http"
 
 	"github.com/gorilla/mux"
-	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
 	"github.com/speakeasy-api/rest-template-go/internal/core/logging"
 	"github.com/speakeasy-api/rest-template-go/internal/users/model"
 	"go.uber.org/zap"
@@ -25,20 +22,11 @@ type deletedUserResponse struct {
 func (s *Server) createUser(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	w.Header().Add("Content-Type", "application/json") // TODO might do this in application specific middleware instead
-
-	data, err := io.ReadAll(r.Body)
-	if err != nil {
-		logging.From(ctx).Error("failed to read request body", zap.Error(err))
-		handleError(ctx, w, errors.ErrUnknown.Wrap(err))
-		return
-	}
-
 	u := model.User{}
 
-	if err := json.Unmarshal(data, &u); err != nil {
-		logging.From(ctx).Error("failed to unmarshal json body", zap.Error(err))
-		handleError(ctx, w, errors.ErrInvalidRequest.Wrap(err))
+	if err := decodeBody(r, &u, userReadOnlyFields...); err != nil {
+		logging.From(ctx).Error("failed to decode request body", zap.Error(err))
+		handleError(ctx, w, err)
 		return
 	}
 
@@ -56,8 +44,6 @@ func (s *Server) createUser(w http.ResponseWriter, r *http.Request) {
 func (s *Server) getUser(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	w.Header().Add("Content-Type", "application/json") // TODO might do this in application specific middleware instead
-
 	vars := mux.Vars(r)
 	id := vars["id"]
 
@@ -68,30 +54,31 @@ func (s *Server) getUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if u.UpdatedAt != nil {
+		etag := userETag(u, encoderFrom(ctx).MediaType())
+		setValidators(w, etag, *u.UpdatedAt)
+
+		if notModified(r, etag, *u.UpdatedAt) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
 	handleResponse(ctx, w, u)
 }
 
 func (s *Server) searchUsers(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	w.Header().Add("Content-Type", "application/json") // TODO might do this in application specific middleware instead
-
-	data, err := io.ReadAll(r.Body)
-	if err != nil {
-		logging.From(ctx).Error("failed to read request body", zap.Error(err))
-		handleError(ctx, w, errors.ErrUnknown.Wrap(err))
-		return
-	}
-
 	req := searchUsersRequest{}
 
-	if err := json.Unmarshal(data, &req); err != nil {
-		logging.From(ctx).Error("failed to unmarshal json body", zap.Error(err))
-		handleError(ctx, w, errors.ErrInvalidRequest.Wrap(err))
+	if err := decodeBody(r, &req); err != nil {
+		logging.From(ctx).Error("failed to decode request body", zap.Error(err))
+		handleError(ctx, w, err)
 		return
 	}
 
-	users, err := s.users.FindUsers(ctx, req.Filters, req.Offset, req.Limit)
+	users, err := s.users.FindUsers(ctx, req.Filters, nil, req.Offset, req.Limit)
 	if err != nil {
 		logging.From(ctx).Error("failed to find users", zap.Error(err))
 		handleError(ctx, w, err)
@@ -104,23 +91,14 @@ func (s *Server) searchUsers(w http.ResponseWriter, r *http.Request) {
 func (s *Server) updateUser(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	w.Header().Add("Content-Type", "application/json")
-
 	vars := mux.Vars(r)
 	id := vars["id"]
 
-	data, err := io.ReadAll(r.Body)
-	if err != nil {
-		logging.From(ctx).Error("failed to read request body", zap.Error(err))
-		handleError(ctx, w, errors.ErrUnknown.Wrap(err))
-		return
-	}
-
 	u := model.User{}
 
-	if err := json.Unmarshal(data, &u); err != nil {
-		logging.From(ctx).Error("failed to unmarshal json body", zap.Error(err))
-		handleError(ctx, w, errors.ErrInvalidRequest.Wrap(err))
+	if err := decodeBody(r, &u, userReadOnlyFields...); err != nil {
+		logging.From(ctx).Error("failed to decode request body", zap.Error(err))
+		handleError(ctx, w, err)
 		return
 	}
 
@@ -139,8 +117,6 @@ func (s *Server) updateUser(w http.ResponseWriter, r *http.Request) {
 func (s *Server) deleteUser(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	w.Header().Add("Content-Type", "application/json")
-
 	vars := mux.Vars(r)
 	id := vars["id"]
 
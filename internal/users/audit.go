@@ -0,0 +1,21 @@
+package users
+
+import (
+	"context"
+
+	"github.com/speakeasy-api/rest-template-go/internal/core/logging"
+	"github.com/speakeasy-api/rest-template-go/internal/events"
+	"go.uber.org/zap"
+)
+
+// AuditUserEvent will record a change made to a user by any instance of the service in the application log, it is
+// handled by the events consumer so the audit trail includes the changes made by every instance.
+func AuditUserEvent(ctx context.Context, e events.UserEvent) error {
+	logging.From(ctx).Info("user changed",
+		zap.String("event_type", string(e.EventType)),
+		zap.String("user_id", e.ID),
+		zap.Strings("changed_fields", e.ChangedFields),
+	)
+
+	return nil
+}
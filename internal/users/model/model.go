@@ -29,6 +29,10 @@ const (
 	FieldEmail Field = "email"
 	// FieldCountry represents the country field.
 	FieldCountry Field = "country"
+	// FieldCreatedAt represents the created at field, it can only be used for sorting.
+	FieldCreatedAt Field = "created_at"
+	// FieldUpdatedAt represents the updated at field, it can only be used for sorting.
+	FieldUpdatedAt Field = "updated_at"
 )
 
 // MatchType is an enum providing valid matching mechanisms for filtering values.
@@ -47,3 +51,19 @@ type Filter struct {
 	Field     Field     `json:"field"`
 	Value     string    `json:"value"`
 }
+
+// SortDirection is an enum providing valid directions for sorting.
+type SortDirection string
+
+const (
+	// SortDirectionAsc represents an ascending sort.
+	SortDirectionAsc SortDirection = "ASC"
+	// SortDirectionDesc represents a descending sort.
+	SortDirectionDesc SortDirection = "DESC"
+)
+
+// Sort is a struct representing the order users are found in.
+type Sort struct {
+	Field     Field         `json:"field"`
+	Direction SortDirection `json:"direction"`
+}
@@ -12,10 +12,39 @@ import (
 	"go.uber.org/zap"
 )
 
-// FindUsers will retrieve a list of users based on matching all of the the provided filters and using pagination if limit is gt 0
+var filterColumns = map[model.Field]string{
+	model.FieldFirstName: "first_name",
+	model.FieldLastName:  "last_name",
+	model.FieldNickname:  "nickname",
+	model.FieldEmail:     "email",
+	model.FieldCountry:   "country",
+}
+
+var sortColumns = map[model.Field]string{
+	model.FieldFirstName: "first_name",
+	model.FieldLastName:  "last_name",
+	model.FieldNickname:  "nickname",
+	model.FieldEmail:     "email",
+	model.FieldCountry:   "country",
+	model.FieldCreatedAt: "created_at",
+	model.FieldUpdatedAt: "updated_at",
+}
+
+var matchOperators = map[model.MatchType]string{
+	model.MatchTypeEqual: "=",
+	model.MatchTypeLike:  "ILIKE",
+}
+
+var sortDirections = map[model.SortDirection]string{
+	model.SortDirectionAsc:  "ASC",
+	model.SortDirectionDesc: "DESC",
+}
+
+// FindUsers will retrieve a list of users based on matching all of the the provided filters, ordered by the provided sorts
+// and using pagination if limit is gt 0
 // Note: depending on the actual use cases for such functionality I would probably take the route of using elasticsearch and opening up
 // the flexibility of having a search type function.
-func (s *Store) FindUsers(ctx context.Context, filters []model.Filter, offset, limit int64) ([]*model.User, error) {
+func (s *Store) FindUsers(ctx context.Context, filters []model.Filter, sort []model.Sort, offset, limit int64) ([]*model.User, error) {
 	if len(filters) == 0 {
 		return nil, ErrInvalidFilters.Wrap(errors.ErrInvalidRequest)
 	}
@@ -23,18 +52,44 @@ func (s *Store) FindUsers(ctx context.Context, filters []model.Filter, offset, l
 	whereClauses := []string{}
 	values := []interface{}{}
 
+	// Only the columns and operators below are ever written into the query, values are always passed as parameters
 	for i, f := range filters {
-		whereClauses = append(whereClauses, fmt.Sprintf("%s %s $%d", f.Field, f.MatchType, i+1))
+		column, ok := filterColumns[f.Field]
+		if !ok {
+			return nil, ErrInvalidFilters.Wrap(errors.ErrInvalidRequest)
+		}
+		operator, ok := matchOperators[f.MatchType]
+		if !ok {
+			return nil, ErrInvalidFilters.Wrap(errors.ErrInvalidRequest)
+		}
+
+		whereClauses = append(whereClauses, fmt.Sprintf("%s %s $%d", column, operator, i+1))
 		values = append(values, getFindValue(f))
 	}
 
+	// Always finish ordering by id so pages are stable when sorted fields have equal values
+	orderClauses := []string{}
+	for _, o := range sort {
+		column, ok := sortColumns[o.Field]
+		if !ok {
+			return nil, ErrInvalidSorts.Wrap(errors.ErrInvalidRequest)
+		}
+		direction, ok := sortDirections[o.Direction]
+		if !ok {
+			return nil, ErrInvalidSorts.Wrap(errors.ErrInvalidRequest)
+		}
+
+		orderClauses = append(orderClauses, fmt.Sprintf("%s %s", column, direction))
+	}
+	orderClauses = append(orderClauses, "id ASC")
+
 	limitClause := ""
 
 	if limit > 0 {
 		limitClause = fmt.Sprintf(" LIMIT %d OFFSET %d", limit, offset)
 	}
 
-	rows, err := s.db.QueryxContext(ctx, fmt.Sprintf("SELECT * FROM users WHERE %s ORDER BY id ASC%s", strings.Join(whereClauses, " AND "), limitClause), values...)
+	rows, err := s.db.QueryxContext(ctx, fmt.Sprintf("SELECT * FROM users WHERE %s ORDER BY %s%s", strings.Join(whereClauses, " AND "), strings.Join(orderClauses, ", "), limitClause), values...)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, errors.ErrNotFound.Wrap(err)
@@ -36,6 +36,7 @@ func TestStore_FindUsers_Success(t *testing.T) {
 
 	type args struct {
 		filters []model.Filter
+		sort    []model.Sort
 		offset  int64
 		limit   int64
 	}
@@ -43,6 +44,7 @@ func TestStore_FindUsers_Success(t *testing.T) {
 		name          string
 		args          args
 		wantUserCount int
+		wantFirst     string
 	}{
 		{
 			name: "get all UK users using equal match",
@@ -199,6 +201,28 @@ func TestStore_FindUsers_Success(t *testing.T) {
 			},
 			wantUserCount: 33,
 		},
+		{
+			name: "get first page of UK users sorted by first name descending",
+			args: args{
+				filters: []model.Filter{
+					{
+						Field:     model.FieldCountry,
+						MatchType: model.MatchTypeEqual,
+						Value:     "UK",
+					},
+				},
+				sort: []model.Sort{
+					{
+						Field:     model.FieldFirstName,
+						Direction: model.SortDirectionDesc,
+					},
+				},
+				offset: 0,
+				limit:  10,
+			},
+			wantUserCount: 10,
+			wantFirst:     "judy96",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -206,10 +230,13 @@ func TestStore_FindUsers_Success(t *testing.T) {
 
 			ctx := context.Background()
 
-			users, err := s.FindUsers(ctx, tt.args.filters, tt.args.offset, tt.args.limit)
+			users, err := s.FindUsers(ctx, tt.args.filters, tt.args.sort, tt.args.offset, tt.args.limit)
 			assert.NoError(t, err)
 			assert.NotNil(t, users)
 			assert.Len(t, users, tt.wantUserCount)
+			if tt.wantFirst != "" {
+				assert.Equal(t, tt.wantFirst, *users[0].FirstName)
+			}
 		})
 	}
 }
@@ -217,6 +244,7 @@ func TestStore_FindUsers_Success(t *testing.T) {
 func TestStore_FindUsers_Error(t *testing.T) {
 	type args struct {
 		filters []model.Filter
+		sort    []model.Sort
 		offset  int64
 		limit   int64
 	}
@@ -236,6 +264,64 @@ func TestStore_FindUsers_Error(t *testing.T) {
 			wantErr1: errors.ErrInvalidRequest,
 			wantErr2: store.ErrInvalidFilters,
 		},
+		{
+			name: "fails with unsupported filter field",
+			args: args{
+				filters: []model.Filter{
+					{
+						Field:     model.Field("1 = 1 OR nickname"),
+						MatchType: model.MatchTypeEqual,
+						Value:     "blah",
+					},
+				},
+			},
+			wantErr1: errors.ErrInvalidRequest,
+			wantErr2: store.ErrInvalidFilters,
+		},
+		{
+			name: "fails with unsupported filter match type",
+			args: args{
+				filters: []model.Filter{
+					{
+						Field:     model.FieldNickname,
+						MatchType: model.MatchType("IS NOT NULL OR nickname ="),
+						Value:     "blah",
+					},
+				},
+			},
+			wantErr1: errors.ErrInvalidRequest,
+			wantErr2: store.ErrInvalidFilters,
+		},
+		{
+			name: "fails with unsupported sort field",
+			args: args{
+				filters: []model.Filter{
+					{
+						Field:     model.FieldNickname,
+						MatchType: model.MatchTypeLike,
+						Value:     "blah",
+					},
+				},
+				sort: []model.Sort{{Field: model.Field("(SELECT password FROM users LIMIT 1)"), Direction: model.SortDirectionAsc}},
+			},
+			wantErr1: errors.ErrInvalidRequest,
+			wantErr2: store.ErrInvalidSorts,
+		},
+		{
+			name: "fails with unsupported sort direction",
+			args: args{
+				filters: []model.Filter{
+					{
+						Field:     model.FieldNickname,
+						MatchType: model.MatchTypeLike,
+						Value:     "blah",
+					},
+				},
+				sort: []model.Sort{{Field: model.FieldNickname, Direction: model.SortDirection("ASC; DROP TABLE users")}},
+			},
+			wantErr1: errors.ErrInvalidRequest,
+			wantErr2: store.ErrInvalidSorts,
+		},
 		{
 			name: "fails with no users found",
 			args: args{
@@ -259,7 +345,7 @@ func TestStore_FindUsers_Error(t *testing.T) {
 
 			ctx := context.Background()
 
-			users, err := s.FindUsers(ctx, tt.args.filters, tt.args.offset, tt.args.limit)
+			users, err := s.FindUsers(ctx, tt.args.filters, tt.args.sort, tt.args.offset, tt.args.limit)
 			assert.ErrorIs(t, err, tt.wantErr1)
 			assert.ErrorIs(t, err, tt.wantErr2)
 			assert.Nil(t, users)
@@ -33,6 +33,8 @@ const (
 	ErrUserNotDeleted = errors.Error("user_not_deleted: user record wasn't deleted")
 	// ErrInvalidFilters is returned when the filters for finding a user are not valid.
 	ErrInvalidFilters = errors.Error("invalid_filters: filters invalid for finding user")
+	// ErrInvalidSorts is returned when the sorts for finding a user are not valid.
+	ErrInvalidSorts = errors.Error("invalid_sorts: sorts invalid for finding user")
 )
 
 const (
@@ -114,6 +116,38 @@ func (s *Store) GetUser(ctx context.Context, id string) (*model.User, error) {
 	return &u, nil
 }
 
+// GetUsers will retrieve the existing users with the provided IDs, IDs that don't exist are omitted from the result.
+func (s *Store) GetUsers(ctx context.Context, ids []string) ([]*model.User, error) {
+	rows, err := s.db.QueryxContext(ctx, "SELECT * FROM users WHERE id = ANY($1)", pq.Array(ids))
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) {
+			if pqErr.Code.Name() == pqErrInvalidTextRepresentation && strings.Contains(pqErr.Error(), "uuid") {
+				return nil, ErrInvalidID.Wrap(errors.ErrValidation.Wrap(err))
+			}
+		}
+
+		return nil, errors.ErrUnknown.Wrap(err)
+	}
+	defer rows.Close()
+
+	users := []*model.User{}
+
+	for rows.Next() {
+		var u model.User
+		if err := rows.StructScan(&u); err != nil {
+			return nil, errors.ErrUnknown.Wrap(err)
+		}
+		users = append(users, &u)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.ErrUnknown.Wrap(err)
+	}
+
+	return users, nil
+}
+
 // GetUserByEmail will retrieve an existing user via their email address.
 func (s *Store) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
 	var u model.User
@@ -129,66 +163,84 @@ func (s *Store) GetUserByEmail(ctx context.Context, email string) (*model.User,
 	return &u, nil
 }
 
+// userChange represents the state of a user row before and after a write.
+type userChange struct {
+	model.User
+	Previous model.User `db:"previous"`
+}
+
 // UpdateUser will update an existing user in the database using only the present data provided.
-func (s *Store) UpdateUser(ctx context.Context, u *model.User) (*model.User, error) {
+// The previous state of the user is returned alongside the updated user.
+func (s *Store) UpdateUser(ctx context.Context, u *model.User) (*model.User, *model.User, error) {
 	if u.ID == nil || *u.ID == "" {
-		return nil, ErrInvalidID.Wrap(errors.ErrValidation)
+		return nil, nil, ErrInvalidID.Wrap(errors.ErrValidation)
 	}
 
 	u.UpdatedAt = timeNow()
 
+	// The CTE captures the row as it was before the update, both are evaluated against the same snapshot
 	res, err := s.db.NamedQueryContext(ctx,
-		`UPDATE users 
+		`WITH previous AS (
+			SELECT * FROM users WHERE id = :id FOR UPDATE
+		)
+		UPDATE users 
 		SET 
-		first_name = COALESCE(:first_name, first_name), 
-		last_name = COALESCE(:last_name, last_name), 
-		nickname = COALESCE(:nickname, nickname), 
-		password = COALESCE(:password, password),
-		email = COALESCE(:email, email),
-		country = COALESCE(:country, country),
+		first_name = COALESCE(:first_name, users.first_name), 
+		last_name = COALESCE(:last_name, users.last_name), 
+		nickname = COALESCE(:nickname, users.nickname), 
+		password = COALESCE(:password, users.password),
+		email = COALESCE(:email, users.email),
+		country = COALESCE(:country, users.country),
 		updated_at = :updated_at 
-		WHERE id = :id
-		RETURNING *`, u)
+		FROM previous
+		WHERE users.id = previous.id
+		RETURNING users.*,
+		previous.id AS "previous.id",
+		previous.first_name AS "previous.first_name",
+		previous.last_name AS "previous.last_name",
+		previous.nickname AS "previous.nickname",
+		previous.password AS "previous.password",
+		previous.email AS "previous.email",
+		previous.country AS "previous.country",
+		previous.created_at AS "previous.created_at",
+		previous.updated_at AS "previous.updated_at"`, u)
 	if err = checkWriteError(err); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer res.Close()
 
 	if !res.Next() {
-		return nil, ErrUserNotUpdated.Wrap(errors.ErrNotFound)
+		return nil, nil, ErrUserNotUpdated.Wrap(errors.ErrNotFound)
 	}
 
-	updatedUser := &model.User{}
+	change := &userChange{}
 
-	if err := res.StructScan(&updatedUser); err != nil {
-		return nil, errors.ErrUnknown.Wrap(err)
+	if err := res.StructScan(change); err != nil {
+		return nil, nil, errors.ErrUnknown.Wrap(err)
 	}
 
-	return updatedUser, nil
+	return &change.User, &change.Previous, nil
 }
 
-// DeleteUser will delete an existing user via their ID.
-func (s *Store) DeleteUser(ctx context.Context, id string) error {
-	res, err := s.db.ExecContext(ctx, "DELETE FROM users WHERE id = $1", id)
-	if err != nil {
+// DeleteUser will delete an existing user via their ID returning the deleted user.
+func (s *Store) DeleteUser(ctx context.Context, id string) (*model.User, error) {
+	var u model.User
+
+	if err := s.db.GetContext(ctx, &u, "DELETE FROM users WHERE id = $1 RETURNING *", id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotDeleted.Wrap(errors.ErrNotFound)
+		}
 		var pqErr *pq.Error
 		if errors.As(err, &pqErr) {
 			if pqErr.Code.Name() == pqErrInvalidTextRepresentation && strings.Contains(pqErr.Error(), "uuid") {
-				return ErrInvalidID.Wrap(errors.ErrValidation.Wrap(err))
+				return nil, ErrInvalidID.Wrap(errors.ErrValidation.Wrap(err))
 			}
 		}
 
-		return errors.ErrUnknown.Wrap(err)
-	}
-	rows, err := res.RowsAffected()
-	if err != nil {
-		return errors.ErrUnknown.Wrap(err)
-	}
-	if rows != 1 {
-		return ErrUserNotDeleted.Wrap(errors.ErrNotFound)
+		return nil, errors.ErrUnknown.Wrap(err)
 	}
 
-	return nil
+	return &u, nil
 }
 
 //nolint:cyclop
@@ -205,42 +257,52 @@ func checkWriteError(err error) error {
 		case "check_violation":
 			switch {
 			case strings.Contains(pqErr.Error(), "email_check"):
-				return ErrInvalidEmail.Wrap(errors.ErrValidation.Wrap(err))
+				return fieldError(ErrInvalidEmail, "email", err)
 			case strings.Contains(pqErr.Error(), "users_nickname_check"):
-				return ErrEmptyNickname.Wrap(errors.ErrValidation.Wrap(err))
+				return fieldError(ErrEmptyNickname, "nickname", err)
 			case strings.Contains(pqErr.Error(), "users_password_check"):
-				return ErrEmptyPassword.Wrap(errors.ErrValidation.Wrap(err))
+				return fieldError(ErrEmptyPassword, "password", err)
 			case strings.Contains(pqErr.Error(), "users_country_check"):
-				return ErrEmptyCountry.Wrap(errors.ErrValidation.Wrap(err))
+				return fieldError(ErrEmptyCountry, "country", err)
 			default:
 				return errors.ErrValidation.Wrap(err)
 			}
 		case "not_null_violation":
 			switch {
 			case strings.Contains(pqErr.Error(), "email"):
-				return ErrInvalidEmail.Wrap(errors.ErrValidation.Wrap(err))
+				return fieldError(ErrInvalidEmail, "email", err)
 			case strings.Contains(pqErr.Error(), "nickname"):
-				return ErrEmptyNickname.Wrap(errors.ErrValidation.Wrap(err))
+				return fieldError(ErrEmptyNickname, "nickname", err)
 			case strings.Contains(pqErr.Error(), "password"):
-				return ErrEmptyPassword.Wrap(errors.ErrValidation.Wrap(err))
+				return fieldError(ErrEmptyPassword, "password", err)
 			case strings.Contains(pqErr.Error(), "country"):
-				return ErrEmptyCountry.Wrap(errors.ErrValidation.Wrap(err))
+				return fieldError(ErrEmptyCountry, "country", err)
 			default:
 				return errors.ErrValidation.Wrap(err)
 			}
 		case "unique_violation":
 			if strings.Contains(pqErr.Error(), "email_unique") {
-				return ErrEmailAlreadyUsed.Wrap(errors.ErrValidation.Wrap(err))
+				return conflictError(ErrEmailAlreadyUsed, "email", pqErr)
 			} else if strings.Contains(pqErr.Error(), "nickname_unique") {
-				return ErrNicknameAlreadyUsed.Wrap(errors.ErrValidation.Wrap(err))
+				return conflictError(ErrNicknameAlreadyUsed, "nickname", pqErr)
 			}
 			return errors.ErrValidation.Wrap(err)
 		case "invalid_text_representation":
 			if strings.Contains(pqErr.Error(), "uuid") {
-				return ErrInvalidID.Wrap(errors.ErrValidation.Wrap(err))
+				return fieldError(ErrInvalidID, "id", err)
 			}
 		}
 	}
 
 	return errors.ErrUnknown.Wrap(err)
 }
+
+// fieldError wraps a validation error with details of the field that caused it.
+func fieldError(e errors.Error, field string, err error) error {
+	return errors.WithFieldErrors(e.Wrap(errors.ErrValidation.Wrap(err)), e.Field(field))
+}
+
+// conflictError reports the field as conflicting with an existing user along with the constraint it violated.
+func conflictError(e errors.Error, field string, pqErr *pq.Error) error {
+	return errors.WithFieldErrors(e.Structured(errors.CategoryConflict).WithDetail("constraint", pqErr.Constraint).Wrap(pqErr), e.Field(field))
+}
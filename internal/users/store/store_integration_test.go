@@ -158,10 +158,11 @@ func TestStore_InsertUser_Error(t *testing.T) {
 		user *model.User
 	}
 	tests := []struct {
-		name     string
-		args     args
-		wantErr1 error
-		wantErr2 error
+		name      string
+		args      args
+		wantErr1  error
+		wantErr2  error
+		wantField string
 	}{
 		{
 			name: "failed with invalid email",
@@ -175,8 +176,9 @@ func TestStore_InsertUser_Error(t *testing.T) {
 					Country:   pointer.ToString("UK"),
 				},
 			},
-			wantErr1: errors.ErrValidation,
-			wantErr2: store.ErrInvalidEmail,
+			wantErr1:  errors.ErrValidation,
+			wantErr2:  store.ErrInvalidEmail,
+			wantField: "email",
 		},
 		{
 			name: "failed with not-unique email",
@@ -190,8 +192,9 @@ func TestStore_InsertUser_Error(t *testing.T) {
 					Country:   pointer.ToString("UK"),
 				},
 			},
-			wantErr1: errors.ErrValidation,
-			wantErr2: store.ErrEmailAlreadyUsed,
+			wantErr1:  errors.ErrConflict,
+			wantErr2:  store.ErrEmailAlreadyUsed,
+			wantField: "email",
 		},
 		{
 			name: "failed with not-unique nickname",
@@ -205,8 +208,9 @@ func TestStore_InsertUser_Error(t *testing.T) {
 					Country:   pointer.ToString("UK"),
 				},
 			},
-			wantErr1: errors.ErrValidation,
-			wantErr2: store.ErrNicknameAlreadyUsed,
+			wantErr1:  errors.ErrConflict,
+			wantErr2:  store.ErrNicknameAlreadyUsed,
+			wantField: "nickname",
 		},
 		{
 			name: "failed with empty email",
@@ -220,8 +224,9 @@ func TestStore_InsertUser_Error(t *testing.T) {
 					Country:   pointer.ToString("UK"),
 				},
 			},
-			wantErr1: errors.ErrValidation,
-			wantErr2: store.ErrInvalidEmail,
+			wantErr1:  errors.ErrValidation,
+			wantErr2:  store.ErrInvalidEmail,
+			wantField: "email",
 		},
 		{
 			name: "failed with null email",
@@ -235,8 +240,9 @@ func TestStore_InsertUser_Error(t *testing.T) {
 					Country:   pointer.ToString("UK"),
 				},
 			},
-			wantErr1: errors.ErrValidation,
-			wantErr2: store.ErrInvalidEmail,
+			wantErr1:  errors.ErrValidation,
+			wantErr2:  store.ErrInvalidEmail,
+			wantField: "email",
 		},
 		{
 			name: "failed with empty nickname",
@@ -250,8 +256,9 @@ func TestStore_InsertUser_Error(t *testing.T) {
 					Country:   pointer.ToString("UK"),
 				},
 			},
-			wantErr1: errors.ErrValidation,
-			wantErr2: store.ErrEmptyNickname,
+			wantErr1:  errors.ErrValidation,
+			wantErr2:  store.ErrEmptyNickname,
+			wantField: "nickname",
 		},
 		{
 			name: "failed with null nickname",
@@ -265,8 +272,9 @@ func TestStore_InsertUser_Error(t *testing.T) {
 					Country:   pointer.ToString("UK"),
 				},
 			},
-			wantErr1: errors.ErrValidation,
-			wantErr2: store.ErrEmptyNickname,
+			wantErr1:  errors.ErrValidation,
+			wantErr2:  store.ErrEmptyNickname,
+			wantField: "nickname",
 		},
 		{
 			name: "failed with empty password",
@@ -280,8 +288,9 @@ func TestStore_InsertUser_Error(t *testing.T) {
 					Country:   pointer.ToString("UK"),
 				},
 			},
-			wantErr1: errors.ErrValidation,
-			wantErr2: store.ErrEmptyPassword,
+			wantErr1:  errors.ErrValidation,
+			wantErr2:  store.ErrEmptyPassword,
+			wantField: "password",
 		},
 		{
 			name: "failed with null password",
@@ -295,8 +304,9 @@ func TestStore_InsertUser_Error(t *testing.T) {
 					Country:   pointer.ToString("UK"),
 				},
 			},
-			wantErr1: errors.ErrValidation,
-			wantErr2: store.ErrEmptyPassword,
+			wantErr1:  errors.ErrValidation,
+			wantErr2:  store.ErrEmptyPassword,
+			wantField: "password",
 		},
 		{
 			name: "failed with empty country",
@@ -310,8 +320,9 @@ func TestStore_InsertUser_Error(t *testing.T) {
 					Country:   pointer.ToString(""),
 				},
 			},
-			wantErr1: errors.ErrValidation,
-			wantErr2: store.ErrEmptyCountry,
+			wantErr1:  errors.ErrValidation,
+			wantErr2:  store.ErrEmptyCountry,
+			wantField: "country",
 		},
 		{
 			name: "failed with null country",
@@ -325,8 +336,9 @@ func TestStore_InsertUser_Error(t *testing.T) {
 					Country:   nil,
 				},
 			},
-			wantErr1: errors.ErrValidation,
-			wantErr2: store.ErrEmptyCountry,
+			wantErr1:  errors.ErrValidation,
+			wantErr2:  store.ErrEmptyCountry,
+			wantField: "country",
 		},
 	}
 	for _, tt := range tests {
@@ -340,6 +352,10 @@ func TestStore_InsertUser_Error(t *testing.T) {
 			if tt.wantErr2 != nil {
 				assert.ErrorIs(t, err, tt.wantErr2)
 			}
+			fields := errors.FieldErrors(err)
+			if assert.Len(t, fields, 1) {
+				assert.Equal(t, tt.wantField, fields[0].Field)
+			}
 			assert.Nil(t, createdUser)
 		})
 	}
@@ -427,6 +443,64 @@ func TestStore_GetUser_Error(t *testing.T) {
 	}
 }
 
+func TestStore_GetUsers(t *testing.T) {
+	type args struct {
+		ids []string
+	}
+	tests := []struct {
+		name     string
+		args     args
+		wantIDs  []string
+		wantErr1 error
+		wantErr2 error
+	}{
+		{
+			name: "success omitting missing users",
+			args: args{
+				ids: []string{initialInsertedUserID, "9cdd8ae2-15ab-40df-9c46-50f391e16f60"},
+			},
+			wantIDs: []string{initialInsertedUserID},
+		},
+		{
+			name: "success with no users",
+			args: args{
+				ids: []string{},
+			},
+			wantIDs: []string{},
+		},
+		{
+			name: "fails with invalid id",
+			args: args{
+				ids: []string{initialInsertedUserID, "invalid"},
+			},
+			wantErr1: errors.ErrValidation,
+			wantErr2: store.ErrInvalidID,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := store.New(db.GetDB())
+
+			ctx := context.Background()
+
+			users, err := s.GetUsers(ctx, tt.args.ids)
+			if tt.wantErr1 != nil {
+				assert.ErrorIs(t, err, tt.wantErr1)
+				assert.ErrorIs(t, err, tt.wantErr2)
+				assert.Nil(t, users)
+				return
+			}
+			require.NoError(t, err)
+
+			ids := []string{}
+			for _, u := range users {
+				ids = append(ids, *u.ID)
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+		})
+	}
+}
+
 func TestStore_GetUserByEmail_Success(t *testing.T) {
 	type args struct {
 		email string
@@ -696,10 +770,14 @@ func TestStore_UpdateUser_Success(t *testing.T) {
 
 			store.ExportSetTimeNow(time.Date(2021, time.January, tt.fields.updateDay, 0, 0, 0, 0, time.UTC))
 
-			updatedUser, err := s.UpdateUser(ctx, tt.args.user)
+			previousUser, err := s.GetUser(ctx, *tt.args.user.ID)
+			require.NoError(t, err)
+
+			updatedUser, gotPreviousUser, err := s.UpdateUser(ctx, tt.args.user)
 			assert.NoError(t, err)
 			require.NotNil(t, updatedUser)
 			assert.Equal(t, tt.wantUser, *updatedUser)
+			assert.Equal(t, previousUser, gotPreviousUser)
 		})
 	}
 }
@@ -822,10 +900,11 @@ func TestStore_UpdateUser_Error(t *testing.T) {
 
 			store.ExportSetTimeNow(time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC))
 
-			updatedUser, err := s.UpdateUser(ctx, tt.args.user)
+			updatedUser, previousUser, err := s.UpdateUser(ctx, tt.args.user)
 			assert.ErrorIs(t, err, tt.wantErr1)
 			assert.ErrorIs(t, err, tt.wantErr2)
 			assert.Nil(t, updatedUser)
+			assert.Nil(t, previousUser)
 		})
 	}
 }
@@ -861,8 +940,11 @@ func TestStore_DeleteUser_Success(t *testing.T) {
 
 			ctx := context.Background()
 
-			err := s.DeleteUser(ctx, tt.args.id)
+			deletedUser, err := s.DeleteUser(ctx, tt.args.id)
 			assert.NoError(t, err)
+			require.NotNil(t, deletedUser)
+			assert.Equal(t, tt.args.id, *deletedUser.ID)
+			assert.Equal(t, "deleteTest", *deletedUser.Nickname)
 		})
 	}
 }
@@ -900,9 +982,10 @@ func TestStore_DeleteUser_Error(t *testing.T) {
 
 			ctx := context.Background()
 
-			err := s.DeleteUser(ctx, tt.args.id)
+			deletedUser, err := s.DeleteUser(ctx, tt.args.id)
 			assert.ErrorIs(t, err, tt.wantErr1)
 			assert.ErrorIs(t, err, tt.wantErr2)
+			assert.Nil(t, deletedUser)
 		})
 	}
 }
@@ -4,6 +4,7 @@ package users
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
 	"github.com/speakeasy-api/rest-template-go/internal/core/logging"
@@ -19,16 +20,21 @@ const (
 	ErrInvalidFilterMatchType = errors.Error("invalid_filter_match_type: invalid filter match type")
 	// ErrInvalidFilterField is returned when a filter field is not found in the supported enum list.
 	ErrInvalidFilterField = errors.Error("invalid_filter_field: invalid filter field")
+	// ErrInvalidSortField is returned when a sort field is not found in the supported enum list.
+	ErrInvalidSortField = errors.Error("invalid_sort_field: invalid sort field")
+	// ErrInvalidSortDirection is returned when a sort direction is not found in the supported enum list.
+	ErrInvalidSortDirection = errors.Error("invalid_sort_direction: invalid sort direction")
 )
 
 // Store represents a type for storing a user in a database.
 type Store interface {
 	InsertUser(ctx context.Context, user *model.User) (*model.User, error)
-	UpdateUser(ctx context.Context, user *model.User) (*model.User, error)
+	UpdateUser(ctx context.Context, user *model.User) (*model.User, *model.User, error)
 	GetUser(ctx context.Context, id string) (*model.User, error)
+	GetUsers(ctx context.Context, ids []string) ([]*model.User, error)
 	GetUserByEmail(ctx context.Context, email string) (*model.User, error)
-	FindUsers(ctx context.Context, filters []model.Filter, offset, limit int64) ([]*model.User, error)
-	DeleteUser(ctx context.Context, id string) error
+	FindUsers(ctx context.Context, filters []model.Filter, sort []model.Sort, offset, limit int64) ([]*model.User, error)
+	DeleteUser(ctx context.Context, id string) (*model.User, error)
 }
 
 // Events represents a type for producing events on user CRUD operations.
@@ -65,11 +71,7 @@ func (u *Users) CreateUser(ctx context.Context, user *model.User) (*model.User,
 	// of recovery mechanism to ensure that we don't lose any events. Such as picking up failed events on
 	// a later run, and retrying them.
 	// We shouldn't need to fail the whole process if we can't produce an event right now.
-	u.events.Produce(ctx, events.TopicUsers, events.UserEvent{
-		EventType: events.EventTypeUserCreated,
-		ID:        *createdUser.ID,
-		User:      createdUser,
-	})
+	u.events.Produce(ctx, events.TopicUsers, events.NewUserEvent(events.EventTypeUserCreated, *createdUser.ID, nil, createdUser))
 
 	return createdUser, nil
 }
@@ -84,37 +86,75 @@ func (u *Users) GetUser(ctx context.Context, id string) (*model.User, error) {
 	return user, nil
 }
 
-// FindUsers will retrieve a list of users based on matching all of the the provided filters and using pagination if limit is gt 0.
-func (u *Users) FindUsers(ctx context.Context, filters []model.Filter, offset, limit int64) ([]*model.User, error) {
-	// Validate filters before searching with them
-	// TODO may want to return details of error instead of just logging
+// GetUsers will try to get the existing users in our database with the provided ids, ids that don't exist are omitted.
+func (u *Users) GetUsers(ctx context.Context, ids []string) ([]*model.User, error) {
+	users, err := u.store.GetUsers(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// FindUsers will retrieve a list of users based on matching all of the the provided filters, ordered by the provided sorts
+// and using pagination if limit is gt 0.
+func (u *Users) FindUsers(ctx context.Context, filters []model.Filter, sort []model.Sort, offset, limit int64) ([]*model.User, error) {
+	// Validate filters and sorts before searching with them, returning details of every invalid field
+	var (
+		first  errors.Error
+		fields []errors.FieldError
+	)
+
+	invalid := func(err errors.Error, field string, args ...any) {
+		if first == "" {
+			first = err
+		}
+		fields = append(fields, err.Field(fmt.Sprintf(field, args...)))
+	}
+
 	for i, f := range filters {
 		if f.Value == "" {
-			err := ErrInvalidFilterValue.Wrap(errors.ErrValidation)
-			logging.From(ctx).Error("empty filter value provided", zap.Error(err), zap.Int("index", i))
-			return nil, err
+			invalid(ErrInvalidFilterValue, "filters[%d].value", i)
 		}
 
 		switch f.MatchType {
 		case model.MatchTypeEqual, model.MatchTypeLike:
 			// noop
 		default:
-			err := ErrInvalidFilterMatchType.Wrap(errors.ErrValidation)
-			logging.From(ctx).Error("match type not supported", zap.Error(err), zap.String("match_type", string(f.MatchType)), zap.Int("index", i))
-			return nil, err
+			invalid(ErrInvalidFilterMatchType, "filters[%d].match_type", i)
 		}
 
 		switch f.Field {
 		case model.FieldFirstName, model.FieldLastName, model.FieldNickname, model.FieldEmail, model.FieldCountry:
 		// noop
 		default:
-			err := ErrInvalidFilterField.Wrap(errors.ErrValidation)
-			logging.From(ctx).Error("filter field not supported", zap.Error(err), zap.String("field", string(f.Field)), zap.Int("index", i))
-			return nil, err
+			invalid(ErrInvalidFilterField, "filters[%d].field", i)
+		}
+	}
+
+	for i, o := range sort {
+		switch o.Field {
+		case model.FieldFirstName, model.FieldLastName, model.FieldNickname, model.FieldEmail, model.FieldCountry, model.FieldCreatedAt, model.FieldUpdatedAt:
+		// noop
+		default:
+			invalid(ErrInvalidSortField, "sort[%d].field", i)
 		}
+
+		switch o.Direction {
+		case model.SortDirectionAsc, model.SortDirectionDesc:
+		// noop
+		default:
+			invalid(ErrInvalidSortDirection, "sort[%d].direction", i)
+		}
+	}
+
+	if len(fields) > 0 {
+		err := errors.WithFieldErrors(first.Wrap(errors.ErrValidation), fields...)
+		logging.From(ctx).Error("invalid search provided", zap.Error(err), zap.Any("fields", fields))
+		return nil, err
 	}
 
-	users, err := u.store.FindUsers(ctx, filters, offset, limit)
+	users, err := u.store.FindUsers(ctx, filters, sort, offset, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -124,31 +164,24 @@ func (u *Users) FindUsers(ctx context.Context, filters []model.Filter, offset, l
 
 // UpdateUser will try to update an existing user in our database with the provided data.
 func (u *Users) UpdateUser(ctx context.Context, user *model.User) (*model.User, error) {
-	updatedUser, err := u.store.UpdateUser(ctx, user)
+	updatedUser, previousUser, err := u.store.UpdateUser(ctx, user)
 	if err != nil {
 		return nil, err
 	}
 
-	u.events.Produce(ctx, events.TopicUsers, events.UserEvent{
-		EventType: events.EventTypeUserUpdated,
-		ID:        *updatedUser.ID,
-		User:      updatedUser,
-	})
+	u.events.Produce(ctx, events.TopicUsers, events.NewUserEvent(events.EventTypeUserUpdated, *updatedUser.ID, previousUser, updatedUser))
 
 	return updatedUser, nil
 }
 
 // DeleteUser will try to delete an existing user in our database with the provided id.
 func (u *Users) DeleteUser(ctx context.Context, id string) error {
-	err := u.store.DeleteUser(ctx, id)
+	deletedUser, err := u.store.DeleteUser(ctx, id)
 	if err != nil {
 		return err
 	}
 
-	u.events.Produce(ctx, events.TopicUsers, events.UserEvent{
-		EventType: events.EventTypeUserDeleted,
-		ID:        id,
-	})
+	u.events.Produce(ctx, events.TopicUsers, events.NewUserEvent(events.EventTypeUserDeleted, id, deletedUser, nil))
 
 	return nil
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/speakeasy-api/rest-template-go/internal/core/errors"
 	"github.com/speakeasy-api/rest-template-go/internal/events"
+	"github.com/speakeasy-api/rest-template-go/internal/events/eventstest"
 	"github.com/speakeasy-api/rest-template-go/internal/users"
 	"github.com/speakeasy-api/rest-template-go/internal/users/mocks"
 	"github.com/speakeasy-api/rest-template-go/internal/users/model"
@@ -32,9 +33,10 @@ func TestUsers_CreateUser_Success(t *testing.T) {
 		user *model.User
 	}
 	tests := []struct {
-		name     string
-		args     args
-		wantUser *model.User
+		name      string
+		args      args
+		wantUser  *model.User
+		wantEvent events.UserEvent
 	}{
 		{
 			name: "success",
@@ -59,6 +61,22 @@ func TestUsers_CreateUser_Success(t *testing.T) {
 				CreatedAt: pointer.ToTime(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)),
 				UpdatedAt: pointer.ToTime(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)),
 			},
+			wantEvent: events.UserEvent{
+				EventType: events.EventTypeUserCreated,
+				ID:        "some-test-id",
+				Before:    nil,
+				After: &model.User{
+					ID:        pointer.ToString("some-test-id"),
+					FirstName: pointer.ToString("testFirst"),
+					LastName:  pointer.ToString("testLast"),
+					Nickname:  pointer.ToString("test"),
+					Email:     pointer.ToString("test@test.com"),
+					Country:   pointer.ToString("UK"),
+					CreatedAt: pointer.ToTime(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)),
+					UpdatedAt: pointer.ToTime(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)),
+				},
+				ChangedFields: []string{"id", "first_name", "last_name", "nickname", "password", "email", "country", "created_at", "updated_at"},
+			},
 		},
 	}
 	for _, tt := range tests {
@@ -75,11 +93,7 @@ func TestUsers_CreateUser_Success(t *testing.T) {
 			ctx := context.Background()
 
 			s.EXPECT().InsertUser(gomock.Any(), tt.args.user).Return(tt.wantUser, nil).Times(1)
-			e.EXPECT().Produce(gomock.Any(), events.TopicUsers, events.UserEvent{
-				EventType: events.EventTypeUserCreated,
-				ID:        *tt.wantUser.ID,
-				User:      tt.wantUser,
-			}).Times(1)
+			e.EXPECT().Produce(gomock.Any(), events.TopicUsers, tt.wantEvent).Times(1)
 
 			user, err := u.CreateUser(ctx, tt.args.user)
 			assert.NoError(t, err)
@@ -181,6 +195,60 @@ func TestUsers_GetUser_Success(t *testing.T) {
 	}
 }
 
+func TestUsers_GetUsers(t *testing.T) {
+	type args struct {
+		ids []string
+	}
+	tests := []struct {
+		name      string
+		args      args
+		storeErr  error
+		wantUsers []*model.User
+		wantErr   error
+	}{
+		{
+			name: "success",
+			args: args{
+				ids: []string{"some-test-id", "missing-test-id"},
+			},
+			wantUsers: []*model.User{
+				{
+					ID:        pointer.ToString("some-test-id"),
+					FirstName: pointer.ToString("testFirst"),
+				},
+			},
+		},
+		{
+			name: "fails when get fails",
+			args: args{
+				ids: []string{"some-test-id"},
+			},
+			storeErr: errors.ErrUnknown,
+			wantErr:  errors.ErrUnknown,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := mocks.NewMockStore(ctrl)
+			e := mocks.NewMockEvents(ctrl)
+
+			u := users.New(s, e)
+			require.NotNil(t, u)
+
+			ctx := context.Background()
+
+			s.EXPECT().GetUsers(gomock.Any(), tt.args.ids).Return(tt.wantUsers, tt.storeErr).Times(1)
+
+			users, err := u.GetUsers(ctx, tt.args.ids)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.EqualValues(t, tt.wantUsers, users)
+		})
+	}
+}
+
 func TestUsers_GetUser_Error(t *testing.T) {
 	type args struct {
 		id string
@@ -223,6 +291,7 @@ func TestUsers_GetUser_Error(t *testing.T) {
 func TestUsers_FindUsers_Success(t *testing.T) {
 	type args struct {
 		filters []model.Filter
+		sort    []model.Sort
 		offset  int64
 		limit   int64
 	}
@@ -241,6 +310,12 @@ func TestUsers_FindUsers_Success(t *testing.T) {
 						Value:     "UK",
 					},
 				},
+				sort: []model.Sort{
+					{
+						Field:     model.FieldCreatedAt,
+						Direction: model.SortDirectionDesc,
+					},
+				},
 				offset: 0,
 				limit:  10,
 			},
@@ -272,9 +347,9 @@ func TestUsers_FindUsers_Success(t *testing.T) {
 
 			ctx := context.Background()
 
-			s.EXPECT().FindUsers(gomock.Any(), tt.args.filters, tt.args.offset, tt.args.limit).Return(tt.wantUsers, nil).Times(1)
+			s.EXPECT().FindUsers(gomock.Any(), tt.args.filters, tt.args.sort, tt.args.offset, tt.args.limit).Return(tt.wantUsers, nil).Times(1)
 
-			users, err := u.FindUsers(ctx, tt.args.filters, tt.args.offset, tt.args.limit)
+			users, err := u.FindUsers(ctx, tt.args.filters, tt.args.sort, tt.args.offset, tt.args.limit)
 			assert.NoError(t, err)
 			assert.EqualValues(t, tt.wantUsers, users)
 		})
@@ -287,15 +362,17 @@ func TestUsers_FindUsers_Error(t *testing.T) {
 	}
 	type args struct {
 		filters []model.Filter
+		sort    []model.Sort
 		offset  int64
 		limit   int64
 	}
 	tests := []struct {
-		name     string
-		fields   fields
-		args     args
-		wantErr1 error
-		wantErr2 error
+		name       string
+		fields     fields
+		args       args
+		wantErr1   error
+		wantErr2   error
+		wantFields []errors.FieldError
 	}{
 		{
 			name: "fails when search fails",
@@ -329,8 +406,9 @@ func TestUsers_FindUsers_Error(t *testing.T) {
 				offset: 0,
 				limit:  10,
 			},
-			wantErr1: errors.ErrValidation,
-			wantErr2: users.ErrInvalidFilterValue,
+			wantErr1:   errors.ErrValidation,
+			wantErr2:   users.ErrInvalidFilterValue,
+			wantFields: []errors.FieldError{{Field: "filters[0].value", Code: "invalid_filter_value", Message: "invalid filter value"}},
 		},
 		{
 			name: "fails with invalid match type",
@@ -345,8 +423,9 @@ func TestUsers_FindUsers_Error(t *testing.T) {
 				offset: 0,
 				limit:  10,
 			},
-			wantErr1: errors.ErrValidation,
-			wantErr2: users.ErrInvalidFilterMatchType,
+			wantErr1:   errors.ErrValidation,
+			wantErr2:   users.ErrInvalidFilterMatchType,
+			wantFields: []errors.FieldError{{Field: "filters[0].match_type", Code: "invalid_filter_match_type", Message: "invalid filter match type"}},
 		},
 		{
 			name: "fails with invalid field",
@@ -361,8 +440,87 @@ func TestUsers_FindUsers_Error(t *testing.T) {
 				offset: 0,
 				limit:  10,
 			},
+			wantErr1:   errors.ErrValidation,
+			wantErr2:   users.ErrInvalidFilterField,
+			wantFields: []errors.FieldError{{Field: "filters[0].field", Code: "invalid_filter_field", Message: "invalid filter field"}},
+		},
+		{
+			name: "fails with invalid sort field",
+			args: args{
+				filters: []model.Filter{
+					{
+						Field:     model.FieldCountry,
+						MatchType: model.MatchTypeEqual,
+						Value:     "UK",
+					},
+				},
+				sort: []model.Sort{
+					{
+						Field:     "password",
+						Direction: model.SortDirectionAsc,
+					},
+				},
+				offset: 0,
+				limit:  10,
+			},
+			wantErr1:   errors.ErrValidation,
+			wantErr2:   users.ErrInvalidSortField,
+			wantFields: []errors.FieldError{{Field: "sort[0].field", Code: "invalid_sort_field", Message: "invalid sort field"}},
+		},
+		{
+			name: "fails with invalid sort direction",
+			args: args{
+				filters: []model.Filter{
+					{
+						Field:     model.FieldCountry,
+						MatchType: model.MatchTypeEqual,
+						Value:     "UK",
+					},
+				},
+				sort: []model.Sort{
+					{
+						Field:     model.FieldEmail,
+						Direction: "sideways",
+					},
+				},
+				offset: 0,
+				limit:  10,
+			},
+			wantErr1:   errors.ErrValidation,
+			wantErr2:   users.ErrInvalidSortDirection,
+			wantFields: []errors.FieldError{{Field: "sort[0].direction", Code: "invalid_sort_direction", Message: "invalid sort direction"}},
+		},
+		{
+			name: "fails with details of every invalid field",
+			args: args{
+				filters: []model.Filter{
+					{
+						Field:     model.FieldCountry,
+						MatchType: model.MatchTypeEqual,
+						Value:     "UK",
+					},
+					{
+						Field:     "invalid",
+						MatchType: model.MatchTypeEqual,
+						Value:     "",
+					},
+				},
+				sort: []model.Sort{
+					{
+						Field:     model.FieldEmail,
+						Direction: "sideways",
+					},
+				},
+				offset: 0,
+				limit:  10,
+			},
 			wantErr1: errors.ErrValidation,
-			wantErr2: users.ErrInvalidFilterField,
+			wantErr2: users.ErrInvalidFilterValue,
+			wantFields: []errors.FieldError{
+				{Field: "filters[1].value", Code: "invalid_filter_value", Message: "invalid filter value"},
+				{Field: "filters[1].field", Code: "invalid_filter_field", Message: "invalid filter field"},
+				{Field: "sort[0].direction", Code: "invalid_sort_direction", Message: "invalid sort direction"},
+			},
 		},
 	}
 	for _, tt := range tests {
@@ -379,12 +537,13 @@ func TestUsers_FindUsers_Error(t *testing.T) {
 			ctx := context.Background()
 
 			if tt.fields.findUsersErr != nil {
-				s.EXPECT().FindUsers(gomock.Any(), tt.args.filters, tt.args.offset, tt.args.limit).Return(nil, tt.fields.findUsersErr).Times(1)
+				s.EXPECT().FindUsers(gomock.Any(), tt.args.filters, tt.args.sort, tt.args.offset, tt.args.limit).Return(nil, tt.fields.findUsersErr).Times(1)
 			}
 
-			user, err := u.FindUsers(ctx, tt.args.filters, tt.args.offset, tt.args.limit)
+			user, err := u.FindUsers(ctx, tt.args.filters, tt.args.sort, tt.args.offset, tt.args.limit)
 			assert.ErrorIs(t, err, tt.wantErr1)
 			assert.ErrorIs(t, err, tt.wantErr2)
+			assert.Equal(t, tt.wantFields, errors.FieldErrors(err))
 			assert.Nil(t, user)
 		})
 	}
@@ -395,9 +554,11 @@ func TestUsers_UpdateUser_Success(t *testing.T) {
 		user *model.User
 	}
 	tests := []struct {
-		name     string
-		args     args
-		wantUser *model.User
+		name         string
+		args         args
+		previousUser *model.User
+		wantUser     *model.User
+		wantEvent    events.UserEvent
 	}{
 		{
 			name: "success",
@@ -405,16 +566,12 @@ func TestUsers_UpdateUser_Success(t *testing.T) {
 				user: &model.User{
 					ID:        pointer.ToString("some-test-id"),
 					FirstName: pointer.ToString("testFirst"),
-					LastName:  pointer.ToString("testLast"),
-					Nickname:  pointer.ToString("test"),
-					Password:  pointer.ToString("test"),
-					Email:     pointer.ToString("test@test.com"),
-					Country:   pointer.ToString("UK"),
+					Password:  pointer.ToString("newPassword"),
 				},
 			},
-			wantUser: &model.User{
+			previousUser: &model.User{
 				ID:        pointer.ToString("some-test-id"),
-				FirstName: pointer.ToString("testFirst"),
+				FirstName: pointer.ToString("oldFirst"),
 				LastName:  pointer.ToString("testLast"),
 				Nickname:  pointer.ToString("test"),
 				Password:  pointer.ToString("test"),
@@ -423,6 +580,42 @@ func TestUsers_UpdateUser_Success(t *testing.T) {
 				CreatedAt: pointer.ToTime(time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)),
 				UpdatedAt: pointer.ToTime(time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)),
 			},
+			wantUser: &model.User{
+				ID:        pointer.ToString("some-test-id"),
+				FirstName: pointer.ToString("testFirst"),
+				LastName:  pointer.ToString("testLast"),
+				Nickname:  pointer.ToString("test"),
+				Password:  pointer.ToString("newPassword"),
+				Email:     pointer.ToString("test@test.com"),
+				Country:   pointer.ToString("UK"),
+				CreatedAt: pointer.ToTime(time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)),
+				UpdatedAt: pointer.ToTime(time.Date(2020, time.January, 2, 0, 0, 0, 0, time.UTC)),
+			},
+			wantEvent: events.UserEvent{
+				EventType: events.EventTypeUserUpdated,
+				ID:        "some-test-id",
+				Before: &model.User{
+					ID:        pointer.ToString("some-test-id"),
+					FirstName: pointer.ToString("oldFirst"),
+					LastName:  pointer.ToString("testLast"),
+					Nickname:  pointer.ToString("test"),
+					Email:     pointer.ToString("test@test.com"),
+					Country:   pointer.ToString("UK"),
+					CreatedAt: pointer.ToTime(time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)),
+					UpdatedAt: pointer.ToTime(time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)),
+				},
+				After: &model.User{
+					ID:        pointer.ToString("some-test-id"),
+					FirstName: pointer.ToString("testFirst"),
+					LastName:  pointer.ToString("testLast"),
+					Nickname:  pointer.ToString("test"),
+					Email:     pointer.ToString("test@test.com"),
+					Country:   pointer.ToString("UK"),
+					CreatedAt: pointer.ToTime(time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)),
+					UpdatedAt: pointer.ToTime(time.Date(2020, time.January, 2, 0, 0, 0, 0, time.UTC)),
+				},
+				ChangedFields: []string{"first_name", "password", "updated_at"},
+			},
 		},
 	}
 	for _, tt := range tests {
@@ -438,12 +631,8 @@ func TestUsers_UpdateUser_Success(t *testing.T) {
 
 			ctx := context.Background()
 
-			s.EXPECT().UpdateUser(gomock.Any(), tt.args.user).Return(tt.wantUser, nil).Times(1)
-			e.EXPECT().Produce(gomock.Any(), events.TopicUsers, events.UserEvent{
-				EventType: events.EventTypeUserUpdated,
-				ID:        *tt.wantUser.ID,
-				User:      tt.wantUser,
-			}).Times(1)
+			s.EXPECT().UpdateUser(gomock.Any(), tt.args.user).Return(tt.wantUser, tt.previousUser, nil).Times(1)
+			e.EXPECT().Produce(gomock.Any(), events.TopicUsers, tt.wantEvent).Times(1)
 
 			updatedUser, err := u.UpdateUser(ctx, tt.args.user)
 			assert.NoError(t, err)
@@ -490,7 +679,7 @@ func TestUsers_UpdateUser_Error(t *testing.T) {
 
 			ctx := context.Background()
 
-			s.EXPECT().UpdateUser(gomock.Any(), tt.args.user).Return(nil, tt.wantErr).Times(1)
+			s.EXPECT().UpdateUser(gomock.Any(), tt.args.user).Return(nil, nil, tt.wantErr).Times(1)
 
 			updatedUser, err := u.UpdateUser(ctx, tt.args.user)
 			assert.ErrorIs(t, err, tt.wantErr)
@@ -504,14 +693,43 @@ func TestUsers_DeleteUser_Success(t *testing.T) {
 		id string
 	}
 	tests := []struct {
-		name string
-		args args
+		name        string
+		args        args
+		deletedUser *model.User
+		wantEvent   events.UserEvent
 	}{
 		{
 			name: "success",
 			args: args{
 				id: "some-test-id",
 			},
+			deletedUser: &model.User{
+				ID:        pointer.ToString("some-test-id"),
+				FirstName: pointer.ToString("testFirst"),
+				LastName:  pointer.ToString("testLast"),
+				Nickname:  pointer.ToString("test"),
+				Password:  pointer.ToString("test"),
+				Email:     pointer.ToString("test@test.com"),
+				Country:   pointer.ToString("UK"),
+				CreatedAt: pointer.ToTime(time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)),
+				UpdatedAt: pointer.ToTime(time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)),
+			},
+			wantEvent: events.UserEvent{
+				EventType: events.EventTypeUserDeleted,
+				ID:        "some-test-id",
+				Before: &model.User{
+					ID:        pointer.ToString("some-test-id"),
+					FirstName: pointer.ToString("testFirst"),
+					LastName:  pointer.ToString("testLast"),
+					Nickname:  pointer.ToString("test"),
+					Email:     pointer.ToString("test@test.com"),
+					Country:   pointer.ToString("UK"),
+					CreatedAt: pointer.ToTime(time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)),
+					UpdatedAt: pointer.ToTime(time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)),
+				},
+				After:         nil,
+				ChangedFields: []string{"id", "first_name", "last_name", "nickname", "password", "email", "country", "created_at", "updated_at"},
+			},
 		},
 	}
 	for _, tt := range tests {
@@ -527,11 +745,8 @@ func TestUsers_DeleteUser_Success(t *testing.T) {
 
 			ctx := context.Background()
 
-			s.EXPECT().DeleteUser(gomock.Any(), tt.args.id).Return(nil).Times(1)
-			e.EXPECT().Produce(gomock.Any(), events.TopicUsers, events.UserEvent{
-				EventType: events.EventTypeUserDeleted,
-				ID:        tt.args.id,
-			}).Times(1)
+			s.EXPECT().DeleteUser(gomock.Any(), tt.args.id).Return(tt.deletedUser, nil).Times(1)
+			e.EXPECT().Produce(gomock.Any(), events.TopicUsers, tt.wantEvent).Times(1)
 
 			err := u.DeleteUser(ctx, tt.args.id)
 			assert.NoError(t, err)
@@ -569,10 +784,65 @@ func TestUsers_DeleteUser_Error(t *testing.T) {
 
 			ctx := context.Background()
 
-			s.EXPECT().DeleteUser(gomock.Any(), tt.args.id).Return(tt.wantErr).Times(1)
+			s.EXPECT().DeleteUser(gomock.Any(), tt.args.id).Return(nil, tt.wantErr).Times(1)
 
 			err := u.DeleteUser(ctx, tt.args.id)
 			assert.ErrorIs(t, err, tt.wantErr)
 		})
 	}
 }
+
+func TestUsers_Events_Delivered(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s := mocks.NewMockStore(ctrl)
+	b := eventstest.New(t)
+
+	u := users.New(s, b)
+
+	ctx := context.Background()
+
+	created := &model.User{
+		ID:        pointer.ToString("some-test-id"),
+		FirstName: pointer.ToString("testFirst"),
+		Password:  pointer.ToString("test"),
+		Email:     pointer.ToString("test@test.com"),
+	}
+	updated := &model.User{
+		ID:        pointer.ToString("some-test-id"),
+		FirstName: pointer.ToString("testFirstUpdated"),
+		Password:  pointer.ToString("test"),
+		Email:     pointer.ToString("test@test.com"),
+	}
+
+	s.EXPECT().InsertUser(gomock.Any(), gomock.Any()).Return(created, nil).Times(1)
+	s.EXPECT().UpdateUser(gomock.Any(), gomock.Any()).Return(updated, created, nil).Times(1)
+	s.EXPECT().DeleteUser(gomock.Any(), "some-test-id").Return(updated, nil).Times(1)
+	s.EXPECT().DeleteUser(gomock.Any(), "some-missing-id").Return(nil, errors.ErrNotFound).Times(1)
+
+	_, err := u.CreateUser(ctx, &model.User{})
+	require.NoError(t, err)
+	_, err = u.UpdateUser(ctx, &model.User{})
+	require.NoError(t, err)
+	require.NoError(t, u.DeleteUser(ctx, "some-test-id"))
+
+	e := b.AwaitUserEvent(eventstest.IsUserEvent(events.EventTypeUserCreated, "some-test-id"), time.Second)
+	assert.Nil(t, e.Before)
+	assert.Nil(t, e.After.Password)
+
+	msg := b.AwaitEvent(events.TopicUsers, func(msg events.Message) bool {
+		return msg.Key == "some-test-id"
+	}, time.Second)
+	e, err = events.DecodeUserEvent(msg)
+	require.NoError(t, err)
+	assert.Equal(t, events.EventTypeUserUpdated, e.EventType)
+	assert.Equal(t, []string{"first_name"}, e.ChangedFields)
+
+	e = b.AwaitUserEvent(eventstest.IsUserEvent(events.EventTypeUserDeleted, "some-test-id"), time.Second)
+	assert.Nil(t, e.After)
+
+	// Failed operations don't produce events
+	require.ErrorIs(t, u.DeleteUser(ctx, "some-missing-id"), errors.ErrNotFound)
+	b.AssertNoEvents(events.TopicUsers)
+}
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_dead_letters_first_failed_at;
+
+DROP INDEX IF EXISTS idx_dead_letters_topic_sink;
+
+DROP TABLE IF EXISTS dead_letters;
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS dead_letters (
+    id uuid DEFAULT uuid_generate_v4 (),
+    event_id VARCHAR (255) NOT NULL,
+    topic VARCHAR (255) NOT NULL,
+    sink VARCHAR (255) NOT NULL,
+    key VARCHAR (255) NOT NULL,
+    content_type VARCHAR (255) NOT NULL,
+    headers jsonb NOT NULL DEFAULT '{}',
+    payload bytea NOT NULL,
+    error TEXT NOT NULL,
+    attempts INTEGER NOT NULL CHECK (attempts > 0),
+    produced_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    first_failed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_failed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (id)
+);
+
+/* supports filtering dead letters by destination */
+CREATE INDEX idx_dead_letters_topic_sink ON dead_letters (topic, sink);
+
+CREATE INDEX idx_dead_letters_first_failed_at ON dead_letters (first_failed_at);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS processed_events (
+    consumer VARCHAR (255) NOT NULL,
+    event_id VARCHAR (255) NOT NULL,
+    claimed_until TIMESTAMP WITH TIME ZONE NOT NULL,
+    processed_at TIMESTAMP WITH TIME ZONE,
+    PRIMARY KEY (consumer, event_id)
+);
+
+/* supports pruning old processed events */
+CREATE INDEX idx_processed_events_processed_at ON processed_events (processed_at);
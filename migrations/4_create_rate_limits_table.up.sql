@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS rate_limits (
+    key VARCHAR (512) PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    allowed BOOLEAN NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    full_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+/* supports pruning buckets that have refilled */
+CREATE INDEX idx_rate_limits_full_at ON rate_limits (full_at);
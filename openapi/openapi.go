@@ -0,0 +1,11 @@
+// Package openapi embeds the OpenAPI document describing the service's REST API.
+package openapi
+
+import (
+	_ "embed"
+)
+
+// Spec is the OpenAPI document in YAML.
+//
+//go:embed openapi.yaml
+var Spec []byte